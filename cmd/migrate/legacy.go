@@ -0,0 +1,36 @@
+package main
+
+import (
+	"context"
+	"log"
+
+	"cloud.google.com/go/firestore"
+
+	firestoreRepo "github.com/spalqui/habitattrack-api/pkg/firestore"
+)
+
+// copyLegacy copies data written before tenant scoping, when every document
+// lived in a root collection, into an organization's collections.
+func copyLegacy(ctx context.Context, client *firestore.Client, orgID string, dryRun bool) {
+	if orgID == "" {
+		log.Fatal("-org is required")
+	}
+
+	counts, err := firestoreRepo.CopyLegacyCollections(ctx, client, orgID, dryRun, func(collection string, copied int) {
+		log.Printf("%s: %d documents", collection, copied)
+	})
+	if err != nil {
+		log.Fatalf("Migration failed: %v", err)
+	}
+
+	total := 0
+	for _, count := range counts {
+		total += count
+	}
+
+	if dryRun {
+		log.Printf("Dry run: %d documents would be copied into organization %s", total, orgID)
+		return
+	}
+	log.Printf("Copied %d documents into organization %s", total, orgID)
+}
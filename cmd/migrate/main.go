@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/spalqui/habitattrack-api/internal/config"
+	"github.com/spalqui/habitattrack-api/internal/storage"
+	firestoreRepo "github.com/spalqui/habitattrack-api/pkg/firestore"
+	"github.com/spalqui/habitattrack-api/pkg/money"
+)
+
+const usage = `Usage: migrate <command> [flags]
+
+Commands:
+  up            apply pending migrations
+  status        list migrations and when they were applied
+  copy-legacy   copy data from the root collections used before tenant
+                scoping into an organization (-org); a user's personal
+                organization has their user ID
+  rebuild-rollups
+                recompute the monthly rollups from the transactions
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	flags := flag.NewFlagSet(os.Args[1], flag.ExitOnError)
+	dryRun := flags.Bool("dry-run", false, "report what would change without writing")
+	orgID := flags.String("org", "", "ID of the organization to copy legacy data into (copy-legacy)")
+	flags.Parse(os.Args[2:])
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+	if err := money.SetCurrency(cfg.Currency); err != nil {
+		log.Fatalf("Invalid CURRENCY: %v", err)
+	}
+	ctx := context.Background()
+
+	client, err := storage.NewFirestoreClient(ctx, cfg)
+	if err != nil {
+		log.Fatalf("Failed to create Firestore client: %v", err)
+	}
+	defer client.Close()
+
+	switch os.Args[1] {
+	case "up":
+		applied, err := firestoreRepo.RunMigrations(ctx, client, *dryRun)
+		for _, migration := range applied {
+			if *dryRun {
+				log.Printf("Would apply %d: %s", migration.Version, migration.Name)
+			} else {
+				log.Printf("Applied %d: %s", migration.Version, migration.Name)
+			}
+		}
+		if err != nil {
+			log.Fatalf("Migration failed: %v", err)
+		}
+		if len(applied) == 0 {
+			log.Print("No pending migrations")
+		}
+
+	case "status":
+		statuses, err := firestoreRepo.MigrationStatuses(ctx, client)
+		if err != nil {
+			log.Fatalf("Failed to read migrations: %v", err)
+		}
+		for _, migrationStatus := range statuses {
+			applied := "pending"
+			if migrationStatus.AppliedAt != nil {
+				applied = migrationStatus.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%4d  %-40s %s\n", migrationStatus.Version, migrationStatus.Name, applied)
+		}
+
+	case "copy-legacy":
+		copyLegacy(ctx, client, *orgID, *dryRun)
+
+	case "rebuild-rollups":
+		rebuilt, err := firestoreRepo.NewRollupRepository(client).Rebuild(ctx)
+		if err != nil {
+			log.Fatalf("Rebuilding rollups failed: %v", err)
+		}
+		log.Printf("Rebuilt %d rollups", rebuilt)
+
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"github.com/spalqui/habitattrack-api/internal/config"
+	"github.com/spalqui/habitattrack-api/internal/models"
+	"github.com/spalqui/habitattrack-api/internal/services"
+	"github.com/spalqui/habitattrack-api/internal/storage"
+)
+
+// restore replays a backup into the configured database, by default only
+// when it is empty.
+func main() {
+	backup := flag.String("backup", "", "name of a backup in BACKUP_BUCKET to restore")
+	file := flag.String("file", "", "path of a local backup file to restore")
+	prefix := flag.String("prefix", "", "document path to restore beneath instead of the database root, e.g. restores/2026-01-31")
+	dryRun := flag.Bool("dry-run", false, "validate the backup without writing")
+	force := flag.Bool("force", false, "restore even if the target already has data")
+	flag.Parse()
+
+	if (*backup == "") == (*file == "") {
+		log.Fatal("Exactly one of -backup and -file is required")
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+	ctx := context.Background()
+
+	repos, err := storage.New(ctx, cfg)
+	if err != nil {
+		log.Fatalf("Failed to initialize %s storage: %v", cfg.StorageBackend, err)
+	}
+	defer repos.Close()
+
+	backupService := services.NewBackupService(repos.Documents, repos.Backups, 0)
+
+	open := func() (io.ReadCloser, error) {
+		return os.Open(*file)
+	}
+	if *backup != "" {
+		if repos.Backups == nil {
+			log.Fatal("BACKUP_BUCKET is required to restore a stored backup")
+		}
+		open = func() (io.ReadCloser, error) {
+			return backupService.OpenBackup(ctx, *backup)
+		}
+	}
+
+	start := time.Now()
+	options := models.RestoreOptions{Prefix: *prefix, DryRun: *dryRun, Force: *force}
+	restored, err := backupService.RestoreBackup(ctx, open, options, func(restored int) {
+		log.Printf("Restored %d documents", restored)
+	})
+	if err != nil {
+		log.Fatalf("Restore failed after %d documents: %v", restored, err)
+	}
+
+	if *dryRun {
+		log.Printf("Dry run: backup is valid, %d documents would be restored", restored)
+		return
+	}
+	log.Printf("Restored %d documents in %v", restored, time.Since(start).Round(time.Second))
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log"
+	"math/rand"
+	"time"
+
+	"github.com/spalqui/habitattrack-api/internal/config"
+	"github.com/spalqui/habitattrack-api/internal/models"
+	"github.com/spalqui/habitattrack-api/internal/repositories"
+	"github.com/spalqui/habitattrack-api/internal/services"
+	"github.com/spalqui/habitattrack-api/internal/storage"
+	"github.com/spalqui/habitattrack-api/pkg/auth"
+	"github.com/spalqui/habitattrack-api/pkg/money"
+	"github.com/spalqui/habitattrack-api/pkg/sanitize"
+	"github.com/spalqui/habitattrack-api/pkg/tasks"
+)
+
+var sampleProperties = []models.Property{
+	{Name: "Harbour View Flat", Address: "12 Quay Street", Postcode: "BS1 4DJ", Description: "Two bedroom flat"},
+	{Name: "Elm Cottage", Address: "3 Elm Lane", Postcode: "BA2 6PL", Description: "Three bedroom house"},
+	{Name: "Mill Road Studio", Address: "48 Mill Road", Postcode: "CB1 2AD", Description: "Studio apartment"},
+}
+
+var sampleCategories = []models.Category{
+	{Name: "Rent", Type: models.TransactionTypeIncome},
+	{Name: "Late Fees", Type: models.TransactionTypeIncome},
+	{Name: "Repairs", Type: models.TransactionTypeExpense},
+	{Name: "Insurance", Type: models.TransactionTypeExpense},
+	{Name: "Utilities", Type: models.TransactionTypeExpense},
+	{Name: "Property Tax", Type: models.TransactionTypeExpense},
+	{Name: "Management Fees", Type: models.TransactionTypeExpense},
+}
+
+// monthlyRent is each sample property's rent, by index.
+var monthlyRent = []float64{1150, 1475, 825}
+
+// seed fills a development database with sample properties, categories and
+// a year of transactions. Don't run it against production.
+func main() {
+	orgID := flag.String("org", "", "ID of the organization to seed; empty seeds the root collections used with authentication disabled")
+	randomSeed := flag.Int64("seed", 1, "random seed, so runs are reproducible")
+	flag.Parse()
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+	if err := money.SetCurrency(cfg.Currency); err != nil {
+		log.Fatalf("Invalid CURRENCY: %v", err)
+	}
+	ctx := context.Background()
+	if *orgID != "" {
+		ctx = auth.WithPrincipal(ctx, &auth.Principal{UserID: "seed", OrgID: *orgID, Role: auth.RoleOwner})
+	}
+
+	repos, err := storage.New(ctx, cfg)
+	if err != nil {
+		log.Fatalf("Failed to initialize %s storage: %v", cfg.StorageBackend, err)
+	}
+	defer repos.Close()
+
+	// Seed data isn't recorded in the audit log, though its webhook events are
+	// queued in the outbox like any other write's
+	publisher := services.MultiPublisher()
+	propertyService := services.NewPropertyService(repos.Properties, repos.Transactions, publisher, sanitize.Policy{})
+	categoryService := services.NewCategoryService(repos.Categories, repos.Transactions, publisher, sanitize.Policy{})
+	transactionService := services.NewTransactionService(repos.Transactions, repos.Categories, repos.Properties, repos.Organizations, publisher, tasks.NewLocalQueue(tasks.NewRegistry()), 0, sanitize.Policy{})
+
+	properties := make([]*models.Property, len(sampleProperties))
+	for i := range sampleProperties {
+		property, err := ensureProperty(ctx, propertyService, repos.Properties, sampleProperties[i])
+		if err != nil {
+			log.Fatalf("Failed to create property %q: %v", sampleProperties[i].Name, err)
+		}
+		properties[i] = property
+	}
+	log.Printf("Created %d properties", len(properties))
+
+	categories := make(map[string]*models.Category)
+	for _, sample := range sampleCategories {
+		category, err := ensureCategory(ctx, categoryService, sample)
+		if err != nil {
+			log.Fatalf("Failed to create category %q: %v", sample.Name, err)
+		}
+		categories[category.Name] = category
+	}
+	log.Printf("Using %d categories", len(categories))
+
+	transactions := sampleTransactions(rand.New(rand.NewSource(*randomSeed)), properties, categories, time.Now())
+	result, err := transactionService.CreateTransactions(ctx, transactions)
+	if err != nil {
+		log.Fatalf("Failed to create transactions: %v", err)
+	}
+	if err := result.Err(); err != nil {
+		log.Printf("%d transactions failed, first error: %v", result.Failed, err)
+	}
+	log.Printf("Created %d transactions", result.Succeeded)
+}
+
+// ensureProperty creates the property, or reuses the one of the same name
+// left by an earlier run.
+func ensureProperty(ctx context.Context, propertyService services.PropertyService, propertyRepo repositories.PropertyRepository, property models.Property) (*models.Property, error) {
+	err := propertyService.CreateProperty(ctx, &property)
+	if err == nil {
+		return &property, nil
+	}
+	if !errors.Is(err, repositories.ErrPropertyNameExists) {
+		return nil, err
+	}
+
+	existing, err := propertyRepo.FindByName(ctx, property.Name)
+	if err != nil {
+		return nil, err
+	}
+	if existing == nil {
+		return nil, repositories.ErrPropertyNameExists
+	}
+	return existing, nil
+}
+
+// ensureCategory creates the category, or reuses the one of the same name
+// left by an earlier run.
+func ensureCategory(ctx context.Context, categoryService services.CategoryService, category models.Category) (*models.Category, error) {
+	err := categoryService.CreateCategory(ctx, &category)
+	if err == nil {
+		return &category, nil
+	}
+	if !errors.Is(err, repositories.ErrCategoryNameExists) {
+		return nil, err
+	}
+
+	existing, err := categoryService.GetCategoriesByType(ctx, category.Type)
+	if err != nil {
+		return nil, err
+	}
+	for _, candidate := range existing {
+		if candidate.Name == category.Name {
+			return candidate, nil
+		}
+	}
+	return nil, repositories.ErrCategoryNameExists
+}
+
+// sampleTransactions returns twelve months of rent for each property, with
+// the occasional late fee and a realistic spread of running costs.
+func sampleTransactions(random *rand.Rand, properties []*models.Property, categories map[string]*models.Category, now time.Time) []*models.Transaction {
+	var transactions []*models.Transaction
+	add := func(property *models.Property, categoryName string, amount float64, date time.Time, description string) {
+		category := categories[categoryName]
+		exact, err := money.FromFloat(amount)
+		if err != nil {
+			// The sample amounts are all a few thousand at most.
+			panic(err)
+		}
+		transactions = append(transactions, &models.Transaction{
+			PropertyID:  property.ID,
+			CategoryID:  category.ID,
+			Type:        category.Type,
+			Amount:      exact,
+			Date:        date,
+			Description: description,
+		})
+	}
+
+	start := time.Date(now.Year()-1, now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	for month := 0; month < 12; month++ {
+		first := start.AddDate(0, month, 0)
+
+		for i, property := range properties {
+			rent := monthlyRent[i%len(monthlyRent)]
+			add(property, "Rent", rent, first.AddDate(0, 0, random.Intn(3)), first.Format("January 2006")+" rent")
+
+			if random.Float64() < 0.08 {
+				add(property, "Late Fees", 50, first.AddDate(0, 0, 10), "Late payment fee")
+			}
+
+			add(property, "Management Fees", rent*0.1, first.AddDate(0, 0, 5), "Letting agent fee")
+			add(property, "Utilities", 40+random.Float64()*60, first.AddDate(0, 0, 15), "Communal utilities")
+
+			if random.Float64() < 0.3 {
+				add(property, "Repairs", 60+random.Float64()*400, first.AddDate(0, 0, 1+random.Intn(27)), "Maintenance call-out")
+			}
+
+			switch month {
+			case 0:
+				add(property, "Insurance", 280+random.Float64()*120, first.AddDate(0, 0, 20), "Annual landlord insurance")
+			case 3, 9:
+				add(property, "Property Tax", rent*0.6, first.AddDate(0, 0, 25), "Half-yearly property tax")
+			}
+		}
+	}
+
+	return transactions
+}
@@ -2,82 +2,603 @@ package main
 
 import (
 	"context"
+	"crypto/rand"
+	"expvar"
+	"fmt"
 	"log"
+	"log/slog"
 	"net/http"
+	"net/http/pprof"
+	"net/url"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
-	"cloud.google.com/go/firestore"
 	"github.com/gorilla/mux"
-	"google.golang.org/api/option"
+	"github.com/redis/go-redis/v9"
+	"golang.org/x/crypto/acme/autocert"
 
 	"github.com/spalqui/habitattrack-api/internal/config"
+	"github.com/spalqui/habitattrack-api/internal/graphql"
 	"github.com/spalqui/habitattrack-api/internal/handlers"
+	"github.com/spalqui/habitattrack-api/internal/models"
 	"github.com/spalqui/habitattrack-api/internal/services"
-	firestoreRepo "github.com/spalqui/habitattrack-api/pkg/firestore"
+	"github.com/spalqui/habitattrack-api/internal/storage"
+	"github.com/spalqui/habitattrack-api/pkg/auth"
+	"github.com/spalqui/habitattrack-api/pkg/buildinfo"
+	"github.com/spalqui/habitattrack-api/pkg/errorreport"
 	"github.com/spalqui/habitattrack-api/pkg/middleware"
+	"github.com/spalqui/habitattrack-api/pkg/money"
+	"github.com/spalqui/habitattrack-api/pkg/pagetoken"
+	"github.com/spalqui/habitattrack-api/pkg/pubsub"
+	"github.com/spalqui/habitattrack-api/pkg/ratelimit"
+	"github.com/spalqui/habitattrack-api/pkg/sanitize"
+	"github.com/spalqui/habitattrack-api/pkg/scheduler"
+	"github.com/spalqui/habitattrack-api/pkg/tasks"
+	"github.com/spalqui/habitattrack-api/pkg/utils"
 )
 
 func main() {
-	cfg := config.Load()
+	// Log as JSON, including messages from the standard logger
+	logLevel := new(slog.LevelVar)
+	slog.SetDefault(slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: logLevel})))
 
-	// Initialize Firestore client
-	ctx := context.Background()
-	var client *firestore.Client
-	var err error
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+	if err := logLevel.UnmarshalText([]byte(cfg.LogLevel)); err != nil {
+		log.Fatalf("Invalid LOG_LEVEL %q: %v", cfg.LogLevel, err)
+	}
+	utils.SetErrorFormat(cfg.ErrorFormat)
+	utils.SetFieldNaming(cfg.JSONFieldNaming)
+	if err := money.SetCurrency(cfg.Currency); err != nil {
+		log.Fatalf("Invalid CURRENCY: %v", err)
+	}
+	errorReporter := newErrorReporter(cfg)
+	utils.SetErrorReporter(errorReporter)
 
-	if cfg.FirestoreKeyPath != "" {
-		client, err = firestore.NewClientWithDatabase(ctx, cfg.GoogleProject, "habitattrack", option.WithCredentialsFile(cfg.FirestoreKeyPath))
-	} else {
-		client, err = firestore.NewClientWithDatabase(ctx, cfg.GoogleProject, "habitattrack")
+	location, err := time.LoadLocation(cfg.Timezone)
+	if err != nil {
+		log.Fatalf("Invalid timezone %q: %v", cfg.Timezone, err)
 	}
+
+	ctx := context.Background()
+	repos, err := storage.New(ctx, cfg)
 	if err != nil {
-		log.Fatalf("Failed to create Firestore client: %v", err)
+		log.Fatalf("Failed to initialize %s storage: %v", cfg.StorageBackend, err)
 	}
-	defer client.Close()
+	defer repos.Close()
 
-	// Initialize repositories
-	propertyRepo := firestoreRepo.NewPropertyRepository(client)
-	transactionRepo := firestoreRepo.NewTransactionRepository(client)
-	categoryRepo := firestoreRepo.NewCategoryRepository(client)
+	if cfg.StartupChecks != "off" {
+		runStartupChecks(ctx, cfg, repos)
+	}
+
+	// Background jobs stop, and the server drains, on SIGINT or SIGTERM
+	ctx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
 
 	// Initialize services
-	propertyService := services.NewPropertyService(propertyRepo)
-	transactionService := services.NewTransactionService(transactionRepo, categoryRepo, propertyRepo)
-	categoryService := services.NewCategoryService(categoryRepo)
+	webhookDispatcher := services.NewWebhookDispatcher(repos.Webhooks, repos.WebhookDeliveries, repos.DeliveryQueue, repos.DeadLetters)
+	auditService := services.NewAuditService(repos.AuditEvents)
+	// Events are written to the outbox with each change and relayed from
+	// there to webhooks and Pub/Sub; the audit trail is recorded as the change
+	// is made
+	publisher := auditService
+	eventSinks := []services.EventSink{webhookDispatcher}
+	if cfg.PubSubTopic != "" {
+		eventSinks = append(eventSinks, newPubSubPublisher(ctx, cfg))
+	}
+	outboxRelay := services.NewOutboxRelay(repos.Outbox, repos.DeadLetters, eventSinks...)
+	go outboxRelay.Schedule(ctx, time.Duration(cfg.OutboxPollMs)*time.Millisecond)
+	// The relay queues a delivery per webhook, sent from the queue like the
+	// outbox's events
+	go webhookDispatcher.Schedule(ctx, time.Duration(cfg.OutboxPollMs)*time.Millisecond)
+	text := sanitize.Policy{
+		MaxNameLength:        cfg.MaxNameLength,
+		MaxDescriptionLength: cfg.MaxDescriptionLen,
+		EscapeHTML:           cfg.EscapeHTML,
+	}
+	propertyService := services.NewPropertyService(repos.Properties, repos.Transactions, publisher, text)
+	taskRegistry := tasks.NewRegistry()
+	taskQueue, taskCallers := newTaskQueue(ctx, cfg, taskRegistry)
+	transactionService := services.NewTransactionService(repos.Transactions, repos.Categories, repos.Properties, repos.Organizations, publisher, taskQueue, time.Duration(cfg.MaxDateRangeDays)*24*time.Hour, text)
+	services.RegisterTransactionTasks(taskRegistry, transactionService)
+	categoryService := services.NewCategoryService(repos.Categories, repos.Transactions, publisher, text)
+	webhookService := services.NewWebhookService(repos.Webhooks, repos.WebhookDeliveries)
+	apiKeyService := services.NewAPIKeyService(repos.APIKeys)
+	memberService := services.NewMemberService(repos.Members)
+	organizationService := services.NewOrganizationService(repos.Organizations, repos.Members)
+	tokenSecret := oauthTokenSecret(cfg)
+	clientTokens := auth.NewClientTokens(tokenSecret, time.Hour)
+	oauthClientService := services.NewOAuthClientService(repos.OAuthClients, clientTokens)
+	revocationService := services.NewRevocationService(repos.Revocations)
+	impersonationService := services.NewImpersonationService(repos.Impersonations, revocationService, clientTokens)
+	consentService := services.NewConsentService(repos.Consents, consentRequirements(cfg))
+	accountService := services.NewAccountService(repos.Accounts, repos.Members, revocationService, taskQueue, tokenSecret)
+	services.RegisterAccountTasks(taskRegistry, accountService)
+	reportService := services.NewReportService(repos.Rollups)
+	maintenanceService := services.NewMaintenanceService(repos.Maintenance)
+	cleanupService := services.NewCleanupService(repos.Cleanup,
+		time.Duration(cfg.TrashRetentionDays)*24*time.Hour,
+		time.Duration(cfg.WebhookLogDays)*24*time.Hour)
+
+	// Each occurrence of a scheduled job runs on a single instance
+	jobs := scheduler.New(repos.JobLocks, instanceName(), slog.Default())
+	addJob(jobs, "rebuild-rollups", jobSchedule(cfg.RollupSchedule, cfg.RollupRebuildHours), reportService.RunScheduled)
+	addJob(jobs, "cleanup", jobSchedule(cfg.CleanupSchedule, cfg.CleanupHours), cleanupService.RunScheduled)
+	addJob(jobs, "purge-accounts", cfg.DeletionSchedule, accountService.RunScheduled)
 
 	// Initialize handlers
-	propertyHandler := handlers.NewPropertyHandler(propertyService)
-	transactionHandler := handlers.NewTransactionHandler(transactionService)
-	categoryHandler := handlers.NewCategoryHandler(categoryService)
+	pageTokens := pagetoken.NewCodec(tokenSecret)
+	propertyHandler := handlers.NewPropertyHandler(propertyService, pageTokens)
+	transactionHandler := handlers.NewTransactionHandler(transactionService, location, pageTokens)
+	categoryHandler := handlers.NewCategoryHandler(categoryService, pageTokens)
+	webhookHandler := handlers.NewWebhookHandler(webhookService)
+	apiKeyHandler := handlers.NewAPIKeyHandler(apiKeyService)
+	memberHandler := handlers.NewMemberHandler(memberService)
+	organizationHandler := handlers.NewOrganizationHandler(organizationService)
+	oauthHandler := handlers.NewOAuthHandler(oauthClientService)
+	revocationHandler := handlers.NewRevocationHandler(revocationService)
+	accountHandler := handlers.NewAccountHandler(accountService)
+	impersonationHandler := handlers.NewImpersonationHandler(impersonationService)
+	consentHandler := handlers.NewConsentHandler(consentService)
+	auditHandler := handlers.NewAuditHandler(auditService)
+	reportHandler := handlers.NewReportHandler(reportService)
+	maintenanceHandler := handlers.NewMaintenanceHandler(maintenanceService)
+	deadLetterHandler := handlers.NewDeadLetterHandler(services.NewDeadLetterService(repos.DeadLetters, repos.Webhooks, repos.Outbox, webhookDispatcher))
+	var taskHandler *handlers.TaskHandler
+	if taskCallers != nil {
+		taskHandler = handlers.NewTaskHandler(taskRegistry)
+	}
+	logLevelHandler := handlers.NewLogLevelHandler(services.NewLogLevelService(logLevel))
+	var redisClient *redis.Client
+	if cfg.RateLimitEnabled && cfg.RateLimitBackend == "redis" {
+		redisClient = redis.NewClient(&redis.Options{Addr: cfg.RedisAddr})
+		defer redisClient.Close()
+	}
+	healthComponents := []services.HealthComponent{
+		{Name: "firestore", Check: repos.Ping, Required: true},
+		{Name: "webhookDispatcher", Check: webhookDispatcher.Check},
+	}
+	if redisClient != nil {
+		healthComponents = append(healthComponents, services.HealthComponent{Name: "cache", Check: func(ctx context.Context) error {
+			return redisClient.Ping(ctx).Err()
+		}})
+	}
+	healthHandler := handlers.NewHealthHandler(services.NewHealthService(healthComponents, 2*time.Second))
+	var backupHandler *handlers.BackupHandler
+	if repos.Backups != nil {
+		backupService := services.NewBackupService(repos.Documents, repos.Backups, time.Duration(cfg.BackupRetentionDays)*24*time.Hour)
+		backupHandler = handlers.NewBackupHandler(backupService)
+		addJob(jobs, "backup", jobSchedule(cfg.BackupSchedule, cfg.BackupIntervalHours), backupService.RunScheduled)
+	}
+	go jobs.Run(ctx)
+	graphqlHandler := graphql.NewHandler(propertyService, transactionService, categoryService, reportService)
 
 	// Setup routes
-	router := setupRoutes(propertyHandler, transactionHandler, categoryHandler)
+	legacySunset, err := time.Parse(time.DateOnly, cfg.LegacySunset)
+	if err != nil {
+		log.Fatalf("Invalid LEGACY_ROUTES_SUNSET %q: %v", cfg.LegacySunset, err)
+	}
+
+	requireMFA := func(next http.Handler) http.Handler { return next }
+	if cfg.AuthEnabled && cfg.MFARequired {
+		requireMFA = middleware.RequireMFA(time.Duration(cfg.MFAMaxAgeMinutes) * time.Minute)
+	}
+
+	corsOrigins := middleware.NewCORSOrigins(splitList(cfg.CORSOrigins))
+	router := setupRoutes(corsOrigins, propertyHandler, transactionHandler, categoryHandler, webhookHandler, apiKeyHandler, memberHandler, organizationHandler, oauthHandler, auditHandler, revocationHandler, accountHandler, impersonationHandler, consentHandler, reportHandler, healthHandler, maintenanceHandler, logLevelHandler, deadLetterHandler, backupHandler, taskHandler, taskCallers, graphqlHandler, legacySunset, requireMFA, cfg.PprofEnabled, errorReporter)
+
+	if cfg.CompressionEnabled {
+		router.Use(middleware.Compress(cfg.CompressionMinBytes))
+	}
+
+	// Purges, exports and queued tasks can touch every transaction, and
+	// profiles run for as long as asked, so they aren't cut short
+	router.Use(middleware.Timeout(middleware.Timeouts{
+		Read:  time.Duration(cfg.ReadTimeoutSeconds) * time.Second,
+		List:  time.Duration(cfg.ListTimeoutSeconds) * time.Second,
+		Write: time.Duration(cfg.WriteTimeoutSeconds) * time.Second,
+	}, "/transactions/purge", "/transactions/export", tasks.ReceiverPath, "/debug/pprof"))
+
+	router.Use(middleware.BodyLimit(cfg.MaxBodyBytes, cfg.MaxUploadBytes))
+
+	// Administrators can still reach the switch, and sign-in keeps working
+	router.Use(middleware.Maintenance(maintenanceService, "/admin", "/oauth/token"))
+
+	// Client IPs come from X-Forwarded-For only behind trusted proxies
+	proxyHops := 0
+	if cfg.TrustProxy {
+		proxyHops = cfg.TrustedProxyHops
+	}
+
+	if cfg.AdminAllowedCIDRs != "" {
+		networks, err := middleware.ParseCIDRs(cfg.AdminAllowedCIDRs)
+		if err != nil {
+			log.Fatalf("Invalid ADMIN_ALLOWED_CIDRS: %v", err)
+		}
+
+		// Admin, purge, metrics and profiling routes are only reachable from trusted networks
+		router.Use(middleware.IPAllowlist(networks, proxyHops, "/admin", "/transactions/purge", "/debug"))
+	}
+
+	// Addresses are limited before authentication, so failed sign-ins and
+	// bad API keys count too; callers are limited again once identified
+	var limiter ratelimit.Limiter
+	var rateLimitPolicy *ratelimit.DynamicPolicy
+	if cfg.RateLimitEnabled {
+		switch cfg.RateLimitBackend {
+		case "memory":
+			limiter = ratelimit.NewMemoryLimiter()
+		case "redis":
+			limiter = ratelimit.NewRedisLimiter(redisClient)
+		default:
+			log.Fatalf("Unknown rate limit backend %q", cfg.RateLimitBackend)
+		}
 
-	log.Printf("Server starting on port %s", cfg.Port)
-	log.Fatal(http.ListenAndServe(":"+cfg.Port, router))
+		policy, err := newRateLimitPolicy(cfg)
+		if err != nil {
+			log.Fatalf("Invalid RATE_LIMIT_OVERRIDES: %v", err)
+		}
+		rateLimitPolicy = ratelimit.NewDynamicPolicy(policy)
+		router.Use(middleware.RateLimitByIP(limiter, rateLimitPolicy, proxyHops))
+	}
+
+	if cfg.AuthEnabled {
+		if cfg.FirebaseProject == "" {
+			log.Fatal("FIREBASE_PROJECT_ID or GOOGLE_CLOUD_PROJECT is required when authentication is enabled")
+		}
+
+		verifier := auth.FirstOf(clientTokens, auth.NewFirebaseVerifier(cfg.FirebaseProject))
+		// The task receiver checks the task queue's own tokens instead
+		publicPaths := []string{"/health", "/livez", "/readyz", "/healthz", "/version", "/oauth/token"}
+		for _, name := range taskRegistry.Names() {
+			publicPaths = append(publicPaths, tasks.ReceiverPath+name)
+		}
+		router.Use(middleware.Authenticate(verifier, apiKeyService, revocationService, publicPaths...))
+	} else {
+		log.Println("Authentication is disabled; all data is shared by every caller")
+	}
+
+	// Per-caller limits run after authentication so buckets are keyed by principal
+	if cfg.RateLimitEnabled {
+		router.Use(middleware.RateLimit(limiter, rateLimitPolicy, proxyHops))
+	}
+
+	if cfg.AuthEnabled {
+		router.Use(middleware.Authorize(memberService, map[string]auth.Permission{
+			"/api-keys": auth.PermissionManage,
+			"/members":  auth.PermissionManage,
+			"/webhooks": auth.PermissionManage,
+			"/oauth":    auth.PermissionManage,
+			// The audit log shows every member's activity
+			"/audit-events": auth.PermissionManage,
+			// GraphQL only exposes queries
+			"/graphql": auth.PermissionRead,
+			// Organization routes check membership of the organization in the path
+			"/organizations": auth.PermissionRead,
+		}))
+
+		if len(consentService.GetRequirements()) > 0 {
+			// Users can still review and accept the documents, or delete their account
+			router.Use(middleware.RequireConsent(consentService, "/me", "/consent-requirements"))
+		}
+	}
+
+	server := &http.Server{
+		Addr:              ":" + cfg.Port,
+		Handler:           router,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	// Log level, CORS origins and rate limits follow the config file; other
+	// settings need a restart
+	go config.Watch(ctx, time.Duration(cfg.ReloadSeconds)*time.Second, func(reloaded *config.Config) {
+		logLevel.UnmarshalText([]byte(reloaded.LogLevel))
+		corsOrigins.Set(splitList(reloaded.CORSOrigins))
+		if rateLimitPolicy != nil {
+			policy, err := newRateLimitPolicy(reloaded)
+			if err != nil {
+				log.Printf("Keeping rate limits: invalid RATE_LIMIT_OVERRIDES: %v", err)
+			} else {
+				rateLimitPolicy.Store(policy)
+			}
+		}
+		log.Println("Reloaded configuration")
+	})
+
+	serve, challengeServer := tlsServing(cfg, server)
+	serverErr := make(chan error, 2)
+	go func() {
+		log.Printf("Server starting on port %s", cfg.Port)
+		serverErr <- serve()
+	}()
+	if challengeServer != nil {
+		go func() {
+			serverErr <- challengeServer.ListenAndServe()
+		}()
+	}
+
+	select {
+	case err := <-serverErr:
+		log.Fatalf("Server failed: %v", err)
+	case <-ctx.Done():
+	}
+
+	// Stop accepting connections and let in-flight requests finish; the
+	// deferred calls then close the storage and Redis clients
+	log.Println("Shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.ShutdownTimeout)*time.Second)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Shutdown timed out with requests in flight: %v", err)
+	}
+	if challengeServer != nil {
+		challengeServer.Shutdown(shutdownCtx)
+	}
 }
 
-func setupRoutes(propertyHandler *handlers.PropertyHandler, transactionHandler *handlers.TransactionHandler, categoryHandler *handlers.CategoryHandler) *mux.Router {
+// newPubSubPublisher publishes to PUBSUB_TOPIC, which is either a full topic
+// name or a topic in the service's own project.
+func newPubSubPublisher(ctx context.Context, cfg *config.Config) *pubsub.Publisher {
+	topic := cfg.PubSubTopic
+	if !strings.HasPrefix(topic, "projects/") {
+		topic = "projects/" + cfg.GoogleProject + "/topics/" + topic
+	}
+
+	publisher, err := pubsub.NewPublisher(ctx, topic)
+	if err != nil {
+		log.Fatalf("Failed to create Pub/Sub client: %v", err)
+	}
+	return publisher
+}
+
+// newTaskQueue queues tasks on Cloud Tasks when a queue is configured,
+// returning the verifier for its calls to the task receiver. Otherwise tasks
+// run in the background of this instance.
+func newTaskQueue(ctx context.Context, cfg *config.Config, registry *tasks.Registry) (tasks.Queue, middleware.TaskCallerVerifier) {
+	if cfg.TasksQueue == "" {
+		return tasks.NewLocalQueue(registry), nil
+	}
+
+	queue, err := tasks.NewCloudQueue(ctx, cfg.TasksQueue, cfg.TasksURL, cfg.TasksAccount)
+	if err != nil {
+		log.Fatalf("Failed to create Cloud Tasks client: %v", err)
+	}
+	callers, err := tasks.NewCallerVerifier(ctx, cfg.TasksURL, cfg.TasksAccount)
+	if err != nil {
+		log.Fatalf("Failed to create task token verifier: %v", err)
+	}
+	return queue, callers
+}
+
+// jobSchedule returns a job's cron schedule: its own setting when there is
+// one, otherwise every hours, or "" to leave the job off.
+func jobSchedule(schedule string, hours int) string {
+	if schedule != "" {
+		return schedule
+	}
+	if hours > 0 {
+		return fmt.Sprintf("@every %dh", hours)
+	}
+	return ""
+}
+
+func addJob(jobs *scheduler.Scheduler, name, schedule string, run func(ctx context.Context) error) {
+	if schedule == "" {
+		return
+	}
+	if err := jobs.Add(scheduler.Job{Name: name, Schedule: schedule, Run: run}); err != nil {
+		log.Fatalf("Failed to schedule %s: %v", name, err)
+	}
+}
+
+// instanceName identifies this process to the other instances sharing the
+// scheduled jobs.
+func instanceName() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return fmt.Sprintf("%s:%d", host, os.Getpid())
+}
+
+func newRateLimitPolicy(cfg *config.Config) (ratelimit.Policy, error) {
+	overrides, err := ratelimit.ParseOverrides(cfg.RateLimitOverrides)
+	if err != nil {
+		return ratelimit.Policy{}, err
+	}
+
+	return ratelimit.Policy{
+		Read:      ratelimit.Quota{Rate: cfg.RateLimitRate, Burst: cfg.RateLimitBurst},
+		Write:     ratelimit.Quota{Rate: cfg.RateLimitWriteRate, Burst: cfg.RateLimitWriteBurst},
+		Address:   ratelimit.Quota{Rate: cfg.RateLimitIPRate, Burst: cfg.RateLimitIPBurst},
+		Overrides: overrides,
+	}, nil
+}
+
+// splitList splits a comma-separated setting, dropping empty entries.
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// runStartupChecks verifies the configuration and every dependency before
+// the server takes traffic, logging a line per check. A failure stops
+// startup unless STARTUP_CHECKS is warn.
+func runStartupChecks(ctx context.Context, cfg *config.Config, repos *storage.Repositories) {
+	components := []services.HealthComponent{
+		{Name: "config", Check: func(context.Context) error { return cfg.Validate() }, Required: true},
+		{Name: "firestore", Check: repos.Ping, Required: true},
+		{Name: "firestoreIndexes", Check: repos.CheckIndexes, Required: true},
+	}
+	if repos.Backups != nil {
+		components = append(components, services.HealthComponent{Name: "backupBucket", Check: repos.Backups.CheckAccess, Required: true})
+	}
+	// Authenticated callers only see their organization's collections
+	if cfg.AuthEnabled {
+		components = append(components, services.HealthComponent{Name: "legacyData", Check: repos.CheckLegacyData, Required: true})
+	}
+
+	report := services.NewHealthService(components, 10*time.Second).CheckHealth(ctx)
+	for name, component := range report.Components {
+		level := slog.LevelInfo
+		if component.Status != models.HealthStatusUp {
+			level = slog.LevelError
+		}
+		slog.Log(ctx, level, "startup check",
+			slog.String("check", name),
+			slog.String("status", component.Status),
+			slog.Int64("latencyMs", component.LatencyMs),
+			slog.String("error", component.Error),
+		)
+	}
+
+	if report.Status == models.HealthStatusUp {
+		return
+	}
+	if cfg.StartupChecks == "warn" {
+		log.Println("Startup checks failed; starting anyway because STARTUP_CHECKS is warn")
+		return
+	}
+	log.Fatal("Startup checks failed; set STARTUP_CHECKS=warn to start regardless")
+}
+
+// newErrorReporter returns the configured error reporter, or nil when
+// reporting is off.
+func newErrorReporter(cfg *config.Config) errorreport.Reporter {
+	switch cfg.ErrorReporter {
+	case "none":
+		return nil
+	case "cloud":
+		return errorreport.NewCloudReporter(slog.Default(), cfg.ErrorService, buildinfo.Version)
+	default:
+		log.Fatalf("Unknown error reporter %q", cfg.ErrorReporter)
+		return nil
+	}
+}
+
+// tlsServing picks how the server listens: TLS with the configured
+// certificate, TLS with certificates obtained from Let's Encrypt for the
+// configured domain, or plain HTTP for deployments behind a load balancer
+// that terminates TLS. Autocert also answers HTTP-01 challenges, and
+// redirects everything else to HTTPS, from a second server on port 80.
+func tlsServing(cfg *config.Config, server *http.Server) (func() error, *http.Server) {
+	switch {
+	case cfg.TLSCertFile != "" || cfg.TLSKeyFile != "":
+		if cfg.TLSCertFile == "" || cfg.TLSKeyFile == "" {
+			log.Fatal("TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+		}
+		return func() error {
+			return server.ListenAndServeTLS(cfg.TLSCertFile, cfg.TLSKeyFile)
+		}, nil
+
+	case cfg.TLSDomain != "":
+		manager := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(cfg.TLSDomain),
+			Cache:      autocert.DirCache(cfg.TLSCacheDir),
+		}
+		server.TLSConfig = manager.TLSConfig()
+		challengeServer := &http.Server{
+			Addr:              ":80",
+			Handler:           manager.HTTPHandler(nil),
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+		return func() error {
+			return server.ListenAndServeTLS("", "")
+		}, challengeServer
+
+	default:
+		return server.ListenAndServe, nil
+	}
+}
+
+// consentRequirements returns the configured document versions users must
+// accept.
+func consentRequirements(cfg *config.Config) models.ConsentRequirements {
+	requirements := models.ConsentRequirements{}
+	if cfg.TermsVersion != "" {
+		requirements[models.ConsentDocumentTerms] = cfg.TermsVersion
+	}
+	if cfg.PrivacyVersion != "" {
+		requirements[models.ConsentDocumentPrivacy] = cfg.PrivacyVersion
+	}
+	return requirements
+}
+
+// cascadeRequested reports whether a property deletion also deletes its
+// transactions.
+func cascadeRequested(r *http.Request) bool {
+	cascade, _ := strconv.ParseBool(r.URL.Query().Get("cascade"))
+	return cascade
+}
+
+// oauthTokenSecret returns the key used to sign client credentials, account
+// deletion confirmation tokens and page tokens. A random key is generated
+// when none is configured, which invalidates issued tokens on every restart.
+func oauthTokenSecret(cfg *config.Config) []byte {
+	if cfg.OAuthTokenSecret != "" {
+		return []byte(cfg.OAuthTokenSecret)
+	}
+
+	log.Println("OAUTH_TOKEN_SECRET is not set; using a random key for this process")
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		log.Fatalf("Failed to generate OAuth token secret: %v", err)
+	}
+	return secret
+}
+
+func setupRoutes(corsOrigins *middleware.CORSOrigins, propertyHandler *handlers.PropertyHandler, transactionHandler *handlers.TransactionHandler, categoryHandler *handlers.CategoryHandler, webhookHandler *handlers.WebhookHandler, apiKeyHandler *handlers.APIKeyHandler, memberHandler *handlers.MemberHandler, organizationHandler *handlers.OrganizationHandler, oauthHandler *handlers.OAuthHandler, auditHandler *handlers.AuditHandler, revocationHandler *handlers.RevocationHandler, accountHandler *handlers.AccountHandler, impersonationHandler *handlers.ImpersonationHandler, consentHandler *handlers.ConsentHandler, reportHandler *handlers.ReportHandler, healthHandler *handlers.HealthHandler, maintenanceHandler *handlers.MaintenanceHandler, logLevelHandler *handlers.LogLevelHandler, deadLetterHandler *handlers.DeadLetterHandler, backupHandler *handlers.BackupHandler, taskHandler *handlers.TaskHandler, taskCallers middleware.TaskCallerVerifier, graphqlHandler http.Handler, legacySunset time.Time, requireMFA func(http.Handler) http.Handler, pprofEnabled bool, errorReporter errorreport.Reporter) *mux.Router {
 	router := mux.NewRouter()
 
 	// Add middleware
-	router.Use(middleware.CORS)
+	router.Use(middleware.RequestID)
+	router.Use(middleware.ServerTiming)
+	router.Use(middleware.CORS(corsOrigins))
 	router.Use(middleware.JSONContentType)
-	router.Use(middleware.Logging)
+	router.Use(middleware.AccessLog(slog.Default()))
+	router.Use(middleware.Recover(slog.Default(), errorReporter))
+	router.Use(middleware.FailFast)
 
 	// Property routes
 	router.HandleFunc("/properties", propertyHandler.CreateProperty).Methods("POST")
 	router.HandleFunc("/properties", propertyHandler.GetAllProperties).Methods("GET")
 	router.HandleFunc("/properties/{id}", propertyHandler.GetProperty).Methods("GET")
 	router.HandleFunc("/properties/{id}", propertyHandler.UpdateProperty).Methods("PUT")
-	router.HandleFunc("/properties/{id}", propertyHandler.DeleteProperty).Methods("DELETE")
+	router.HandleFunc("/properties/{id}", propertyHandler.PatchProperty).Methods("PATCH")
+	router.Handle("/properties/{id}", middleware.When(cascadeRequested, requireMFA)(http.HandlerFunc(propertyHandler.DeleteProperty))).Methods("DELETE")
+	router.HandleFunc("/properties/{id}/clone", propertyHandler.CloneProperty).Methods("POST")
+	router.HandleFunc("/properties/{id}/totals", propertyHandler.GetPropertyTotals).Methods("GET")
 
 	// Transaction routes
 	router.HandleFunc("/transactions", transactionHandler.CreateTransaction).Methods("POST")
 	router.HandleFunc("/transactions", transactionHandler.GetAllTransactions).Methods("GET")
+	router.HandleFunc("/transactions/bulk", transactionHandler.CreateTransactions).Methods("POST")
+	router.HandleFunc("/transactions/deleted", transactionHandler.GetDeletedTransactions).Methods("GET")
+	router.HandleFunc("/transactions/export", transactionHandler.ExportTransactions).Methods("GET")
+	router.Handle("/transactions/purge", requireMFA(http.HandlerFunc(transactionHandler.PurgeDeletedTransactions))).Methods("POST")
+	router.HandleFunc("/transactions/reassign-category", transactionHandler.ReassignCategory).Methods("POST")
+	router.HandleFunc("/transactions/external/{source}/{externalId}", transactionHandler.UpsertExternalTransaction).Methods("PUT")
 	router.HandleFunc("/transactions/{id}", transactionHandler.GetTransaction).Methods("GET")
 	router.HandleFunc("/transactions/{id}", transactionHandler.UpdateTransaction).Methods("PUT")
+	router.HandleFunc("/transactions/{id}", transactionHandler.PatchTransaction).Methods("PATCH")
 	router.HandleFunc("/transactions/{id}", transactionHandler.DeleteTransaction).Methods("DELETE")
-	router.HandleFunc("/properties/{propertyId}/transactions", transactionHandler.GetTransactionsByProperty).Methods("GET")
+	router.HandleFunc("/transactions/{id}/duplicate", transactionHandler.DuplicateTransaction).Methods("POST")
+	router.Handle("/properties/{propertyId}/transactions", middleware.Deprecated(legacySunset, func(r *http.Request) string {
+		return "/transactions?propertyId=" + url.QueryEscape(mux.Vars(r)["propertyId"])
+	})(http.HandlerFunc(transactionHandler.GetTransactionsByProperty))).Methods("GET")
 
 	// Category routes
 	router.HandleFunc("/categories", categoryHandler.CreateCategory).Methods("POST")
@@ -85,13 +606,114 @@ func setupRoutes(propertyHandler *handlers.PropertyHandler, transactionHandler *
 	router.HandleFunc("/categories/{id}", categoryHandler.GetCategory).Methods("GET")
 	router.HandleFunc("/categories/{id}", categoryHandler.UpdateCategory).Methods("PUT")
 	router.HandleFunc("/categories/{id}", categoryHandler.DeleteCategory).Methods("DELETE")
-	router.HandleFunc("/categories/type/{type}", categoryHandler.GetCategoriesByType).Methods("GET")
+	router.Handle("/categories/type/{type}", middleware.Deprecated(legacySunset, func(r *http.Request) string {
+		return "/categories?type=" + url.QueryEscape(mux.Vars(r)["type"])
+	})(http.HandlerFunc(categoryHandler.GetCategoriesByType))).Methods("GET")
+
+	// Report routes
+	router.HandleFunc("/reports/monthly", reportHandler.GetMonthlyReport).Methods("GET")
+
+	// Webhook routes
+	router.HandleFunc("/webhooks", webhookHandler.CreateWebhook).Methods("POST")
+	router.HandleFunc("/webhooks", webhookHandler.GetAllWebhooks).Methods("GET")
+	router.HandleFunc("/webhooks/{id}", webhookHandler.GetWebhook).Methods("GET")
+	router.HandleFunc("/webhooks/{id}", webhookHandler.UpdateWebhook).Methods("PUT")
+	router.HandleFunc("/webhooks/{id}", webhookHandler.DeleteWebhook).Methods("DELETE")
+	router.HandleFunc("/webhooks/{id}/rotate-secret", webhookHandler.RotateWebhookSecret).Methods("POST")
+	router.HandleFunc("/webhooks/{id}/deliveries", webhookHandler.GetWebhookDeliveries).Methods("GET")
+
+	// API key routes
+	router.HandleFunc("/api-keys", apiKeyHandler.CreateAPIKey).Methods("POST")
+	router.HandleFunc("/api-keys", apiKeyHandler.GetAllAPIKeys).Methods("GET")
+	router.HandleFunc("/api-keys/{id}/rotate", apiKeyHandler.RotateAPIKey).Methods("POST")
+	router.HandleFunc("/api-keys/{id}", apiKeyHandler.RevokeAPIKey).Methods("DELETE")
+
+	// Member routes
+	router.HandleFunc("/members", memberHandler.CreateMember).Methods("POST")
+	router.HandleFunc("/members", memberHandler.GetAllMembers).Methods("GET")
+	router.HandleFunc("/members/{id}", memberHandler.UpdateMember).Methods("PUT")
+	router.HandleFunc("/members/{id}", memberHandler.DeleteMember).Methods("DELETE")
+
+	// Organization routes
+	router.HandleFunc("/organizations", organizationHandler.CreateOrganization).Methods("POST")
+	router.HandleFunc("/organizations", organizationHandler.GetAllOrganizations).Methods("GET")
+	router.HandleFunc("/organizations/{id}", organizationHandler.GetOrganization).Methods("GET")
+	router.HandleFunc("/organizations/{id}", organizationHandler.UpdateOrganization).Methods("PUT")
+
+	// OAuth routes
+	router.HandleFunc("/oauth/token", oauthHandler.Token).Methods("POST")
+	router.HandleFunc("/oauth/clients", oauthHandler.CreateClient).Methods("POST")
+	router.HandleFunc("/oauth/clients", oauthHandler.GetAllClients).Methods("GET")
+	router.HandleFunc("/oauth/clients/{id}", oauthHandler.RevokeClient).Methods("DELETE")
+
+	// Audit routes
+	router.HandleFunc("/audit-events", auditHandler.GetAuditEvents).Methods("GET")
+
+	// Account routes
+	router.HandleFunc("/me/deletion-token", accountHandler.RequestDeletion).Methods("POST")
+	router.HandleFunc("/me/consents", consentHandler.AcceptConsent).Methods("POST")
+	router.HandleFunc("/me/consents", consentHandler.GetConsents).Methods("GET")
+	router.HandleFunc("/consent-requirements", consentHandler.GetRequirements).Methods("GET")
+	router.Handle("/me", requireMFA(http.HandlerFunc(accountHandler.DeleteAccount))).Methods("DELETE")
+
+	// Admin routes
+	admin := router.PathPrefix("/admin").Subrouter()
+	admin.Use(middleware.RequireAdmin)
+	admin.HandleFunc("/users/{userId}/revoke-sessions", revocationHandler.RevokeUserSessions).Methods("POST")
+	admin.HandleFunc("/tokens/{tokenId}/revoke", revocationHandler.RevokeToken).Methods("POST")
+	admin.HandleFunc("/revocations", revocationHandler.GetAllRevocations).Methods("GET")
+	admin.HandleFunc("/impersonations", impersonationHandler.StartImpersonation).Methods("POST")
+	admin.HandleFunc("/impersonations", impersonationHandler.GetAllImpersonations).Methods("GET")
+	admin.HandleFunc("/impersonations/{id}", impersonationHandler.EndImpersonation).Methods("DELETE")
+	admin.HandleFunc("/maintenance", maintenanceHandler.GetMaintenance).Methods("GET")
+	admin.HandleFunc("/maintenance", maintenanceHandler.SetMaintenance).Methods("PUT")
+	admin.HandleFunc("/log-level", logLevelHandler.GetLogLevel).Methods("GET")
+	admin.HandleFunc("/log-level", logLevelHandler.SetLogLevel).Methods("PUT")
+	admin.HandleFunc("/dead-letters", deadLetterHandler.GetDeadLetters).Methods("GET")
+	admin.HandleFunc("/dead-letters/replay", deadLetterHandler.ReplayDeadLetters).Methods("POST")
+	admin.HandleFunc("/dead-letters/{id}/replay", deadLetterHandler.ReplayDeadLetter).Methods("POST")
+	if backupHandler != nil {
+		admin.HandleFunc("/backups", backupHandler.CreateBackup).Methods("POST")
+		admin.HandleFunc("/backups", backupHandler.GetAllBackups).Methods("GET")
+		admin.HandleFunc("/backups/{name}", backupHandler.DeleteBackup).Methods("DELETE")
+	}
+
+	// Tasks delivered by Cloud Tasks
+	if taskHandler != nil {
+		router.Handle(tasks.ReceiverPath+"{name}", middleware.RequireTaskCaller(taskCallers)(http.HandlerFunc(taskHandler.RunTask))).Methods("POST")
+	}
+
+	// GraphQL
+	router.Handle("/graphql", graphqlHandler).Methods("POST")
+
+	// Runtime metrics, including legacy route usage, for administrators
+	debug := router.PathPrefix("/debug").Subrouter()
+	debug.Use(middleware.RequireAdmin)
+	debug.Handle("/vars", expvar.Handler()).Methods("GET")
+
+	// CPU and heap profiles of the live service, for administrators
+	if pprofEnabled {
+		profiling := debug.PathPrefix("/pprof").Subrouter()
+		profiling.HandleFunc("/cmdline", pprof.Cmdline)
+		profiling.HandleFunc("/profile", pprof.Profile)
+		profiling.HandleFunc("/symbol", pprof.Symbol)
+		profiling.HandleFunc("/trace", pprof.Trace)
+		profiling.PathPrefix("/").HandlerFunc(pprof.Index)
+	}
+
+	// Build identification
+	router.HandleFunc("/version", func(w http.ResponseWriter, r *http.Request) {
+		utils.WriteJSONResponse(w, http.StatusOK, buildinfo.Get())
+	}).Methods("GET")
 
 	// Health check
 	router.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte("OK"))
 	}).Methods("GET")
+	router.HandleFunc("/livez", healthHandler.Live).Methods("GET")
+	router.HandleFunc("/readyz", healthHandler.Ready).Methods("GET")
+	router.HandleFunc("/healthz", healthHandler.Health).Methods("GET")
 
 	return router
 }
@@ -2,24 +2,201 @@ package config
 
 import (
 	"os"
+	"strconv"
 )
 
 type Config struct {
-	Port             string
-	GoogleProject    string
-	FirestoreKeyPath string
+	Port                string
+	GoogleProject       string
+	FirestoreKeyPath    string
+	StorageBackend      string
+	ErrorFormat         string
+	Timezone            string
+	Currency            string
+	TrustProxy          bool
+	TrustedProxyHops    int
+	RateLimitEnabled    bool
+	RateLimitBackend    string
+	RateLimitRate       float64
+	RateLimitBurst      int
+	RateLimitWriteRate  float64
+	RateLimitWriteBurst int
+	RateLimitOverrides  string
+	RateLimitIPRate     float64
+	RateLimitIPBurst    int
+	RedisAddr           string
+	LegacySunset        string
+	JSONFieldNaming     string
+	AuthEnabled         bool
+	FirebaseProject     string
+	OAuthTokenSecret    string
+	AdminAllowedCIDRs   string
+	MFARequired         bool
+	MFAMaxAgeMinutes    int
+	TermsVersion        string
+	PrivacyVersion      string
+	CategoryCacheTTL    int
+	BackupBucket        string
+	BackupIntervalHours int
+	BackupRetentionDays int
+	CompressionEnabled  bool
+	CompressionMinBytes int
+	ReadTimeoutSeconds  int
+	ListTimeoutSeconds  int
+	MaxDateRangeDays    int
+	MaxNameLength       int
+	MaxDescriptionLen   int
+	EscapeHTML          bool
+	WriteTimeoutSeconds int
+	RollupRebuildHours  int
+	CleanupHours        int
+	TrashRetentionDays  int
+	WebhookLogDays      int
+	ShutdownTimeout     int
+	PprofEnabled        bool
+	MaxBodyBytes        int64
+	MaxUploadBytes      int64
+	TLSCertFile         string
+	TLSKeyFile          string
+	TLSDomain           string
+	TLSCacheDir         string
+	ErrorReporter       string
+	ErrorService        string
+	StartupChecks       string
+	LogLevel            string
+	CORSOrigins         string
+	ReloadSeconds       int
+	SlowCallMs          int
+	BreakerFailures     int
+	BreakerCooldown     int
+	OutboxPollMs        int
+	RollupSchedule      string
+	CleanupSchedule     string
+	BackupSchedule      string
+	DeletionSchedule    string
+	TasksQueue          string
+	TasksURL            string
+	TasksAccount        string
+	PubSubTopic         string
 }
 
-func Load() *Config {
-	return &Config{
-		Port:             getEnv("PORT", "8080"),
-		GoogleProject:    getEnv("GOOGLE_CLOUD_PROJECT", ""),
-		FirestoreKeyPath: getEnv("FIRESTORE_KEY_PATH", ""),
+// Load reads settings from the environment, falling back to the YAML file
+// named by CONFIG_FILE and then to defaults.
+func Load() (*Config, error) {
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		values, err := loadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		fileValues = values
 	}
+
+	return &Config{
+		Port:                getEnv("PORT", "8080"),
+		GoogleProject:       getEnv("GOOGLE_CLOUD_PROJECT", ""),
+		FirestoreKeyPath:    getEnv("FIRESTORE_KEY_PATH", ""),
+		StorageBackend:      getEnv("STORAGE_BACKEND", "firestore"),
+		ErrorFormat:         getEnv("ERROR_FORMAT", "json"),
+		Timezone:            getEnv("TIMEZONE", "UTC"),
+		Currency:            getEnv("CURRENCY", "GBP"),
+		TrustProxy:          getEnvBool("TRUST_PROXY", false),
+		TrustedProxyHops:    getEnvInt("TRUSTED_PROXY_HOPS", 1),
+		RateLimitEnabled:    getEnvBool("RATE_LIMIT_ENABLED", true),
+		RateLimitBackend:    getEnv("RATE_LIMIT_BACKEND", "memory"),
+		RateLimitRate:       getEnvFloat("RATE_LIMIT_RATE", 10),
+		RateLimitBurst:      getEnvInt("RATE_LIMIT_BURST", 20),
+		RateLimitWriteRate:  getEnvFloat("RATE_LIMIT_WRITE_RATE", 5),
+		RateLimitWriteBurst: getEnvInt("RATE_LIMIT_WRITE_BURST", 10),
+		RateLimitOverrides:  getEnv("RATE_LIMIT_OVERRIDES", ""),
+		RateLimitIPRate:     getEnvFloat("RATE_LIMIT_IP_RATE", 50),
+		RateLimitIPBurst:    getEnvInt("RATE_LIMIT_IP_BURST", 100),
+		RedisAddr:           getEnv("REDIS_ADDR", "localhost:6379"),
+		LegacySunset:        getEnv("LEGACY_ROUTES_SUNSET", "2027-06-30"),
+		JSONFieldNaming:     getEnv("JSON_FIELD_NAMING", "camel"),
+		AuthEnabled:         getEnvBool("AUTH_ENABLED", true),
+		FirebaseProject:     getEnv("FIREBASE_PROJECT_ID", getEnv("GOOGLE_CLOUD_PROJECT", "")),
+		OAuthTokenSecret:    getEnv("OAUTH_TOKEN_SECRET", ""),
+		AdminAllowedCIDRs:   getEnv("ADMIN_ALLOWED_CIDRS", ""),
+		MFARequired:         getEnvBool("MFA_REQUIRED", true),
+		MFAMaxAgeMinutes:    getEnvInt("MFA_MAX_AGE_MINUTES", 15),
+		TermsVersion:        getEnv("REQUIRED_TERMS_VERSION", ""),
+		PrivacyVersion:      getEnv("REQUIRED_PRIVACY_VERSION", ""),
+		CategoryCacheTTL:    getEnvInt("CATEGORY_CACHE_TTL_SECONDS", 60),
+		BackupBucket:        getEnv("BACKUP_BUCKET", ""),
+		BackupIntervalHours: getEnvInt("BACKUP_INTERVAL_HOURS", 24),
+		BackupRetentionDays: getEnvInt("BACKUP_RETENTION_DAYS", 30),
+		CompressionEnabled:  getEnvBool("COMPRESSION_ENABLED", true),
+		CompressionMinBytes: getEnvInt("COMPRESSION_MIN_BYTES", 1024),
+		ReadTimeoutSeconds:  getEnvInt("READ_TIMEOUT_SECONDS", 10),
+		ListTimeoutSeconds:  getEnvInt("LIST_TIMEOUT_SECONDS", 30),
+		MaxDateRangeDays:    getEnvInt("MAX_DATE_RANGE_DAYS", 5*366),
+		MaxNameLength:       getEnvInt("MAX_NAME_LENGTH", 200),
+		MaxDescriptionLen:   getEnvInt("MAX_DESCRIPTION_LENGTH", 2000),
+		EscapeHTML:          getEnvBool("ESCAPE_HTML", false),
+		WriteTimeoutSeconds: getEnvInt("WRITE_TIMEOUT_SECONDS", 15),
+		RollupRebuildHours:  getEnvInt("ROLLUP_REBUILD_INTERVAL_HOURS", 24),
+		CleanupHours:        getEnvInt("CLEANUP_INTERVAL_HOURS", 24),
+		TrashRetentionDays:  getEnvInt("DELETED_TRANSACTION_RETENTION_DAYS", 30),
+		WebhookLogDays:      getEnvInt("WEBHOOK_DELIVERY_RETENTION_DAYS", 30),
+		ShutdownTimeout:     getEnvInt("SHUTDOWN_TIMEOUT_SECONDS", 10),
+		PprofEnabled:        getEnvBool("PPROF_ENABLED", false),
+		MaxBodyBytes:        getEnvInt64("MAX_BODY_BYTES", 1<<20),
+		MaxUploadBytes:      getEnvInt64("MAX_UPLOAD_BYTES", 10<<20),
+		TLSCertFile:         getEnv("TLS_CERT_FILE", ""),
+		TLSKeyFile:          getEnv("TLS_KEY_FILE", ""),
+		TLSDomain:           getEnv("TLS_AUTOCERT_DOMAIN", ""),
+		TLSCacheDir:         getEnv("TLS_AUTOCERT_CACHE_DIR", "certs"),
+		ErrorReporter:       getEnv("ERROR_REPORTER", "none"),
+		ErrorService:        getEnv("ERROR_REPORTING_SERVICE", "habitattrack-api"),
+		StartupChecks:       getEnv("STARTUP_CHECKS", "strict"),
+		LogLevel:            getEnv("LOG_LEVEL", "info"),
+		CORSOrigins:         getEnv("CORS_ALLOWED_ORIGINS", "*"),
+		ReloadSeconds:       getEnvInt("CONFIG_RELOAD_INTERVAL_SECONDS", 30),
+		SlowCallMs:          getEnvInt("SLOW_FIRESTORE_CALL_MS", 500),
+		BreakerFailures:     getEnvInt("CIRCUIT_BREAKER_FAILURES", 5),
+		BreakerCooldown:     getEnvInt("CIRCUIT_BREAKER_COOLDOWN_SECONDS", 30),
+		OutboxPollMs:        getEnvInt("OUTBOX_POLL_INTERVAL_MS", 1000),
+		RollupSchedule:      getEnv("ROLLUP_REBUILD_SCHEDULE", ""),
+		CleanupSchedule:     getEnv("CLEANUP_SCHEDULE", ""),
+		BackupSchedule:      getEnv("BACKUP_SCHEDULE", ""),
+		DeletionSchedule:    getEnv("ACCOUNT_PURGE_SCHEDULE", "@every 15m"),
+		TasksQueue:          getEnv("TASKS_QUEUE", ""),
+		TasksURL:            getEnv("TASKS_TARGET_URL", ""),
+		TasksAccount:        getEnv("TASKS_SERVICE_ACCOUNT", ""),
+		PubSubTopic:         getEnv("PUBSUB_TOPIC", ""),
+	}, nil
 }
 
 func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
+	if value := lookup(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+func getEnvBool(key string, defaultValue bool) bool {
+	if value, err := strconv.ParseBool(lookup(key)); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
+func getEnvInt(key string, defaultValue int) int {
+	if value, err := strconv.Atoi(lookup(key)); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
+func getEnvInt64(key string, defaultValue int64) int64 {
+	if value, err := strconv.ParseInt(lookup(key), 10, 64); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
+func getEnvFloat(key string, defaultValue float64) float64 {
+	if value, err := strconv.ParseFloat(lookup(key), 64); err == nil {
 		return value
 	}
 	return defaultValue
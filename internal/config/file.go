@@ -0,0 +1,54 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// fileValues holds settings read from CONFIG_FILE, keyed by the environment
+// variable each one stands in for.
+var fileValues map[string]string
+
+// loadFile reads a YAML file of settings named like their environment
+// variables, e.g. "READ_TIMEOUT_SECONDS: 20". Environment variables still
+// take precedence, so a deployment can override single values.
+func loadFile(path string) (map[string]string, error) {
+	switch filepath.Ext(path) {
+	case ".yaml", ".yml":
+	default:
+		return nil, fmt.Errorf("config file %s must be YAML", path)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw map[string]any
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("parsing config file %s: %w", path, err)
+	}
+
+	values := make(map[string]string, len(raw))
+	for key, value := range raw {
+		switch value.(type) {
+		case map[string]any, []any:
+			return nil, fmt.Errorf("config file %s: %s must be a single value", path, key)
+		case nil:
+			continue
+		}
+		values[key] = fmt.Sprint(value)
+	}
+	return values, nil
+}
+
+// lookup returns the environment variable, falling back to the config file.
+func lookup(key string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fileValues[key]
+}
@@ -0,0 +1,68 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spalqui/habitattrack-api/pkg/money"
+)
+
+// Validate reports every inconsistent or out-of-range setting at once, so a
+// misconfigured deployment can be fixed in one go.
+func (c *Config) Validate() error {
+	var problems []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			problems = append(problems, fmt.Errorf(format, args...))
+		}
+	}
+	oneOf := func(name, value string, allowed ...string) {
+		check(slices.Contains(allowed, value), "%s must be one of %v, not %q", name, allowed, value)
+	}
+
+	port, err := strconv.Atoi(c.Port)
+	check(err == nil && port > 0 && port < 65536, "PORT must be a port number, not %q", c.Port)
+
+	oneOf("STORAGE_BACKEND", c.StorageBackend, "firestore")
+	oneOf("ERROR_FORMAT", c.ErrorFormat, "json", "problem")
+	oneOf("JSON_FIELD_NAMING", c.JSONFieldNaming, "camel", "snake")
+	oneOf("RATE_LIMIT_BACKEND", c.RateLimitBackend, "memory", "redis")
+	oneOf("ERROR_REPORTER", c.ErrorReporter, "none", "cloud")
+	oneOf("STARTUP_CHECKS", c.StartupChecks, "strict", "warn", "off")
+
+	var level slog.Level
+	check(level.UnmarshalText([]byte(c.LogLevel)) == nil, "LOG_LEVEL must be debug, info, warn or error, not %q", c.LogLevel)
+
+	_, err = time.LoadLocation(c.Timezone)
+	check(err == nil, "TIMEZONE %q is not a known time zone", c.Timezone)
+	check(money.ValidCode(c.Currency), "CURRENCY must be an ISO 4217 code, three upper-case letters such as GBP, not %q", c.Currency)
+	check(!money.ValidCode(c.Currency) || money.Supported(c.Currency), "CURRENCY must be one of %v, not %q", money.Codes(), c.Currency)
+	_, err = time.Parse(time.DateOnly, c.LegacySunset)
+	check(err == nil, "LEGACY_ROUTES_SUNSET must be a YYYY-MM-DD date, not %q", c.LegacySunset)
+
+	check(!c.AuthEnabled || c.FirebaseProject != "", "FIREBASE_PROJECT_ID or GOOGLE_CLOUD_PROJECT is required when authentication is enabled")
+	check((c.TLSCertFile == "") == (c.TLSKeyFile == ""), "TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	check(c.TLSCertFile == "" || c.TLSDomain == "", "TLS_CERT_FILE and TLS_AUTOCERT_DOMAIN can't both be set")
+	check(c.PubSubTopic == "" || strings.HasPrefix(c.PubSubTopic, "projects/") || c.GoogleProject != "", "GOOGLE_CLOUD_PROJECT is required when PUBSUB_TOPIC isn't a full topic name")
+	check(c.TasksQueue == "" || (c.TasksURL != "" && c.TasksAccount != ""), "TASKS_TARGET_URL and TASKS_SERVICE_ACCOUNT are required with TASKS_QUEUE")
+
+	check(c.ReadTimeoutSeconds > 0 && c.ListTimeoutSeconds > 0 && c.WriteTimeoutSeconds > 0, "request timeouts must be positive")
+	check(c.ShutdownTimeout > 0, "SHUTDOWN_TIMEOUT_SECONDS must be positive")
+	check(c.BreakerFailures == 0 || c.BreakerCooldown > 0, "CIRCUIT_BREAKER_COOLDOWN_SECONDS must be positive")
+	check(c.ReloadSeconds > 0, "CONFIG_RELOAD_INTERVAL_SECONDS must be positive")
+	check(c.OutboxPollMs > 0, "OUTBOX_POLL_INTERVAL_MS must be positive")
+	check(!c.TrustProxy || c.TrustedProxyHops > 0, "TRUSTED_PROXY_HOPS must be positive when TRUST_PROXY is set")
+	check(c.MaxDateRangeDays >= 0, "MAX_DATE_RANGE_DAYS must not be negative")
+	check(c.MaxNameLength >= 0 && c.MaxDescriptionLen >= 0, "MAX_NAME_LENGTH and MAX_DESCRIPTION_LENGTH must not be negative")
+	check(c.MaxBodyBytes > 0 && c.MaxUploadBytes > 0, "MAX_BODY_BYTES and MAX_UPLOAD_BYTES must be positive")
+	check(c.RateLimitRate > 0 && c.RateLimitWriteRate > 0 && c.RateLimitIPRate > 0, "rate limits must be positive")
+	check(c.BackupRetentionDays > 0, "BACKUP_RETENTION_DAYS must be positive")
+	check(c.TrashRetentionDays > 0 && c.WebhookLogDays > 0, "retention periods must be positive")
+
+	return errors.Join(problems...)
+}
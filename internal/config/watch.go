@@ -0,0 +1,52 @@
+package config
+
+import (
+	"context"
+	"log"
+	"os"
+	"time"
+)
+
+// Watch polls CONFIG_FILE every interval and, whenever it changes, calls
+// reload with the settings loaded again from it and the environment. A file
+// that fails to load is logged and the previous settings stay in force. It
+// returns when ctx is done, or straight away without a config file.
+func Watch(ctx context.Context, interval time.Duration, reload func(*Config)) {
+	path := os.Getenv("CONFIG_FILE")
+	if path == "" {
+		return
+	}
+
+	last, _ := os.Stat(path)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			log.Printf("Watching config file: %v", err)
+			continue
+		}
+		if last != nil && info.ModTime().Equal(last.ModTime()) && info.Size() == last.Size() {
+			continue
+		}
+		last = info
+
+		cfg, err := Load()
+		if err != nil {
+			log.Printf("Ignoring config file change: %v", err)
+			continue
+		}
+		if err := cfg.Validate(); err != nil {
+			log.Printf("Ignoring config file change: %v", err)
+			continue
+		}
+		reload(cfg)
+	}
+}
@@ -0,0 +1,457 @@
+// Package graphql serves /graphql over the same services as the REST
+// handlers. It uses graph-gophers/graphql-go rather than gqlgen: resolvers
+// are plain methods matched against the schema when the handler is built, so
+// there is no generated code, or generator step, to keep in step with it.
+package graphql
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	graphqlgo "github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/relay"
+
+	"github.com/spalqui/habitattrack-api/internal/models"
+	"github.com/spalqui/habitattrack-api/internal/services"
+)
+
+type resolver struct {
+	propertyService    services.PropertyService
+	transactionService services.TransactionService
+	categoryService    services.CategoryService
+	reportService      services.ReportService
+}
+
+func NewHandler(
+	propertyService services.PropertyService,
+	transactionService services.TransactionService,
+	categoryService services.CategoryService,
+	reportService services.ReportService,
+) http.Handler {
+	r := &resolver{
+		propertyService:    propertyService,
+		transactionService: transactionService,
+		categoryService:    categoryService,
+		reportService:      reportService,
+	}
+
+	return &relay.Handler{Schema: graphqlgo.MustParseSchema(schema, r)}
+}
+
+func (r *resolver) Properties(ctx context.Context) ([]*propertyResolver, error) {
+	properties, err := r.propertyService.GetAllProperties(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resolvers := make([]*propertyResolver, len(properties))
+	for i, property := range properties {
+		resolvers[i] = &propertyResolver{root: r, property: property}
+	}
+
+	return resolvers, nil
+}
+
+func (r *resolver) Property(ctx context.Context, args struct{ ID graphqlgo.ID }) (*propertyResolver, error) {
+	property, err := r.propertyService.GetProperty(ctx, string(args.ID))
+	if err != nil {
+		return nil, err
+	}
+
+	return &propertyResolver{root: r, property: property}, nil
+}
+
+func (r *resolver) Transactions(ctx context.Context, args struct{ PropertyID *graphqlgo.ID }) ([]*transactionResolver, error) {
+	var transactions []*models.Transaction
+	var err error
+
+	if args.PropertyID != nil {
+		transactions, err = r.transactionService.GetTransactionsByProperty(ctx, string(*args.PropertyID))
+	} else {
+		transactions, err = r.transactionService.GetAllTransactions(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return r.transactionResolvers(transactions), nil
+}
+
+func (r *resolver) Transaction(ctx context.Context, args struct{ ID graphqlgo.ID }) (*transactionResolver, error) {
+	transaction, err := r.transactionService.GetTransaction(ctx, string(args.ID))
+	if err != nil {
+		return nil, err
+	}
+
+	return r.transactionResolvers([]*models.Transaction{transaction})[0], nil
+}
+
+func (r *resolver) Categories(ctx context.Context, args struct{ Type *string }) ([]*categoryResolver, error) {
+	var categories []*models.Category
+	var err error
+
+	if args.Type != nil {
+		categories, err = r.categoryService.GetCategoriesByType(ctx, models.TransactionType(*args.Type))
+	} else {
+		categories, err = r.categoryService.GetAllCategories(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	resolvers := make([]*categoryResolver, len(categories))
+	for i, category := range categories {
+		resolvers[i] = &categoryResolver{category: category}
+	}
+
+	return resolvers, nil
+}
+
+func (r *resolver) Category(ctx context.Context, args struct{ ID graphqlgo.ID }) (*categoryResolver, error) {
+	category, err := r.categoryService.GetCategory(ctx, string(args.ID))
+	if err != nil {
+		return nil, err
+	}
+
+	return &categoryResolver{category: category}, nil
+}
+
+func (r *resolver) MonthlyReport(ctx context.Context, args struct {
+	PropertyID *graphqlgo.ID
+	From       *string
+	To         *string
+}) ([]*monthlySummaryResolver, error) {
+	var filter models.RollupFilter
+	if args.PropertyID != nil {
+		filter.PropertyID = string(*args.PropertyID)
+	}
+	if args.From != nil {
+		filter.From = *args.From
+	}
+	if args.To != nil {
+		filter.To = *args.To
+	}
+
+	if !validMonth(filter.From) {
+		return nil, errors.New("from must be a month in YYYY-MM format")
+	}
+	if !validMonth(filter.To) {
+		return nil, errors.New("to must be a month in YYYY-MM format")
+	}
+
+	summaries, err := r.reportService.GetMonthlyReport(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	refs := &references{root: r}
+	resolvers := make([]*monthlySummaryResolver, len(summaries))
+	for i, summary := range summaries {
+		for _, rollup := range summary.Rollups {
+			refs.add(rollup.PropertyID, rollup.CategoryID)
+		}
+		resolvers[i] = &monthlySummaryResolver{refs: refs, summary: summary}
+	}
+
+	return resolvers, nil
+}
+
+// validMonth accepts an empty month, meaning no bound, or one in the
+// rollups' format.
+func validMonth(month string) bool {
+	if month == "" {
+		return true
+	}
+	_, err := time.Parse(models.RollupMonthFormat, month)
+	return err == nil
+}
+
+func (r *resolver) transactionResolvers(transactions []*models.Transaction) []*transactionResolver {
+	refs := &references{root: r}
+	resolvers := make([]*transactionResolver, len(transactions))
+	for i, transaction := range transactions {
+		refs.add(transaction.PropertyID, transaction.CategoryID)
+		resolvers[i] = &transactionResolver{refs: refs, transaction: transaction}
+	}
+
+	return resolvers
+}
+
+// references reads the properties and categories a list of results refers
+// to, each kind in one round trip the first time any result asks for one,
+// rather than with a read per result.
+type references struct {
+	root        *resolver
+	propertyIDs []string
+	categoryIDs []string
+
+	propertiesOnce sync.Once
+	properties     map[string]*models.Property
+	propertiesErr  error
+
+	categoriesOnce sync.Once
+	categories     map[string]*models.Category
+	categoriesErr  error
+}
+
+func (refs *references) add(propertyID, categoryID string) {
+	refs.propertyIDs = append(refs.propertyIDs, propertyID)
+	refs.categoryIDs = append(refs.categoryIDs, categoryID)
+}
+
+// property returns the property with the ID, or nil when there's none, as
+// for a general transaction or one whose property has been deleted.
+func (refs *references) property(ctx context.Context, id string) (*propertyResolver, error) {
+	if id == "" {
+		return nil, nil
+	}
+
+	refs.propertiesOnce.Do(func() {
+		refs.properties, refs.propertiesErr = refs.root.propertyService.GetPropertiesByIDs(ctx, refs.propertyIDs)
+	})
+	if refs.propertiesErr != nil {
+		return nil, refs.propertiesErr
+	}
+
+	property, ok := refs.properties[id]
+	if !ok {
+		return nil, nil
+	}
+	return &propertyResolver{root: refs.root, property: property}, nil
+}
+
+// category returns the category with the ID, or nil when there's none.
+func (refs *references) category(ctx context.Context, id string) (*categoryResolver, error) {
+	refs.categoriesOnce.Do(func() {
+		refs.categories, refs.categoriesErr = refs.root.categoryService.GetCategoriesByIDs(ctx, refs.categoryIDs)
+	})
+	if refs.categoriesErr != nil {
+		return nil, refs.categoriesErr
+	}
+
+	category, ok := refs.categories[id]
+	if !ok {
+		return nil, nil
+	}
+	return &categoryResolver{category: category}, nil
+}
+
+type propertyResolver struct {
+	root     *resolver
+	property *models.Property
+}
+
+func (p *propertyResolver) ID() graphqlgo.ID {
+	return graphqlgo.ID(p.property.ID)
+}
+
+func (p *propertyResolver) Name() *string {
+	return optionalString(p.property.Name)
+}
+
+func (p *propertyResolver) Address() string {
+	return p.property.Address
+}
+
+func (p *propertyResolver) Postcode() string {
+	return p.property.Postcode
+}
+
+func (p *propertyResolver) Description() *string {
+	return optionalString(p.property.Description)
+}
+
+func (p *propertyResolver) Timezone() *string {
+	return optionalString(p.property.Timezone)
+}
+
+func (p *propertyResolver) CreatedAt() graphqlgo.Time {
+	return graphqlgo.Time{Time: p.property.CreatedAt}
+}
+
+func (p *propertyResolver) UpdatedAt() graphqlgo.Time {
+	return graphqlgo.Time{Time: p.property.UpdatedAt}
+}
+
+func (p *propertyResolver) Transactions(ctx context.Context) ([]*transactionResolver, error) {
+	transactions, err := p.root.transactionService.GetTransactionsByProperty(ctx, p.property.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.root.transactionResolvers(transactions), nil
+}
+
+type transactionResolver struct {
+	refs        *references
+	transaction *models.Transaction
+}
+
+func (t *transactionResolver) ID() graphqlgo.ID {
+	return graphqlgo.ID(t.transaction.ID)
+}
+
+// PropertyID is null for a general transaction, which has no property.
+func (t *transactionResolver) PropertyID() *graphqlgo.ID {
+	if t.transaction.PropertyID == "" {
+		return nil
+	}
+	id := graphqlgo.ID(t.transaction.PropertyID)
+	return &id
+}
+
+func (t *transactionResolver) PropertyName() *string {
+	return optionalString(t.transaction.PropertyName)
+}
+
+func (t *transactionResolver) Property(ctx context.Context) (*propertyResolver, error) {
+	return t.refs.property(ctx, t.transaction.PropertyID)
+}
+
+func (t *transactionResolver) Type() string {
+	return string(t.transaction.Type)
+}
+
+func (t *transactionResolver) CategoryID() graphqlgo.ID {
+	return graphqlgo.ID(t.transaction.CategoryID)
+}
+
+func (t *transactionResolver) CategoryName() *string {
+	return optionalString(t.transaction.CategoryName)
+}
+
+func (t *transactionResolver) Category(ctx context.Context) (*categoryResolver, error) {
+	return t.refs.category(ctx, t.transaction.CategoryID)
+}
+
+func (t *transactionResolver) Amount() float64 {
+	return t.transaction.Amount.Float64()
+}
+
+func (t *transactionResolver) Description() *string {
+	return optionalString(t.transaction.Description)
+}
+
+func (t *transactionResolver) Date() graphqlgo.Time {
+	return graphqlgo.Time{Time: t.transaction.Date}
+}
+
+func (t *transactionResolver) CreatedAt() graphqlgo.Time {
+	return graphqlgo.Time{Time: t.transaction.CreatedAt}
+}
+
+func (t *transactionResolver) UpdatedAt() graphqlgo.Time {
+	return graphqlgo.Time{Time: t.transaction.UpdatedAt}
+}
+
+type categoryResolver struct {
+	category *models.Category
+}
+
+func (c *categoryResolver) ID() graphqlgo.ID {
+	return graphqlgo.ID(c.category.ID)
+}
+
+func (c *categoryResolver) Name() string {
+	return c.category.Name
+}
+
+func (c *categoryResolver) Type() string {
+	return string(c.category.Type)
+}
+
+func (c *categoryResolver) Description() *string {
+	return optionalString(c.category.Description)
+}
+
+func (c *categoryResolver) CreatedAt() graphqlgo.Time {
+	return graphqlgo.Time{Time: c.category.CreatedAt}
+}
+
+func (c *categoryResolver) UpdatedAt() graphqlgo.Time {
+	return graphqlgo.Time{Time: c.category.UpdatedAt}
+}
+
+type monthlySummaryResolver struct {
+	refs    *references
+	summary *models.MonthlySummary
+}
+
+func (s *monthlySummaryResolver) Month() string {
+	return s.summary.Month
+}
+
+func (s *monthlySummaryResolver) Income() float64 {
+	return s.summary.Income.Float64()
+}
+
+func (s *monthlySummaryResolver) Expense() float64 {
+	return s.summary.Expense.Float64()
+}
+
+func (s *monthlySummaryResolver) Net() float64 {
+	return s.summary.Net.Float64()
+}
+
+func (s *monthlySummaryResolver) TransactionCount() int32 {
+	return int32(s.summary.TransactionCount)
+}
+
+func (s *monthlySummaryResolver) Rollups() []*monthlyRollupResolver {
+	resolvers := make([]*monthlyRollupResolver, len(s.summary.Rollups))
+	for i, rollup := range s.summary.Rollups {
+		resolvers[i] = &monthlyRollupResolver{refs: s.refs, rollup: rollup}
+	}
+	return resolvers
+}
+
+type monthlyRollupResolver struct {
+	refs   *references
+	rollup *models.MonthlyRollup
+}
+
+func (r *monthlyRollupResolver) Month() string {
+	return r.rollup.Month
+}
+
+func (r *monthlyRollupResolver) PropertyID() *graphqlgo.ID {
+	if r.rollup.PropertyID == "" {
+		return nil
+	}
+	id := graphqlgo.ID(r.rollup.PropertyID)
+	return &id
+}
+
+func (r *monthlyRollupResolver) Property(ctx context.Context) (*propertyResolver, error) {
+	return r.refs.property(ctx, r.rollup.PropertyID)
+}
+
+func (r *monthlyRollupResolver) CategoryID() graphqlgo.ID {
+	return graphqlgo.ID(r.rollup.CategoryID)
+}
+
+func (r *monthlyRollupResolver) Category(ctx context.Context) (*categoryResolver, error) {
+	return r.refs.category(ctx, r.rollup.CategoryID)
+}
+
+func (r *monthlyRollupResolver) Type() string {
+	return string(r.rollup.Type)
+}
+
+func (r *monthlyRollupResolver) Amount() float64 {
+	return r.rollup.Amount.Float64()
+}
+
+func (r *monthlyRollupResolver) TransactionCount() int32 {
+	return int32(r.rollup.TransactionCount)
+}
+
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
@@ -0,0 +1,81 @@
+package graphql
+
+const schema = `
+	schema {
+		query: Query
+	}
+
+	scalar Time
+
+	enum TransactionType {
+		income
+		expense
+	}
+
+	type Query {
+		properties: [Property!]!
+		property(id: ID!): Property
+		transactions(propertyId: ID): [Transaction!]!
+		transaction(id: ID!): Transaction
+		categories(type: TransactionType): [Category!]!
+		category(id: ID!): Category
+		monthlyReport(propertyId: ID, from: String, to: String): [MonthlySummary!]!
+	}
+
+	type Property {
+		id: ID!
+		name: String
+		address: String!
+		postcode: String!
+		description: String
+		timezone: String
+		createdAt: Time!
+		updatedAt: Time!
+		transactions: [Transaction!]!
+	}
+
+	type Transaction {
+		id: ID!
+		propertyId: ID
+		propertyName: String
+		property: Property
+		type: TransactionType!
+		categoryId: ID!
+		categoryName: String
+		category: Category
+		amount: Float!
+		description: String
+		date: Time!
+		createdAt: Time!
+		updatedAt: Time!
+	}
+
+	type Category {
+		id: ID!
+		name: String!
+		type: TransactionType!
+		description: String
+		createdAt: Time!
+		updatedAt: Time!
+	}
+
+	type MonthlySummary {
+		month: String!
+		income: Float!
+		expense: Float!
+		net: Float!
+		transactionCount: Int!
+		rollups: [MonthlyRollup!]!
+	}
+
+	type MonthlyRollup {
+		month: String!
+		propertyId: ID
+		property: Property
+		categoryId: ID!
+		category: Category
+		type: TransactionType!
+		amount: Float!
+		transactionCount: Int!
+	}
+`
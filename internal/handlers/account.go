@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/spalqui/habitattrack-api/internal/services"
+	"github.com/spalqui/habitattrack-api/pkg/utils"
+)
+
+type AccountHandler struct {
+	accountService services.AccountService
+}
+
+func NewAccountHandler(accountService services.AccountService) *AccountHandler {
+	return &AccountHandler{
+		accountService: accountService,
+	}
+}
+
+func (h *AccountHandler) RequestDeletion(w http.ResponseWriter, r *http.Request) {
+	confirmation, err := h.accountService.RequestDeletion(r.Context())
+	if err != nil {
+		utils.WriteErrorResponse(w, r, http.StatusForbidden, err.Error())
+		return
+	}
+
+	utils.WriteJSONResponse(w, http.StatusCreated, confirmation)
+}
+
+// DeleteAccount expects the token from RequestDeletion in the
+// X-Confirmation-Token header, and responds before the purge completes.
+func (h *AccountHandler) DeleteAccount(w http.ResponseWriter, r *http.Request) {
+	token := r.Header.Get("X-Confirmation-Token")
+	if token == "" {
+		utils.WriteErrorResponse(w, r, http.StatusBadRequest, "X-Confirmation-Token header is required")
+		return
+	}
+
+	tombstone, err := h.accountService.DeleteAccount(r.Context(), token)
+	if err != nil {
+		utils.WriteErrorResponse(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.WriteJSONResponse(w, http.StatusAccepted, tombstone)
+}
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/spalqui/habitattrack-api/internal/models"
+	"github.com/spalqui/habitattrack-api/internal/services"
+	"github.com/spalqui/habitattrack-api/pkg/utils"
+)
+
+type APIKeyHandler struct {
+	apiKeyService services.APIKeyService
+}
+
+func NewAPIKeyHandler(apiKeyService services.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{
+		apiKeyService: apiKeyService,
+	}
+}
+
+func (h *APIKeyHandler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	var apiKey models.APIKey
+	if err := utils.DecodeJSON(r, &apiKey); err != nil {
+		utils.WriteDecodeError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := h.apiKeyService.CreateAPIKey(r.Context(), &apiKey); err != nil {
+		utils.WriteErrorResponse(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.WriteJSONResponse(w, http.StatusCreated, apiKey)
+}
+
+func (h *APIKeyHandler) GetAllAPIKeys(w http.ResponseWriter, r *http.Request) {
+	apiKeys, err := h.apiKeyService.GetAllAPIKeys(r.Context())
+	if err != nil {
+		utils.WriteErrorResponse(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.WriteJSONResponse(w, http.StatusOK, apiKeys)
+}
+
+func (h *APIKeyHandler) RotateAPIKey(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	apiKey, err := h.apiKeyService.RotateAPIKey(r.Context(), id)
+	if err != nil {
+		utils.WriteErrorResponse(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.WriteJSONResponse(w, http.StatusOK, apiKey)
+}
+
+func (h *APIKeyHandler) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	if err := h.apiKeyService.RevokeAPIKey(r.Context(), id); err != nil {
+		utils.WriteErrorResponse(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
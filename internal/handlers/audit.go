@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/spalqui/habitattrack-api/internal/models"
+	"github.com/spalqui/habitattrack-api/internal/services"
+	"github.com/spalqui/habitattrack-api/pkg/utils"
+)
+
+type AuditHandler struct {
+	auditService services.AuditService
+}
+
+func NewAuditHandler(auditService services.AuditService) *AuditHandler {
+	return &AuditHandler{
+		auditService: auditService,
+	}
+}
+
+func (h *AuditHandler) GetAuditEvents(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := models.AuditEventFilter{
+		Actor: query.Get("actor"),
+		Type:  query.Get("type"),
+	}
+
+	if value := query.Get("impersonated"); value != "" {
+		impersonated, err := strconv.ParseBool(value)
+		if err != nil {
+			utils.WriteErrorResponse(w, r, http.StatusBadRequest, "impersonated must be true or false")
+			return
+		}
+		filter.Impersonated = impersonated
+	}
+
+	events, err := h.auditService.ListAuditEvents(r.Context(), filter)
+	if err != nil {
+		utils.WriteErrorResponse(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.WriteJSONResponse(w, http.StatusOK, events)
+}
@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/spalqui/habitattrack-api/internal/services"
+	"github.com/spalqui/habitattrack-api/pkg/utils"
+)
+
+type BackupHandler struct {
+	backupService services.BackupService
+}
+
+func NewBackupHandler(backupService services.BackupService) *BackupHandler {
+	return &BackupHandler{
+		backupService: backupService,
+	}
+}
+
+func (h *BackupHandler) CreateBackup(w http.ResponseWriter, r *http.Request) {
+	utils.WriteJSONResponse(w, http.StatusAccepted, h.backupService.StartBackup(r.Context()))
+}
+
+func (h *BackupHandler) GetAllBackups(w http.ResponseWriter, r *http.Request) {
+	backups, err := h.backupService.ListBackups(r.Context())
+	if err != nil {
+		utils.WriteErrorResponse(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.WriteJSONResponse(w, http.StatusOK, backups)
+}
+
+func (h *BackupHandler) DeleteBackup(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	name := vars["name"]
+
+	if err := h.backupService.DeleteBackup(r.Context(), name); err != nil {
+		utils.WriteErrorResponse(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -1,35 +1,42 @@
 package handlers
 
 import (
-	"encoding/json"
 	"net/http"
 
 	"github.com/gorilla/mux"
 
 	"github.com/spalqui/habitattrack-api/internal/models"
 	"github.com/spalqui/habitattrack-api/internal/services"
+	"github.com/spalqui/habitattrack-api/pkg/pagetoken"
 	"github.com/spalqui/habitattrack-api/pkg/utils"
 )
 
 type CategoryHandler struct {
 	categoryService services.CategoryService
+	pageTokens      *pagetoken.Codec
 }
 
-func NewCategoryHandler(categoryService services.CategoryService) *CategoryHandler {
+func NewCategoryHandler(categoryService services.CategoryService, pageTokens *pagetoken.Codec) *CategoryHandler {
 	return &CategoryHandler{
 		categoryService: categoryService,
+		pageTokens:      pageTokens,
 	}
 }
 
 func (h *CategoryHandler) CreateCategory(w http.ResponseWriter, r *http.Request) {
 	var category models.Category
-	if err := json.NewDecoder(r.Body).Decode(&category); err != nil {
-		utils.WriteErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+	if err := utils.DecodeJSON(r, &category); err != nil {
+		utils.WriteDecodeError(w, r, invalidBodyStatus(r), err)
+		return
+	}
+
+	if validateOnly(r) {
+		writeValidationResult(w, r, h.categoryService.ValidateCategory(r.Context(), &category))
 		return
 	}
 
 	if err := h.categoryService.CreateCategory(r.Context(), &category); err != nil {
-		utils.WriteErrorResponse(w, http.StatusBadRequest, err.Error())
+		utils.WriteErrorResponse(w, r, serviceErrorStatus(err), err.Error())
 		return
 	}
 
@@ -42,7 +49,7 @@ func (h *CategoryHandler) GetCategory(w http.ResponseWriter, r *http.Request) {
 
 	category, err := h.categoryService.GetCategory(r.Context(), id)
 	if err != nil {
-		utils.WriteErrorResponse(w, http.StatusNotFound, err.Error())
+		utils.WriteErrorResponse(w, r, serviceErrorStatus(err), err.Error())
 		return
 	}
 
@@ -50,9 +57,49 @@ func (h *CategoryHandler) GetCategory(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *CategoryHandler) GetAllCategories(w http.ResponseWriter, r *http.Request) {
+	if countOnly(r) {
+		count, err := h.categoryService.CountCategories(r.Context())
+		if err != nil {
+			utils.WriteErrorResponse(w, r, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		writeCount(w, count)
+		return
+	}
+
+	page, paged, err := parsePageRequest(r, h.pageTokens)
+	if err != nil {
+		utils.WriteErrorResponse(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if paged {
+		transactionType := models.TransactionType(r.URL.Query().Get("type"))
+		result, err := h.categoryService.ListCategoriesPage(r.Context(), transactionType, page)
+		if err != nil {
+			utils.WriteErrorResponse(w, r, serviceErrorStatus(err), err.Error())
+			return
+		}
+
+		writePage(w, r, h.pageTokens, result)
+		return
+	}
+
+	if transactionType := r.URL.Query().Get("type"); transactionType != "" {
+		categories, err := h.categoryService.GetCategoriesByType(r.Context(), models.TransactionType(transactionType))
+		if err != nil {
+			utils.WriteErrorResponse(w, r, serviceErrorStatus(err), err.Error())
+			return
+		}
+
+		utils.WriteJSONResponse(w, http.StatusOK, categories)
+		return
+	}
+
 	categories, err := h.categoryService.GetAllCategories(r.Context())
 	if err != nil {
-		utils.WriteErrorResponse(w, http.StatusInternalServerError, err.Error())
+		utils.WriteErrorResponse(w, r, http.StatusInternalServerError, err.Error())
 		return
 	}
 
@@ -65,7 +112,7 @@ func (h *CategoryHandler) GetCategoriesByType(w http.ResponseWriter, r *http.Req
 
 	categories, err := h.categoryService.GetCategoriesByType(r.Context(), transactionType)
 	if err != nil {
-		utils.WriteErrorResponse(w, http.StatusBadRequest, err.Error())
+		utils.WriteErrorResponse(w, r, serviceErrorStatus(err), err.Error())
 		return
 	}
 
@@ -77,14 +124,24 @@ func (h *CategoryHandler) UpdateCategory(w http.ResponseWriter, r *http.Request)
 	id := vars["id"]
 
 	var category models.Category
-	if err := json.NewDecoder(r.Body).Decode(&category); err != nil {
-		utils.WriteErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+	if err := utils.DecodeJSON(r, &category); err != nil {
+		utils.WriteDecodeError(w, r, invalidBodyStatus(r), err)
 		return
 	}
 
 	category.ID = id
+	if validateOnly(r) {
+		if _, err := h.categoryService.GetCategory(r.Context(), id); err != nil {
+			utils.WriteErrorResponse(w, r, serviceErrorStatus(err), err.Error())
+			return
+		}
+
+		writeValidationResult(w, r, h.categoryService.ValidateCategory(r.Context(), &category))
+		return
+	}
+
 	if err := h.categoryService.UpdateCategory(r.Context(), &category); err != nil {
-		utils.WriteErrorResponse(w, http.StatusBadRequest, err.Error())
+		utils.WriteErrorResponse(w, r, serviceErrorStatus(err), err.Error())
 		return
 	}
 
@@ -95,8 +152,9 @@ func (h *CategoryHandler) DeleteCategory(w http.ResponseWriter, r *http.Request)
 	vars := mux.Vars(r)
 	id := vars["id"]
 
-	if err := h.categoryService.DeleteCategory(r.Context(), id); err != nil {
-		utils.WriteErrorResponse(w, http.StatusInternalServerError, err.Error())
+	options := models.CategoryDeleteOptions{ReassignTo: r.URL.Query().Get("reassignTo")}
+	if err := h.categoryService.DeleteCategory(r.Context(), id, options); err != nil {
+		writeDeleteError(w, r, err)
 		return
 	}
 
@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/spalqui/habitattrack-api/internal/models"
+	"github.com/spalqui/habitattrack-api/internal/services"
+	"github.com/spalqui/habitattrack-api/pkg/utils"
+)
+
+type ConsentHandler struct {
+	consentService services.ConsentService
+}
+
+func NewConsentHandler(consentService services.ConsentService) *ConsentHandler {
+	return &ConsentHandler{
+		consentService: consentService,
+	}
+}
+
+func (h *ConsentHandler) AcceptConsent(w http.ResponseWriter, r *http.Request) {
+	var consent models.Consent
+	if err := utils.DecodeJSON(r, &consent); err != nil {
+		utils.WriteDecodeError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := h.consentService.AcceptConsent(r.Context(), &consent); err != nil {
+		utils.WriteErrorResponse(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.WriteJSONResponse(w, http.StatusCreated, consent)
+}
+
+func (h *ConsentHandler) GetConsents(w http.ResponseWriter, r *http.Request) {
+	consents, err := h.consentService.GetConsents(r.Context())
+	if err != nil {
+		utils.WriteErrorResponse(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.WriteJSONResponse(w, http.StatusOK, consents)
+}
+
+// GetRequirements lists the document versions users must accept, so clients
+// know what to show after a consent_required error.
+func (h *ConsentHandler) GetRequirements(w http.ResponseWriter, r *http.Request) {
+	utils.WriteJSONResponse(w, http.StatusOK, h.consentService.GetRequirements())
+}
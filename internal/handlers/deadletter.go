@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/spalqui/habitattrack-api/internal/models"
+	"github.com/spalqui/habitattrack-api/internal/services"
+	"github.com/spalqui/habitattrack-api/pkg/utils"
+)
+
+type DeadLetterHandler struct {
+	deadLetterService services.DeadLetterService
+}
+
+func NewDeadLetterHandler(deadLetterService services.DeadLetterService) *DeadLetterHandler {
+	return &DeadLetterHandler{
+		deadLetterService: deadLetterService,
+	}
+}
+
+func deadLetterFilter(r *http.Request) models.DeadLetterFilter {
+	query := r.URL.Query()
+	return models.DeadLetterFilter{
+		Kind:      query.Get("kind"),
+		WebhookID: query.Get("webhookId"),
+		OrgID:     query.Get("orgId"),
+	}
+}
+
+func (h *DeadLetterHandler) GetDeadLetters(w http.ResponseWriter, r *http.Request) {
+	deadLetters, err := h.deadLetterService.ListDeadLetters(r.Context(), deadLetterFilter(r))
+	if err != nil {
+		utils.WriteErrorResponse(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.WriteJSONResponse(w, http.StatusOK, deadLetters)
+}
+
+func (h *DeadLetterHandler) ReplayDeadLetter(w http.ResponseWriter, r *http.Request) {
+	err := h.deadLetterService.ReplayDeadLetter(r.Context(), mux.Vars(r)["id"])
+	if errors.Is(err, services.ErrDeadLetterNotFound) {
+		utils.WriteErrorResponse(w, r, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		utils.WriteErrorResponse(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.WriteJSONResponse(w, http.StatusAccepted, models.ReplayResult{Replayed: 1})
+}
+
+// ReplayDeadLetters replays every dead letter matching the same filters as
+// the listing, e.g. all of one webhook's after its receiver recovers.
+func (h *DeadLetterHandler) ReplayDeadLetters(w http.ResponseWriter, r *http.Request) {
+	result, err := h.deadLetterService.ReplayDeadLetters(r.Context(), deadLetterFilter(r))
+	if err != nil {
+		utils.WriteErrorResponse(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.WriteJSONResponse(w, http.StatusAccepted, result)
+}
@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/spalqui/habitattrack-api/internal/models"
+	"github.com/spalqui/habitattrack-api/pkg/utils"
+)
+
+// ExportTransactions streams every matching transaction as CSV or JSON,
+// flushing after each page read from Firestore so the export never has to
+// fit in memory.
+func (h *TransactionHandler) ExportTransactions(w http.ResponseWriter, r *http.Request) {
+	var encoder transactionEncoder
+	switch format := r.URL.Query().Get("format"); format {
+	case "", "csv":
+		encoder = &csvTransactionEncoder{writer: csv.NewWriter(w)}
+	case "json":
+		encoder = &jsonTransactionEncoder{writer: w}
+	default:
+		utils.WriteErrorResponse(w, r, http.StatusBadRequest, "format must be csv or json")
+		return
+	}
+
+	filter, err := parseTransactionFilter(r, h.location)
+	if err != nil {
+		writeQueryError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	controller := http.NewResponseController(w)
+	started := false
+	begin := func() error {
+		started = true
+		w.Header().Set("Content-Type", encoder.contentType())
+		w.Header().Set("Content-Disposition", `attachment; filename="transactions.`+encoder.extension()+`"`)
+		return encoder.begin()
+	}
+
+	err = h.transactionService.ExportTransactions(r.Context(), filter, func(transactions []*models.Transaction) error {
+		if !started {
+			if err := begin(); err != nil {
+				return err
+			}
+		}
+		if err := encoder.encode(transactions); err != nil {
+			return err
+		}
+		if err := controller.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+			return err
+		}
+		return nil
+	})
+	if err == nil && !started {
+		err = begin()
+	}
+	if err == nil {
+		err = encoder.end()
+	}
+	if err == nil {
+		return
+	}
+
+	if !started {
+		utils.WriteErrorResponse(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// The status has been sent, so abort the connection rather than let a
+	// truncated export look complete
+	log.Printf("Transaction export failed part way: %v", err)
+	panic(http.ErrAbortHandler)
+}
+
+// transactionEncoder writes an export in one format, a page at a time.
+type transactionEncoder interface {
+	contentType() string
+	extension() string
+	begin() error
+	encode(transactions []*models.Transaction) error
+	end() error
+}
+
+var transactionExportColumns = []string{
+	"id", "date", "type", "propertyId", "propertyName", "categoryId", "categoryName",
+	"amount", "description", "source", "externalId", "createdAt", "updatedAt",
+}
+
+type csvTransactionEncoder struct {
+	writer *csv.Writer
+}
+
+func (e *csvTransactionEncoder) contentType() string { return "text/csv; charset=utf-8" }
+func (e *csvTransactionEncoder) extension() string   { return "csv" }
+
+func (e *csvTransactionEncoder) begin() error {
+	return e.writer.Write(transactionExportColumns)
+}
+
+func (e *csvTransactionEncoder) encode(transactions []*models.Transaction) error {
+	for _, transaction := range transactions {
+		e.writer.Write([]string{
+			transaction.ID,
+			transaction.Date.Format(time.RFC3339),
+			string(transaction.Type),
+			transaction.PropertyID,
+			transaction.PropertyName,
+			transaction.CategoryID,
+			transaction.CategoryName,
+			transaction.Amount.String(),
+			transaction.Description,
+			transaction.Source,
+			transaction.ExternalID,
+			transaction.CreatedAt.Format(time.RFC3339),
+			transaction.UpdatedAt.Format(time.RFC3339),
+		})
+	}
+
+	e.writer.Flush()
+	return e.writer.Error()
+}
+
+func (e *csvTransactionEncoder) end() error {
+	e.writer.Flush()
+	return e.writer.Error()
+}
+
+// jsonTransactionEncoder writes a JSON array one element at a time.
+type jsonTransactionEncoder struct {
+	writer  io.Writer
+	written bool
+}
+
+func (e *jsonTransactionEncoder) contentType() string { return "application/json" }
+func (e *jsonTransactionEncoder) extension() string   { return "json" }
+
+func (e *jsonTransactionEncoder) begin() error {
+	_, err := io.WriteString(e.writer, "[")
+	return err
+}
+
+func (e *jsonTransactionEncoder) encode(transactions []*models.Transaction) error {
+	for _, transaction := range transactions {
+		data, err := json.Marshal(transaction)
+		if err != nil {
+			return err
+		}
+		if e.written {
+			data = append([]byte(","), data...)
+		}
+		e.written = true
+
+		if _, err := e.writer.Write(data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *jsonTransactionEncoder) end() error {
+	_, err := io.WriteString(e.writer, "]\n")
+	return err
+}
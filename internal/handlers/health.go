@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/spalqui/habitattrack-api/internal/models"
+	"github.com/spalqui/habitattrack-api/internal/services"
+	"github.com/spalqui/habitattrack-api/pkg/utils"
+)
+
+type HealthHandler struct {
+	healthService services.HealthService
+}
+
+func NewHealthHandler(healthService services.HealthService) *HealthHandler {
+	return &HealthHandler{
+		healthService: healthService,
+	}
+}
+
+// Live reports that the process is up and serving requests. It touches no
+// dependencies, so an outage elsewhere never gets the instance restarted.
+func (h *HealthHandler) Live(w http.ResponseWriter, r *http.Request) {
+	utils.WriteJSONResponse(w, http.StatusOK, &models.HealthReport{Status: models.HealthStatusUp})
+}
+
+// Ready reports whether the instance can serve traffic, answering 503 while
+// a required dependency is unreachable so load balancers route elsewhere.
+func (h *HealthHandler) Ready(w http.ResponseWriter, r *http.Request) {
+	h.writeReport(w, h.healthService.CheckReadiness(r.Context()), true)
+}
+
+// Health probes every dependency for uptime monitors. Component detail is
+// only included with verbose=true.
+func (h *HealthHandler) Health(w http.ResponseWriter, r *http.Request) {
+	verbose := false
+	if value := r.URL.Query().Get("verbose"); value != "" {
+		var err error
+		if verbose, err = strconv.ParseBool(value); err != nil {
+			utils.WriteErrorResponse(w, r, http.StatusBadRequest, "verbose must be true or false")
+			return
+		}
+	}
+
+	h.writeReport(w, h.healthService.CheckHealth(r.Context()), verbose)
+}
+
+func (h *HealthHandler) writeReport(w http.ResponseWriter, report *models.HealthReport, verbose bool) {
+	status := http.StatusOK
+	if report.Status == models.HealthStatusDown {
+		status = http.StatusServiceUnavailable
+	}
+	if !verbose {
+		report.Components = nil
+	}
+	utils.WriteJSONResponse(w, status, report)
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/spalqui/habitattrack-api/internal/services"
+	"github.com/spalqui/habitattrack-api/pkg/utils"
+)
+
+type ImpersonationHandler struct {
+	impersonationService services.ImpersonationService
+}
+
+func NewImpersonationHandler(impersonationService services.ImpersonationService) *ImpersonationHandler {
+	return &ImpersonationHandler{
+		impersonationService: impersonationService,
+	}
+}
+
+type startImpersonationRequest struct {
+	UserID string `json:"userId"`
+	Reason string `json:"reason"`
+}
+
+func (h *ImpersonationHandler) StartImpersonation(w http.ResponseWriter, r *http.Request) {
+	var req startImpersonationRequest
+	if err := utils.DecodeJSON(r, &req); err != nil {
+		utils.WriteDecodeError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	impersonation, err := h.impersonationService.StartImpersonation(r.Context(), req.UserID, req.Reason)
+	if err != nil {
+		utils.WriteErrorResponse(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.WriteJSONResponse(w, http.StatusCreated, impersonation)
+}
+
+func (h *ImpersonationHandler) GetAllImpersonations(w http.ResponseWriter, r *http.Request) {
+	impersonations, err := h.impersonationService.GetAllImpersonations(r.Context())
+	if err != nil {
+		utils.WriteErrorResponse(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.WriteJSONResponse(w, http.StatusOK, impersonations)
+}
+
+func (h *ImpersonationHandler) EndImpersonation(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	impersonation, err := h.impersonationService.EndImpersonation(r.Context(), id)
+	if err != nil {
+		utils.WriteErrorResponse(w, r, http.StatusNotFound, err.Error())
+		return
+	}
+
+	utils.WriteJSONResponse(w, http.StatusOK, impersonation)
+}
@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/spalqui/habitattrack-api/internal/models"
+	"github.com/spalqui/habitattrack-api/internal/services"
+	"github.com/spalqui/habitattrack-api/pkg/utils"
+)
+
+type LogLevelHandler struct {
+	logLevelService services.LogLevelService
+}
+
+func NewLogLevelHandler(logLevelService services.LogLevelService) *LogLevelHandler {
+	return &LogLevelHandler{
+		logLevelService: logLevelService,
+	}
+}
+
+func (h *LogLevelHandler) GetLogLevel(w http.ResponseWriter, r *http.Request) {
+	utils.WriteJSONResponse(w, http.StatusOK, h.logLevelService.GetLogLevel())
+}
+
+func (h *LogLevelHandler) SetLogLevel(w http.ResponseWriter, r *http.Request) {
+	var logLevel models.LogLevel
+	if err := utils.DecodeJSON(r, &logLevel); err != nil {
+		utils.WriteDecodeError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := h.logLevelService.SetLogLevel(&logLevel); err != nil {
+		utils.WriteErrorResponse(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.WriteJSONResponse(w, http.StatusOK, logLevel)
+}
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/spalqui/habitattrack-api/internal/models"
+	"github.com/spalqui/habitattrack-api/internal/services"
+	"github.com/spalqui/habitattrack-api/pkg/utils"
+)
+
+type MaintenanceHandler struct {
+	maintenanceService services.MaintenanceService
+}
+
+func NewMaintenanceHandler(maintenanceService services.MaintenanceService) *MaintenanceHandler {
+	return &MaintenanceHandler{
+		maintenanceService: maintenanceService,
+	}
+}
+
+func (h *MaintenanceHandler) GetMaintenance(w http.ResponseWriter, r *http.Request) {
+	maintenance, err := h.maintenanceService.GetMaintenance(r.Context())
+	if err != nil {
+		utils.WriteErrorResponse(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.WriteJSONResponse(w, http.StatusOK, maintenance)
+}
+
+func (h *MaintenanceHandler) SetMaintenance(w http.ResponseWriter, r *http.Request) {
+	var maintenance models.Maintenance
+	if err := utils.DecodeJSON(r, &maintenance); err != nil {
+		utils.WriteDecodeError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := h.maintenanceService.SetMaintenance(r.Context(), &maintenance); err != nil {
+		utils.WriteErrorResponse(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.WriteJSONResponse(w, http.StatusOK, maintenance)
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/spalqui/habitattrack-api/internal/models"
+	"github.com/spalqui/habitattrack-api/internal/services"
+	"github.com/spalqui/habitattrack-api/pkg/utils"
+)
+
+type MemberHandler struct {
+	memberService services.MemberService
+}
+
+func NewMemberHandler(memberService services.MemberService) *MemberHandler {
+	return &MemberHandler{
+		memberService: memberService,
+	}
+}
+
+func (h *MemberHandler) CreateMember(w http.ResponseWriter, r *http.Request) {
+	var member models.Member
+	if err := utils.DecodeJSON(r, &member); err != nil {
+		utils.WriteDecodeError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := h.memberService.CreateMember(r.Context(), &member); err != nil {
+		utils.WriteErrorResponse(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.WriteJSONResponse(w, http.StatusCreated, member)
+}
+
+func (h *MemberHandler) GetAllMembers(w http.ResponseWriter, r *http.Request) {
+	members, err := h.memberService.GetAllMembers(r.Context())
+	if err != nil {
+		utils.WriteErrorResponse(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.WriteJSONResponse(w, http.StatusOK, members)
+}
+
+func (h *MemberHandler) UpdateMember(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	var member models.Member
+	if err := utils.DecodeJSON(r, &member); err != nil {
+		utils.WriteDecodeError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	member.ID = id
+	if err := h.memberService.UpdateMember(r.Context(), &member); err != nil {
+		utils.WriteErrorResponse(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.WriteJSONResponse(w, http.StatusOK, member)
+}
+
+func (h *MemberHandler) DeleteMember(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	if err := h.memberService.DeleteMember(r.Context(), id); err != nil {
+		utils.WriteErrorResponse(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
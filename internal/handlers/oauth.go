@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+
+	"github.com/spalqui/habitattrack-api/internal/models"
+	"github.com/spalqui/habitattrack-api/internal/services"
+	"github.com/spalqui/habitattrack-api/pkg/utils"
+)
+
+type OAuthHandler struct {
+	clientService services.OAuthClientService
+}
+
+func NewOAuthHandler(clientService services.OAuthClientService) *OAuthHandler {
+	return &OAuthHandler{
+		clientService: clientService,
+	}
+}
+
+func (h *OAuthHandler) CreateClient(w http.ResponseWriter, r *http.Request) {
+	var client models.OAuthClient
+	if err := utils.DecodeJSON(r, &client); err != nil {
+		utils.WriteDecodeError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := h.clientService.CreateClient(r.Context(), &client); err != nil {
+		utils.WriteErrorResponse(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.WriteJSONResponse(w, http.StatusCreated, client)
+}
+
+func (h *OAuthHandler) GetAllClients(w http.ResponseWriter, r *http.Request) {
+	clients, err := h.clientService.GetAllClients(r.Context())
+	if err != nil {
+		utils.WriteErrorResponse(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.WriteJSONResponse(w, http.StatusOK, clients)
+}
+
+func (h *OAuthHandler) RevokeClient(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	if err := h.clientService.RevokeClient(r.Context(), id); err != nil {
+		utils.WriteErrorResponse(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Token is the OAuth2 token endpoint. Errors use the RFC 6749 format rather
+// than the API's own, since OAuth client libraries parse them.
+func (h *OAuthHandler) Token(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+
+	if err := r.ParseForm(); err != nil {
+		writeOAuthError(w, http.StatusBadRequest, "invalid_request")
+		return
+	}
+
+	if r.PostForm.Get("grant_type") != "client_credentials" {
+		writeOAuthError(w, http.StatusBadRequest, "unsupported_grant_type")
+		return
+	}
+
+	clientID, clientSecret, ok := r.BasicAuth()
+	if !ok {
+		clientID = r.PostForm.Get("client_id")
+		clientSecret = r.PostForm.Get("client_secret")
+	}
+
+	token, err := h.clientService.IssueToken(r.Context(), clientID, clientSecret, strings.Fields(r.PostForm.Get("scope")))
+	switch {
+	case errors.Is(err, services.ErrInvalidClient):
+		w.Header().Set("WWW-Authenticate", "Basic")
+		writeOAuthError(w, http.StatusUnauthorized, "invalid_client")
+	case errors.Is(err, services.ErrInvalidScope):
+		writeOAuthError(w, http.StatusBadRequest, "invalid_scope")
+	case err != nil:
+		writeOAuthError(w, http.StatusInternalServerError, "server_error")
+	default:
+		utils.WriteJSONResponse(w, http.StatusOK, token)
+	}
+}
+
+func writeOAuthError(w http.ResponseWriter, status int, code string) {
+	utils.WriteJSONResponse(w, status, map[string]string{"error": code})
+}
@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/spalqui/habitattrack-api/internal/models"
+	"github.com/spalqui/habitattrack-api/internal/services"
+	"github.com/spalqui/habitattrack-api/pkg/utils"
+)
+
+type OrganizationHandler struct {
+	organizationService services.OrganizationService
+}
+
+func NewOrganizationHandler(organizationService services.OrganizationService) *OrganizationHandler {
+	return &OrganizationHandler{
+		organizationService: organizationService,
+	}
+}
+
+func (h *OrganizationHandler) CreateOrganization(w http.ResponseWriter, r *http.Request) {
+	var organization models.Organization
+	if err := utils.DecodeJSON(r, &organization); err != nil {
+		utils.WriteDecodeError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := h.organizationService.CreateOrganization(r.Context(), &organization); err != nil {
+		utils.WriteErrorResponse(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.WriteJSONResponse(w, http.StatusCreated, organization)
+}
+
+func (h *OrganizationHandler) GetOrganization(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	organization, err := h.organizationService.GetOrganization(r.Context(), id)
+	if err != nil {
+		utils.WriteErrorResponse(w, r, http.StatusNotFound, err.Error())
+		return
+	}
+
+	utils.WriteJSONResponse(w, http.StatusOK, organization)
+}
+
+func (h *OrganizationHandler) GetAllOrganizations(w http.ResponseWriter, r *http.Request) {
+	organizations, err := h.organizationService.GetAllOrganizations(r.Context())
+	if err != nil {
+		utils.WriteErrorResponse(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.WriteJSONResponse(w, http.StatusOK, organizations)
+}
+
+func (h *OrganizationHandler) UpdateOrganization(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	var organization models.Organization
+	if err := utils.DecodeJSON(r, &organization); err != nil {
+		utils.WriteDecodeError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	organization.ID = id
+	if err := h.organizationService.UpdateOrganization(r.Context(), &organization); err != nil {
+		utils.WriteErrorResponse(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.WriteJSONResponse(w, http.StatusOK, organization)
+}
@@ -1,35 +1,43 @@
 package handlers
 
 import (
-	"encoding/json"
 	"net/http"
+	"strconv"
 
 	"github.com/gorilla/mux"
 
 	"github.com/spalqui/habitattrack-api/internal/models"
 	"github.com/spalqui/habitattrack-api/internal/services"
+	"github.com/spalqui/habitattrack-api/pkg/pagetoken"
 	"github.com/spalqui/habitattrack-api/pkg/utils"
 )
 
 type PropertyHandler struct {
 	propertyService services.PropertyService
+	pageTokens      *pagetoken.Codec
 }
 
-func NewPropertyHandler(propertyService services.PropertyService) *PropertyHandler {
+func NewPropertyHandler(propertyService services.PropertyService, pageTokens *pagetoken.Codec) *PropertyHandler {
 	return &PropertyHandler{
 		propertyService: propertyService,
+		pageTokens:      pageTokens,
 	}
 }
 
 func (h *PropertyHandler) CreateProperty(w http.ResponseWriter, r *http.Request) {
 	var property models.Property
-	if err := json.NewDecoder(r.Body).Decode(&property); err != nil {
-		utils.WriteErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+	if err := utils.DecodeJSON(r, &property); err != nil {
+		utils.WriteDecodeError(w, r, invalidBodyStatus(r), err)
+		return
+	}
+
+	if validateOnly(r) {
+		writeValidationResult(w, r, h.propertyService.ValidateProperty(r.Context(), &property))
 		return
 	}
 
 	if err := h.propertyService.CreateProperty(r.Context(), &property); err != nil {
-		utils.WriteErrorResponse(w, http.StatusInternalServerError, err.Error())
+		utils.WriteErrorResponse(w, r, serviceErrorStatus(err), err.Error())
 		return
 	}
 
@@ -42,17 +50,63 @@ func (h *PropertyHandler) GetProperty(w http.ResponseWriter, r *http.Request) {
 
 	property, err := h.propertyService.GetProperty(r.Context(), id)
 	if err != nil {
-		utils.WriteErrorResponse(w, http.StatusNotFound, err.Error())
+		utils.WriteErrorResponse(w, r, serviceErrorStatus(err), err.Error())
 		return
 	}
 
 	utils.WriteJSONResponse(w, http.StatusOK, property)
 }
 
+func (h *PropertyHandler) GetPropertyTotals(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	if _, err := h.propertyService.GetProperty(r.Context(), id); err != nil {
+		utils.WriteErrorResponse(w, r, serviceErrorStatus(err), err.Error())
+		return
+	}
+
+	totals, err := h.propertyService.GetPropertyTotals(r.Context(), id)
+	if err != nil {
+		utils.WriteErrorResponse(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.WriteJSONResponse(w, http.StatusOK, totals)
+}
+
 func (h *PropertyHandler) GetAllProperties(w http.ResponseWriter, r *http.Request) {
+	if countOnly(r) {
+		count, err := h.propertyService.CountProperties(r.Context())
+		if err != nil {
+			utils.WriteErrorResponse(w, r, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		writeCount(w, count)
+		return
+	}
+
+	page, paged, err := parsePageRequest(r, h.pageTokens)
+	if err != nil {
+		utils.WriteErrorResponse(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if paged {
+		result, err := h.propertyService.ListPropertiesPage(r.Context(), page)
+		if err != nil {
+			utils.WriteErrorResponse(w, r, pageErrorStatus(err), err.Error())
+			return
+		}
+
+		writePage(w, r, h.pageTokens, result)
+		return
+	}
+
 	properties, err := h.propertyService.GetAllProperties(r.Context())
 	if err != nil {
-		utils.WriteErrorResponse(w, http.StatusInternalServerError, err.Error())
+		utils.WriteErrorResponse(w, r, http.StatusInternalServerError, err.Error())
 		return
 	}
 
@@ -64,26 +118,86 @@ func (h *PropertyHandler) UpdateProperty(w http.ResponseWriter, r *http.Request)
 	id := vars["id"]
 
 	var property models.Property
-	if err := json.NewDecoder(r.Body).Decode(&property); err != nil {
-		utils.WriteErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+	if err := utils.DecodeJSON(r, &property); err != nil {
+		utils.WriteDecodeError(w, r, invalidBodyStatus(r), err)
 		return
 	}
 
 	property.ID = id
+	if validateOnly(r) {
+		if _, err := h.propertyService.GetProperty(r.Context(), id); err != nil {
+			utils.WriteErrorResponse(w, r, serviceErrorStatus(err), err.Error())
+			return
+		}
+
+		writeValidationResult(w, r, h.propertyService.ValidateProperty(r.Context(), &property))
+		return
+	}
+
 	if err := h.propertyService.UpdateProperty(r.Context(), &property); err != nil {
-		utils.WriteErrorResponse(w, http.StatusInternalServerError, err.Error())
+		utils.WriteErrorResponse(w, r, serviceErrorStatus(err), err.Error())
 		return
 	}
 
 	utils.WriteJSONResponse(w, http.StatusOK, property)
 }
 
+// PatchProperty changes only the fields in the request body; a null name,
+// description or timezone clears it.
+func (h *PropertyHandler) PatchProperty(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	var patch models.PropertyPatch
+	if err := utils.DecodeJSON(r, &patch); err != nil {
+		utils.WriteDecodeError(w, r, invalidBodyStatus(r), err)
+		return
+	}
+
+	property, err := h.propertyService.PatchProperty(r.Context(), id, patch)
+	if err != nil {
+		utils.WriteErrorResponse(w, r, serviceErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.WriteJSONResponse(w, http.StatusOK, property)
+}
+
+func (h *PropertyHandler) CloneProperty(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	if _, err := h.propertyService.GetProperty(r.Context(), id); err != nil {
+		utils.WriteErrorResponse(w, r, serviceErrorStatus(err), err.Error())
+		return
+	}
+
+	property, err := h.propertyService.CloneProperty(r.Context(), id)
+	if err != nil {
+		utils.WriteErrorResponse(w, r, serviceErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.WriteJSONResponse(w, http.StatusCreated, property)
+}
+
 func (h *PropertyHandler) DeleteProperty(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id := vars["id"]
 
-	if err := h.propertyService.DeleteProperty(r.Context(), id); err != nil {
-		utils.WriteErrorResponse(w, http.StatusInternalServerError, err.Error())
+	query := r.URL.Query()
+	options := models.PropertyDeleteOptions{ReassignTo: query.Get("reassignTo")}
+	if value := query.Get("cascade"); value != "" {
+		cascade, err := strconv.ParseBool(value)
+		if err != nil {
+			utils.WriteErrorResponse(w, r, http.StatusBadRequest, "cascade must be true or false")
+			return
+		}
+		options.Cascade = cascade
+	}
+
+	if err := h.propertyService.DeleteProperty(r.Context(), id, options); err != nil {
+		writeDeleteError(w, r, err)
 		return
 	}
 
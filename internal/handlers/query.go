@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/spalqui/habitattrack-api/internal/models"
+	"github.com/spalqui/habitattrack-api/internal/repositories"
+	"github.com/spalqui/habitattrack-api/internal/services"
+	"github.com/spalqui/habitattrack-api/pkg/pagetoken"
+	"github.com/spalqui/habitattrack-api/pkg/utils"
+)
+
+// serviceErrorStatus maps a failed transaction, property or category
+// operation to a status code. Errors the services don't attribute to the
+// request, such as the backend being unavailable, are 500s.
+func serviceErrorStatus(err error) int {
+	var validationErr *services.ValidationError
+	var fieldErr *services.FieldError
+	var limitErr *services.LimitError
+	var inUseErr *services.InUseError
+	switch {
+	case errors.As(err, &validationErr), errors.As(err, &fieldErr), errors.Is(err, repositories.ErrInvalidPageToken):
+		return http.StatusBadRequest
+	case errors.As(err, &limitErr), errors.Is(err, services.ErrFutureDate):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, repositories.ErrTransactionNotFound), errors.Is(err, repositories.ErrPropertyNotFound), errors.Is(err, repositories.ErrCategoryNotFound),
+		errors.Is(err, repositories.ErrWebhookNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, repositories.ErrExternalIDExists), errors.Is(err, repositories.ErrPropertyNameExists), errors.Is(err, repositories.ErrCategoryNameExists),
+		errors.Is(err, repositories.ErrUpdateConflict), errors.Is(err, services.ErrCategoryTypeInUse), errors.As(err, &inUseErr):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// pageErrorStatus maps a failed paged listing to a status code.
+func pageErrorStatus(err error) int {
+	if errors.Is(err, repositories.ErrInvalidPageToken) {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+func countOnly(r *http.Request) bool {
+	value, _ := strconv.ParseBool(r.URL.Query().Get("countOnly"))
+	return value
+}
+
+// parsePageRequest reads the limit, pageToken and totalsMode parameters.
+// Paging is opt-in: without a limit, list endpoints keep returning a plain
+// array of every item. Tokens are rejected unless issued for the same path
+// and filters; the limit and totals mode may change between pages.
+func parsePageRequest(r *http.Request, tokens *pagetoken.Codec) (models.PageRequest, bool, error) {
+	value := r.URL.Query().Get("limit")
+	if value == "" {
+		return models.PageRequest{}, false, nil
+	}
+
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit < 1 || limit > models.MaxPageSize {
+		return models.PageRequest{}, false, fmt.Errorf("limit must be between 1 and %d", models.MaxPageSize)
+	}
+
+	page := models.PageRequest{Limit: limit, Totals: models.TotalsExact}
+	switch totals := models.TotalsMode(r.URL.Query().Get("totalsMode")); totals {
+	case "":
+	case models.TotalsExact, models.TotalsNone, models.TotalsEstimate:
+		page.Totals = totals
+	default:
+		return models.PageRequest{}, false, errors.New("totalsMode must be exact, none or estimate")
+	}
+
+	if token := r.URL.Query().Get("pageToken"); token != "" {
+		cursor, err := tokens.Decode(token, pageFilters(r))
+		if err != nil {
+			return models.PageRequest{}, false, err
+		}
+		page.Cursor = cursor.ID
+		page.Backward = cursor.Backward
+	}
+
+	return page, true, nil
+}
+
+// writePage responds with the page, issuing tokens for its neighbours.
+func writePage[T any](w http.ResponseWriter, r *http.Request, tokens *pagetoken.Codec, page *models.Page[T]) {
+	filters := pageFilters(r)
+	if page.NextCursor != "" {
+		page.NextPageToken = tokens.Encode(pagetoken.Cursor{ID: page.NextCursor}, filters)
+	}
+	if page.PrevCursor != "" {
+		page.PrevPageToken = tokens.Encode(pagetoken.Cursor{ID: page.PrevCursor, Backward: true}, filters)
+	}
+
+	utils.WriteJSONResponse(w, http.StatusOK, page)
+}
+
+// pageFilters identifies the query a page token belongs to: the path and
+// every parameter except those that only shape the page.
+func pageFilters(r *http.Request) string {
+	query := r.URL.Query()
+	query.Del("limit")
+	query.Del("pageToken")
+	query.Del("totalsMode")
+	return r.URL.Path + "?" + query.Encode()
+}
+
+// validateOnly reports whether a create or update should only be checked,
+// not persisted.
+func validateOnly(r *http.Request) bool {
+	value, _ := strconv.ParseBool(r.URL.Query().Get("validateOnly"))
+	return value
+}
+
+// runAsync reports whether a long-running operation should be queued as a
+// task rather than run during the request.
+func runAsync(r *http.Request) bool {
+	value, _ := strconv.ParseBool(r.URL.Query().Get("async"))
+	return value
+}
+
+func invalidBodyStatus(r *http.Request) int {
+	if validateOnly(r) {
+		return http.StatusUnprocessableEntity
+	}
+	return http.StatusBadRequest
+}
+
+// writeQueryError answers a failed request, naming the offending field of
+// one that failed validation or exceeded a limit. Other errors are answered
+// with status.
+func writeQueryError(w http.ResponseWriter, r *http.Request, err error, status int) {
+	var fieldErr *services.FieldError
+	var limitErr *services.LimitError
+	switch {
+	case errors.As(err, &fieldErr):
+		utils.WriteErrorResponseWithDetails(w, r, http.StatusBadRequest, "", err.Error(), map[string]string{"field": fieldErr.Field})
+	case errors.As(err, &limitErr):
+		utils.WriteErrorResponseWithDetails(w, r, http.StatusUnprocessableEntity, "", err.Error(), map[string]string{"field": limitErr.Field})
+	default:
+		utils.WriteErrorResponse(w, r, status, err.Error())
+	}
+}
+
+// writeValidationResult answers a validateOnly request. A validation
+// failure is the answer rather than a bad request, so it's a 422.
+func writeValidationResult(w http.ResponseWriter, r *http.Request, err error) {
+	if err != nil {
+		status := serviceErrorStatus(err)
+		if status == http.StatusBadRequest {
+			status = http.StatusUnprocessableEntity
+		}
+		utils.WriteErrorResponse(w, r, status, err.Error())
+		return
+	}
+
+	utils.WriteJSONResponse(w, http.StatusOK, map[string]bool{"valid": true})
+}
+
+// ErrorCodeInUse marks a delete refused because other records refer to the
+// resource; the details count them by kind.
+const ErrorCodeInUse = "in_use"
+
+// writeDeleteError answers a failed delete, listing what still refers to the
+// resource when that's why it failed.
+func writeDeleteError(w http.ResponseWriter, r *http.Request, err error) {
+	var inUse *services.InUseError
+	if errors.As(err, &inUse) {
+		utils.WriteErrorResponseWithDetails(w, r, http.StatusConflict, ErrorCodeInUse, err.Error(), inUse.Dependents)
+		return
+	}
+
+	utils.WriteErrorResponse(w, r, serviceErrorStatus(err), err.Error())
+}
+
+func writeCount(w http.ResponseWriter, count int64) {
+	w.Header().Set("X-Total-Count", strconv.FormatInt(count, 10))
+	utils.WriteJSONResponse(w, http.StatusOK, map[string]int64{"count": count})
+}
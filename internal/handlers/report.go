@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/spalqui/habitattrack-api/internal/models"
+	"github.com/spalqui/habitattrack-api/internal/services"
+	"github.com/spalqui/habitattrack-api/pkg/utils"
+)
+
+type ReportHandler struct {
+	reportService services.ReportService
+}
+
+func NewReportHandler(reportService services.ReportService) *ReportHandler {
+	return &ReportHandler{
+		reportService: reportService,
+	}
+}
+
+func (h *ReportHandler) GetMonthlyReport(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := models.RollupFilter{
+		PropertyID: query.Get("propertyId"),
+		From:       query.Get("from"),
+		To:         query.Get("to"),
+	}
+
+	if !validMonth(filter.From) {
+		utils.WriteErrorResponse(w, r, http.StatusBadRequest, "from must be a month in YYYY-MM format")
+		return
+	}
+	if !validMonth(filter.To) {
+		utils.WriteErrorResponse(w, r, http.StatusBadRequest, "to must be a month in YYYY-MM format")
+		return
+	}
+
+	report, err := h.reportService.GetMonthlyReport(r.Context(), filter)
+	if err != nil {
+		writeQueryError(w, r, err, http.StatusInternalServerError)
+		return
+	}
+
+	utils.WriteJSONResponse(w, http.StatusOK, report)
+}
+
+// validMonth accepts an empty month, meaning no bound, or one in the
+// rollups' format.
+func validMonth(month string) bool {
+	if month == "" {
+		return true
+	}
+	_, err := time.Parse(models.RollupMonthFormat, month)
+	return err == nil
+}
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/spalqui/habitattrack-api/internal/services"
+	"github.com/spalqui/habitattrack-api/pkg/utils"
+)
+
+type RevocationHandler struct {
+	revocationService services.RevocationService
+}
+
+func NewRevocationHandler(revocationService services.RevocationService) *RevocationHandler {
+	return &RevocationHandler{
+		revocationService: revocationService,
+	}
+}
+
+type revokeRequest struct {
+	Reason string `json:"reason"`
+}
+
+func (h *RevocationHandler) RevokeUserSessions(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	userID := vars["userId"]
+
+	req, ok := decodeRevokeRequest(w, r)
+	if !ok {
+		return
+	}
+
+	revocation, err := h.revocationService.RevokeUserSessions(r.Context(), userID, req.Reason)
+	if err != nil {
+		utils.WriteErrorResponse(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.WriteJSONResponse(w, http.StatusCreated, revocation)
+}
+
+func (h *RevocationHandler) RevokeToken(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	tokenID := vars["tokenId"]
+
+	req, ok := decodeRevokeRequest(w, r)
+	if !ok {
+		return
+	}
+
+	revocation, err := h.revocationService.RevokeToken(r.Context(), tokenID, req.Reason)
+	if err != nil {
+		utils.WriteErrorResponse(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.WriteJSONResponse(w, http.StatusCreated, revocation)
+}
+
+func (h *RevocationHandler) GetAllRevocations(w http.ResponseWriter, r *http.Request) {
+	revocations, err := h.revocationService.GetAllRevocations(r.Context())
+	if err != nil {
+		utils.WriteErrorResponse(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.WriteJSONResponse(w, http.StatusOK, revocations)
+}
+
+// decodeRevokeRequest reads the optional body of a revoke call.
+func decodeRevokeRequest(w http.ResponseWriter, r *http.Request) (revokeRequest, bool) {
+	var req revokeRequest
+	if r.ContentLength != 0 {
+		if err := utils.DecodeJSON(r, &req); err != nil {
+			utils.WriteDecodeError(w, r, http.StatusBadRequest, err)
+			return req, false
+		}
+	}
+	return req, true
+}
@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/spalqui/habitattrack-api/pkg/tasks"
+	"github.com/spalqui/habitattrack-api/pkg/utils"
+)
+
+// TaskHandler receives tasks delivered by the task queue. A failure response
+// has the queue retry the task.
+type TaskHandler struct {
+	registry *tasks.Registry
+}
+
+func NewTaskHandler(registry *tasks.Registry) *TaskHandler {
+	return &TaskHandler{
+		registry: registry,
+	}
+}
+
+func (h *TaskHandler) RunTask(w http.ResponseWriter, r *http.Request) {
+	var task tasks.Task
+	if err := utils.DecodeJSON(r, &task); err != nil {
+		utils.WriteDecodeError(w, r, http.StatusBadRequest, err)
+		return
+	}
+	task.Name = mux.Vars(r)["name"]
+
+	err := h.registry.Run(r.Context(), &task)
+	if errors.Is(err, tasks.ErrUnknownTask) {
+		utils.WriteErrorResponse(w, r, http.StatusNotFound, "task not found")
+		return
+	}
+	if err != nil {
+		log.Printf("Task %s failed: %v", task.Name, err)
+		utils.WriteErrorResponse(w, r, http.StatusInternalServerError, "task failed")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
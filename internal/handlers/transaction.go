@@ -1,39 +1,88 @@
 package handlers
 
 import (
-	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
 	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
 
 	"github.com/spalqui/habitattrack-api/internal/models"
 	"github.com/spalqui/habitattrack-api/internal/services"
+	"github.com/spalqui/habitattrack-api/pkg/money"
+	"github.com/spalqui/habitattrack-api/pkg/pagetoken"
 	"github.com/spalqui/habitattrack-api/pkg/utils"
 )
 
 type TransactionHandler struct {
 	transactionService services.TransactionService
+	location           *time.Location
+	pageTokens         *pagetoken.Codec
 }
 
-func NewTransactionHandler(transactionService services.TransactionService) *TransactionHandler {
+func NewTransactionHandler(transactionService services.TransactionService, location *time.Location, pageTokens *pagetoken.Codec) *TransactionHandler {
 	return &TransactionHandler{
 		transactionService: transactionService,
+		location:           location,
+		pageTokens:         pageTokens,
 	}
 }
 
 func (h *TransactionHandler) CreateTransaction(w http.ResponseWriter, r *http.Request) {
 	var transaction models.Transaction
-	if err := json.NewDecoder(r.Body).Decode(&transaction); err != nil {
-		utils.WriteErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+	if err := utils.DecodeJSON(r, &transaction); err != nil {
+		utils.WriteDecodeError(w, r, invalidBodyStatus(r), err)
+		return
+	}
+
+	if validateOnly(r) {
+		writeValidationResult(w, r, h.transactionService.ValidateTransaction(r.Context(), &transaction))
 		return
 	}
 
 	if err := h.transactionService.CreateTransaction(r.Context(), &transaction); err != nil {
-		utils.WriteErrorResponse(w, http.StatusBadRequest, err.Error())
+		utils.WriteErrorResponse(w, r, serviceErrorStatus(err), err.Error())
 		return
 	}
 
-	utils.WriteJSONResponse(w, http.StatusCreated, transaction)
+	// The transaction is already created, so a failed check only loses the warnings
+	warnings, err := h.transactionService.Warnings(r.Context(), &transaction)
+	if err != nil {
+		log.Printf("Failed to check transaction %s for warnings: %v", transaction.ID, err)
+	}
+	for i := range warnings {
+		warnings[i].Message = utils.Translate(r, warnings[i].Message)
+	}
+
+	utils.WriteJSONResponse(w, http.StatusCreated, createTransactionResponse{Transaction: &transaction, Warnings: warnings})
+}
+
+// createTransactionResponse is the created transaction with any warnings
+// about it alongside its fields.
+type createTransactionResponse struct {
+	*models.Transaction
+	Warnings []models.Warning `json:"warnings,omitempty"`
+}
+
+func (h *TransactionHandler) CreateTransactions(w http.ResponseWriter, r *http.Request) {
+	var transactions []*models.Transaction
+	if err := utils.DecodeJSON(r, &transactions); err != nil {
+		utils.WriteDecodeError(w, r, invalidBodyStatus(r), err)
+		return
+	}
+
+	result, err := h.transactionService.CreateTransactions(r.Context(), transactions)
+	if err != nil {
+		utils.WriteErrorResponse(w, r, serviceErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.WriteJSONResponse(w, http.StatusOK, result)
 }
 
 func (h *TransactionHandler) GetTransaction(w http.ResponseWriter, r *http.Request) {
@@ -42,7 +91,7 @@ func (h *TransactionHandler) GetTransaction(w http.ResponseWriter, r *http.Reque
 
 	transaction, err := h.transactionService.GetTransaction(r.Context(), id)
 	if err != nil {
-		utils.WriteErrorResponse(w, http.StatusNotFound, err.Error())
+		utils.WriteErrorResponse(w, r, serviceErrorStatus(err), err.Error())
 		return
 	}
 
@@ -50,9 +99,43 @@ func (h *TransactionHandler) GetTransaction(w http.ResponseWriter, r *http.Reque
 }
 
 func (h *TransactionHandler) GetAllTransactions(w http.ResponseWriter, r *http.Request) {
-	transactions, err := h.transactionService.GetAllTransactions(r.Context())
+	filter, err := parseTransactionFilter(r, h.location)
+	if err != nil {
+		writeQueryError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	if countOnly(r) {
+		count, err := h.transactionService.CountTransactions(r.Context(), filter)
+		if err != nil {
+			writeQueryError(w, r, err, http.StatusInternalServerError)
+			return
+		}
+
+		writeCount(w, count)
+		return
+	}
+
+	page, paged, err := parsePageRequest(r, h.pageTokens)
+	if err != nil {
+		utils.WriteErrorResponse(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if paged {
+		result, err := h.transactionService.ListTransactionsPage(r.Context(), filter, page)
+		if err != nil {
+			writeQueryError(w, r, err, pageErrorStatus(err))
+			return
+		}
+
+		writePage(w, r, h.pageTokens, result)
+		return
+	}
+
+	transactions, err := h.transactionService.ListTransactions(r.Context(), filter)
 	if err != nil {
-		utils.WriteErrorResponse(w, http.StatusInternalServerError, err.Error())
+		writeQueryError(w, r, err, http.StatusInternalServerError)
 		return
 	}
 
@@ -65,7 +148,7 @@ func (h *TransactionHandler) GetTransactionsByProperty(w http.ResponseWriter, r
 
 	transactions, err := h.transactionService.GetTransactionsByProperty(r.Context(), propertyID)
 	if err != nil {
-		utils.WriteErrorResponse(w, http.StatusBadRequest, err.Error())
+		utils.WriteErrorResponse(w, r, serviceErrorStatus(err), err.Error())
 		return
 	}
 
@@ -77,28 +160,267 @@ func (h *TransactionHandler) UpdateTransaction(w http.ResponseWriter, r *http.Re
 	id := vars["id"]
 
 	var transaction models.Transaction
-	if err := json.NewDecoder(r.Body).Decode(&transaction); err != nil {
-		utils.WriteErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+	if err := utils.DecodeJSON(r, &transaction); err != nil {
+		utils.WriteDecodeError(w, r, invalidBodyStatus(r), err)
 		return
 	}
 
 	transaction.ID = id
+	if validateOnly(r) {
+		if _, err := h.transactionService.GetTransaction(r.Context(), id); err != nil {
+			utils.WriteErrorResponse(w, r, serviceErrorStatus(err), err.Error())
+			return
+		}
+
+		writeValidationResult(w, r, h.transactionService.ValidateTransaction(r.Context(), &transaction))
+		return
+	}
+
 	if err := h.transactionService.UpdateTransaction(r.Context(), &transaction); err != nil {
-		utils.WriteErrorResponse(w, http.StatusBadRequest, err.Error())
+		utils.WriteErrorResponse(w, r, serviceErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.WriteJSONResponse(w, http.StatusOK, transaction)
+}
+
+// PatchTransaction changes only the fields in the request body; a null
+// description clears it.
+func (h *TransactionHandler) PatchTransaction(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	var patch models.TransactionPatch
+	if err := utils.DecodeJSON(r, &patch); err != nil {
+		utils.WriteDecodeError(w, r, invalidBodyStatus(r), err)
+		return
+	}
+
+	transaction, err := h.transactionService.PatchTransaction(r.Context(), id, patch)
+	if err != nil {
+		utils.WriteErrorResponse(w, r, serviceErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.WriteJSONResponse(w, http.StatusOK, transaction)
+}
+
+type duplicateTransactionRequest struct {
+	Date   string        `json:"date"`
+	Amount *money.Amount `json:"amount"`
+}
+
+func (h *TransactionHandler) UpsertExternalTransaction(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	var transaction models.Transaction
+	if err := utils.DecodeJSON(r, &transaction); err != nil {
+		utils.WriteDecodeError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	transaction.Source = vars["source"]
+	transaction.ExternalID = vars["externalId"]
+
+	created, err := h.transactionService.UpsertExternalTransaction(r.Context(), &transaction)
+	if err != nil {
+		utils.WriteErrorResponse(w, r, serviceErrorStatus(err), err.Error())
+		return
+	}
+
+	if created {
+		utils.WriteJSONResponse(w, http.StatusCreated, transaction)
 		return
 	}
 
 	utils.WriteJSONResponse(w, http.StatusOK, transaction)
 }
 
+func (h *TransactionHandler) DuplicateTransaction(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	// The body is optional; without one the transaction is copied as-is
+	var req duplicateTransactionRequest
+	if r.ContentLength != 0 {
+		if err := utils.DecodeJSON(r, &req); err != nil {
+			utils.WriteDecodeError(w, r, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	overrides := models.TransactionOverrides{Amount: req.Amount}
+	if req.Date != "" {
+		date, err := utils.ParseTimeParam(req.Date, h.location, false)
+		if err != nil {
+			utils.WriteErrorResponse(w, r, http.StatusBadRequest, "date must be an RFC3339 timestamp or a YYYY-MM-DD date")
+			return
+		}
+		overrides.Date = &date
+	}
+
+	transaction, err := h.transactionService.DuplicateTransaction(r.Context(), id, overrides)
+	if err != nil {
+		utils.WriteErrorResponse(w, r, serviceErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.WriteJSONResponse(w, http.StatusCreated, transaction)
+}
+
 func (h *TransactionHandler) DeleteTransaction(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id := vars["id"]
 
 	if err := h.transactionService.DeleteTransaction(r.Context(), id); err != nil {
-		utils.WriteErrorResponse(w, http.StatusInternalServerError, err.Error())
+		utils.WriteErrorResponse(w, r, serviceErrorStatus(err), err.Error())
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+func (h *TransactionHandler) GetDeletedTransactions(w http.ResponseWriter, r *http.Request) {
+	transactions, err := h.transactionService.ListDeletedTransactions(r.Context())
+	if err != nil {
+		utils.WriteErrorResponse(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.WriteJSONResponse(w, http.StatusOK, transactions)
+}
+
+func (h *TransactionHandler) PurgeDeletedTransactions(w http.ResponseWriter, r *http.Request) {
+	var olderThan time.Time
+	if value := r.URL.Query().Get("olderThan"); value != "" {
+		var err error
+		olderThan, err = utils.ParseTimeParam(value, h.location, false)
+		if err != nil {
+			utils.WriteErrorResponse(w, r, http.StatusBadRequest, "olderThan must be an RFC3339 timestamp or a YYYY-MM-DD date")
+			return
+		}
+	}
+
+	if runAsync(r) {
+		if err := h.transactionService.QueuePurgeDeletedTransactions(r.Context(), olderThan); err != nil {
+			utils.WriteErrorResponse(w, r, serviceErrorStatus(err), err.Error())
+			return
+		}
+
+		utils.WriteJSONResponse(w, http.StatusAccepted, map[string]string{"status": "queued"})
+		return
+	}
+
+	purged, err := h.transactionService.PurgeDeletedTransactions(r.Context(), olderThan)
+	if err != nil {
+		utils.WriteErrorResponse(w, r, serviceErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.WriteJSONResponse(w, http.StatusOK, map[string]int{"purged": purged})
+}
+
+type reassignCategoryRequest struct {
+	FromCategoryID string `json:"fromCategoryId"`
+	ToCategoryID   string `json:"toCategoryId"`
+	PropertyID     string `json:"propertyId"`
+	StartDate      string `json:"startDate"`
+	EndDate        string `json:"endDate"`
+	DryRun         bool   `json:"dryRun"`
+}
+
+func (h *TransactionHandler) ReassignCategory(w http.ResponseWriter, r *http.Request) {
+	var req reassignCategoryRequest
+	if err := utils.DecodeJSON(r, &req); err != nil {
+		utils.WriteDecodeError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	filter := models.TransactionFilter{PropertyID: req.PropertyID}
+	if req.FromCategoryID != "" {
+		filter.CategoryIDs = []string{req.FromCategoryID}
+	}
+
+	if req.StartDate != "" {
+		startDate, err := utils.ParseTimeParam(req.StartDate, h.location, false)
+		if err != nil {
+			utils.WriteErrorResponse(w, r, http.StatusBadRequest, "startDate must be an RFC3339 timestamp or a YYYY-MM-DD date")
+			return
+		}
+		filter.StartDate = startDate
+	}
+
+	if req.EndDate != "" {
+		endDate, err := utils.ParseTimeParam(req.EndDate, h.location, true)
+		if err != nil {
+			utils.WriteErrorResponse(w, r, http.StatusBadRequest, "endDate must be an RFC3339 timestamp or a YYYY-MM-DD date")
+			return
+		}
+		filter.EndDate = endDate
+	}
+
+	result, err := h.transactionService.ReassignCategory(r.Context(), filter, req.ToCategoryID, req.DryRun)
+	if err != nil {
+		writeQueryError(w, r, err, serviceErrorStatus(err))
+		return
+	}
+
+	utils.WriteJSONResponse(w, http.StatusOK, result)
+}
+
+func parseTransactionFilter(r *http.Request, location *time.Location) (models.TransactionFilter, error) {
+	query := r.URL.Query()
+	var filter models.TransactionFilter
+
+	// propertyId=none is shorthand for hasProperty=false
+	if propertyID := query.Get("propertyId"); propertyID == "none" {
+		hasProperty := false
+		filter.HasProperty = &hasProperty
+	} else {
+		filter.PropertyID = propertyID
+	}
+
+	if value := query.Get("hasProperty"); value != "" {
+		hasProperty, err := strconv.ParseBool(value)
+		if err != nil {
+			return filter, errors.New("hasProperty must be true or false")
+		}
+		filter.HasProperty = &hasProperty
+	}
+
+	if filter.PropertyID != "" && filter.HasProperty != nil && !*filter.HasProperty {
+		return filter, errors.New("propertyId cannot be combined with hasProperty=false")
+	}
+
+	// categoryId may be repeated and/or comma-separated
+	for _, value := range query["categoryId"] {
+		for _, categoryID := range strings.Split(value, ",") {
+			categoryID = strings.TrimSpace(categoryID)
+			if categoryID != "" && !slices.Contains(filter.CategoryIDs, categoryID) {
+				filter.CategoryIDs = append(filter.CategoryIDs, categoryID)
+			}
+		}
+	}
+
+	if len(filter.CategoryIDs) > models.MaxCategoryFilterValues {
+		return filter, fmt.Errorf("at most %d category IDs can be filtered at once", models.MaxCategoryFilterValues)
+	}
+
+	if value := query.Get("startDate"); value != "" {
+		startDate, err := utils.ParseTimeParam(value, location, false)
+		if err != nil {
+			return filter, errors.New("startDate must be an RFC3339 timestamp or a YYYY-MM-DD date")
+		}
+		filter.StartDate = startDate
+	}
+
+	if value := query.Get("endDate"); value != "" {
+		endDate, err := utils.ParseTimeParam(value, location, true)
+		if err != nil {
+			return filter, errors.New("endDate must be an RFC3339 timestamp or a YYYY-MM-DD date")
+		}
+		filter.EndDate = endDate
+	}
+
+	return filter, services.ValidateTransactionFilter(filter)
+}
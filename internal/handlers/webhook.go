@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/spalqui/habitattrack-api/internal/models"
+	"github.com/spalqui/habitattrack-api/internal/services"
+	"github.com/spalqui/habitattrack-api/pkg/utils"
+)
+
+type WebhookHandler struct {
+	webhookService services.WebhookService
+}
+
+func NewWebhookHandler(webhookService services.WebhookService) *WebhookHandler {
+	return &WebhookHandler{
+		webhookService: webhookService,
+	}
+}
+
+func (h *WebhookHandler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	var webhook models.Webhook
+	if err := utils.DecodeJSON(r, &webhook); err != nil {
+		utils.WriteDecodeError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := h.webhookService.CreateWebhook(r.Context(), &webhook); err != nil {
+		utils.WriteErrorResponse(w, r, serviceErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.WriteJSONResponse(w, http.StatusCreated, webhook)
+}
+
+func (h *WebhookHandler) GetWebhook(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	webhook, err := h.webhookService.GetWebhook(r.Context(), id)
+	if err != nil {
+		utils.WriteErrorResponse(w, r, serviceErrorStatus(err), err.Error())
+		return
+	}
+
+	webhook.Secret = ""
+	utils.WriteJSONResponse(w, http.StatusOK, webhook)
+}
+
+func (h *WebhookHandler) GetAllWebhooks(w http.ResponseWriter, r *http.Request) {
+	webhooks, err := h.webhookService.GetAllWebhooks(r.Context())
+	if err != nil {
+		utils.WriteErrorResponse(w, r, serviceErrorStatus(err), err.Error())
+		return
+	}
+
+	for _, webhook := range webhooks {
+		webhook.Secret = ""
+	}
+
+	utils.WriteJSONResponse(w, http.StatusOK, webhooks)
+}
+
+func (h *WebhookHandler) UpdateWebhook(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	var webhook models.Webhook
+	if err := utils.DecodeJSON(r, &webhook); err != nil {
+		utils.WriteDecodeError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	webhook.ID = id
+	if err := h.webhookService.UpdateWebhook(r.Context(), &webhook); err != nil {
+		utils.WriteErrorResponse(w, r, serviceErrorStatus(err), err.Error())
+		return
+	}
+
+	webhook.Secret = ""
+	utils.WriteJSONResponse(w, http.StatusOK, webhook)
+}
+
+func (h *WebhookHandler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	if err := h.webhookService.DeleteWebhook(r.Context(), id); err != nil {
+		utils.WriteErrorResponse(w, r, serviceErrorStatus(err), err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type rotateSecretRequest struct {
+	GracePeriodHours *int `json:"gracePeriodHours"`
+}
+
+// defaultSecretGracePeriod gives receivers a day to pick up a rotated secret.
+const defaultSecretGracePeriod = 24 * time.Hour
+
+func (h *WebhookHandler) RotateWebhookSecret(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	var req rotateSecretRequest
+	if r.ContentLength != 0 {
+		if err := utils.DecodeJSON(r, &req); err != nil {
+			utils.WriteDecodeError(w, r, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	gracePeriod := defaultSecretGracePeriod
+	if req.GracePeriodHours != nil {
+		if *req.GracePeriodHours < 0 || *req.GracePeriodHours > 168 {
+			utils.WriteErrorResponse(w, r, http.StatusBadRequest, "grace period must be between 0 and 168 hours")
+			return
+		}
+		gracePeriod = time.Duration(*req.GracePeriodHours) * time.Hour
+	}
+
+	webhook, err := h.webhookService.RotateWebhookSecret(r.Context(), id, gracePeriod)
+	if err != nil {
+		utils.WriteErrorResponse(w, r, serviceErrorStatus(err), err.Error())
+		return
+	}
+
+	// The new secret is returned once, like on creation
+	utils.WriteJSONResponse(w, http.StatusOK, webhook)
+}
+
+func (h *WebhookHandler) GetWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	deliveries, err := h.webhookService.GetWebhookDeliveries(r.Context(), id)
+	if err != nil {
+		utils.WriteErrorResponse(w, r, serviceErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.WriteJSONResponse(w, http.StatusOK, deliveries)
+}
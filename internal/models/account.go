@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+const (
+	DeletionStatusPending   = "pending"
+	DeletionStatusCompleted = "completed"
+	DeletionStatusFailed    = "failed"
+)
+
+// DeletionConfirmation must be presented to delete an account, so a stray
+// request can't erase it.
+type DeletionConfirmation struct {
+	Token     string    `json:"confirmationToken"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// AccountTombstone records that an account was erased. It is keyed by a hash
+// of the user ID. Until the purge completes it holds the user and the
+// organizations to purge, so an interrupted purge can be resumed; after that
+// it keeps no personal data.
+type AccountTombstone struct {
+	ID                  string     `json:"id" firestore:"-"`
+	Status              string     `json:"status" firestore:"status"`
+	UserID              string     `json:"-" firestore:"userId,omitempty"`
+	OrgIDs              []string   `json:"-" firestore:"orgIds,omitempty"`
+	PurgedOrganizations int        `json:"purgedOrganizations" firestore:"purgedOrganizations"`
+	Attempts            int        `json:"attempts" firestore:"attempts"`
+	Error               string     `json:"error,omitempty" firestore:"error,omitempty"`
+	RequestedAt         time.Time  `json:"requestedAt" firestore:"requestedAt"`
+	CompletedAt         *time.Time `json:"completedAt,omitempty" firestore:"completedAt,omitempty"`
+}
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// APIKey authenticates integrations as its owner. Scopes, when set, limit the
+// key to those resources; ExpiresAt, when set, ends its validity.
+type APIKey struct {
+	ID          string     `json:"id,omitempty" firestore:"-"`
+	Name        string     `json:"name" firestore:"name"`
+	Prefix      string     `json:"prefix" firestore:"prefix"`
+	Key         string     `json:"key,omitempty" firestore:"-"`
+	KeyHash     string     `json:"-" firestore:"keyHash"`
+	OwnerUserID string     `json:"-" firestore:"ownerUserId"`
+	Scopes      []string   `json:"scopes,omitempty" firestore:"scopes,omitempty"`
+	ExpiresAt   *time.Time `json:"expiresAt,omitempty" firestore:"expiresAt,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt" firestore:"createdAt"`
+	UpdatedAt   time.Time  `json:"updatedAt" firestore:"updatedAt"`
+	RevokedAt   *time.Time `json:"revokedAt,omitempty" firestore:"revokedAt,omitempty"`
+}
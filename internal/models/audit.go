@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+const (
+	ActorTypeUser   = "user"
+	ActorTypeAPIKey = "apiKey"
+	ActorTypeClient = "client"
+)
+
+// AuditEvent records a change and who made it. Actor is the API key or
+// client ID when one was used, otherwise the user ID. Impersonated changes
+// were made by ImpersonatorID, an admin acting as UserID.
+type AuditEvent struct {
+	ID             string      `json:"id,omitempty" firestore:"-"`
+	Type           string      `json:"type" firestore:"type"`
+	Actor          string      `json:"actor,omitempty" firestore:"actor,omitempty"`
+	ActorType      string      `json:"actorType,omitempty" firestore:"actorType,omitempty"`
+	UserID         string      `json:"userId,omitempty" firestore:"userId,omitempty"`
+	Impersonated   bool        `json:"impersonated,omitempty" firestore:"impersonated,omitempty"`
+	ImpersonatorID string      `json:"impersonatorId,omitempty" firestore:"impersonatorId,omitempty"`
+	RequestID      string      `json:"requestId,omitempty" firestore:"requestId,omitempty"`
+	Data           interface{} `json:"data" firestore:"data"`
+	CreatedAt      time.Time   `json:"createdAt" firestore:"createdAt"`
+}
+
+type AuditEventFilter struct {
+	Actor        string
+	Type         string
+	Impersonated bool
+}
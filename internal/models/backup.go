@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+type Backup struct {
+	Name      string    `json:"name"`
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// RestoreOptions control how a backup is restored. Prefix is a document path
+// to restore beneath, such as "restores/2026-01-31", so a backup can be
+// inspected without touching live data.
+type RestoreOptions struct {
+	Prefix string
+	DryRun bool
+	Force  bool
+}
+
+// BackupRecord is one document in a backup, stored as one line of JSON. Path
+// is relative to the database root, e.g. "orgs/abc/properties/xyz".
+type BackupRecord struct {
+	Path string                 `json:"path"`
+	Data map[string]interface{} `json:"data"`
+}
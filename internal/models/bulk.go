@@ -0,0 +1,61 @@
+package models
+
+import (
+	"errors"
+	"sort"
+)
+
+// BulkItemResult reports the outcome of one item of a bulk request, by its
+// position in the request.
+type BulkItemResult struct {
+	Index int    `json:"index"`
+	ID    string `json:"id,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+type BulkWriteResult struct {
+	Succeeded int              `json:"succeeded"`
+	Failed    int              `json:"failed"`
+	Items     []BulkItemResult `json:"items"`
+}
+
+// Add records the outcome of the item at index.
+func (r *BulkWriteResult) Add(index int, id string, err error) {
+	item := BulkItemResult{Index: index, ID: id}
+	if err != nil {
+		item.ID = ""
+		item.Error = err.Error()
+		r.Failed++
+	} else {
+		r.Succeeded++
+	}
+	r.Items = append(r.Items, item)
+}
+
+// Merge adds the items of other, a result for a subset of this request, where
+// positions maps other's indexes back to this request's.
+func (r *BulkWriteResult) Merge(other *BulkWriteResult, positions []int) {
+	for _, item := range other.Items {
+		item.Index = positions[item.Index]
+		if item.Error != "" {
+			r.Failed++
+		} else {
+			r.Succeeded++
+		}
+		r.Items = append(r.Items, item)
+	}
+
+	sort.Slice(r.Items, func(i, j int) bool {
+		return r.Items[i].Index < r.Items[j].Index
+	})
+}
+
+// Err returns the first failure, or nil when every item succeeded.
+func (r *BulkWriteResult) Err() error {
+	for _, item := range r.Items {
+		if item.Error != "" {
+			return errors.New(item.Error)
+		}
+	}
+	return nil
+}
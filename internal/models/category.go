@@ -7,6 +7,12 @@ type Category struct {
 	Name        string          `json:"name" firestore:"name"`
 	Type        TransactionType `json:"type" firestore:"type"`
 	Description string          `json:"description,omitempty" firestore:"description,omitempty"`
-	CreatedAt   time.Time       `json:"created_at" firestore:"createdAt"`
-	UpdatedAt   time.Time       `json:"updated_at" firestore:"updatedAt"`
+	CreatedAt   time.Time       `json:"createdAt" firestore:"createdAt"`
+	UpdatedAt   time.Time       `json:"updatedAt" firestore:"updatedAt"`
+}
+
+// CategoryDeleteOptions move a category's transactions to ReassignTo, a
+// category of the same type, before it's deleted.
+type CategoryDeleteOptions struct {
+	ReassignTo string
 }
@@ -0,0 +1,7 @@
+package models
+
+// CleanupResult counts the records a cleanup run removed permanently.
+type CleanupResult struct {
+	DeletedTransactions int `json:"deletedTransactions"`
+	WebhookDeliveries   int `json:"webhookDeliveries"`
+}
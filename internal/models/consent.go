@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+const (
+	ConsentDocumentTerms   = "terms"
+	ConsentDocumentPrivacy = "privacy"
+)
+
+// Consent records a user accepting a version of the terms or privacy policy.
+type Consent struct {
+	ID         string    `json:"id,omitempty" firestore:"-"`
+	UserID     string    `json:"-" firestore:"userId"`
+	Document   string    `json:"document" firestore:"document"`
+	Version    string    `json:"version" firestore:"version"`
+	AcceptedAt time.Time `json:"acceptedAt" firestore:"acceptedAt"`
+}
+
+// ConsentRequirements are the document versions users must have accepted,
+// keyed by document. Documents without a required version are omitted.
+type ConsentRequirements map[string]string
@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+const (
+	// DeadLetterWebhook is a delivery to one webhook that failed for good.
+	DeadLetterWebhook = "webhook"
+	// DeadLetterEvent is an event the outbox relay couldn't publish.
+	DeadLetterEvent = "event"
+)
+
+// DeadLetter keeps a delivery that ran out of retries so it can be replayed
+// once the receiver recovers. For webhook deliveries Payload is the body
+// that was sent; for events it's the event's data.
+type DeadLetter struct {
+	ID        string    `json:"id" firestore:"-"`
+	Kind      string    `json:"kind" firestore:"kind"`
+	OrgID     string    `json:"orgId,omitempty" firestore:"orgId"`
+	WebhookID string    `json:"webhookId,omitempty" firestore:"webhookId,omitempty"`
+	EventID   string    `json:"eventId" firestore:"eventId"`
+	EventType string    `json:"eventType" firestore:"eventType"`
+	Payload   []byte    `json:"-" firestore:"payload"`
+	Attempts  int       `json:"attempts" firestore:"attempts"`
+	LastError string    `json:"lastError,omitempty" firestore:"lastError,omitempty"`
+	EventAt   time.Time `json:"eventAt" firestore:"eventAt"`
+	CreatedAt time.Time `json:"createdAt" firestore:"createdAt"`
+}
+
+type DeadLetterFilter struct {
+	Kind      string
+	WebhookID string
+	OrgID     string
+}
+
+// ReplayResult counts the dead letters handed back for delivery.
+type ReplayResult struct {
+	Replayed int `json:"replayed"`
+}
@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+const (
+	EventPropertyCreated    = "property.created"
+	EventPropertyUpdated    = "property.updated"
+	EventPropertyDeleted    = "property.deleted"
+	EventTransactionCreated = "transaction.created"
+	EventTransactionUpdated = "transaction.updated"
+	EventTransactionDeleted = "transaction.deleted"
+	EventCategoryCreated    = "category.created"
+	EventCategoryUpdated    = "category.updated"
+	EventCategoryDeleted    = "category.deleted"
+
+	EventAll = "*"
+)
+
+var EventTypes = []string{
+	EventPropertyCreated,
+	EventPropertyUpdated,
+	EventPropertyDeleted,
+	EventTransactionCreated,
+	EventTransactionUpdated,
+	EventTransactionDeleted,
+	EventCategoryCreated,
+	EventCategoryUpdated,
+	EventCategoryDeleted,
+}
+
+type Event struct {
+	ID        string      `json:"id"`
+	Type      string      `json:"type"`
+	Data      interface{} `json:"data"`
+	CreatedAt time.Time   `json:"createdAt"`
+}
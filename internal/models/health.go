@@ -0,0 +1,21 @@
+package models
+
+const (
+	HealthStatusUp       = "up"
+	HealthStatusDegraded = "degraded"
+	HealthStatusDown     = "down"
+)
+
+// ComponentHealth is the outcome of probing one dependency.
+type ComponentHealth struct {
+	Status    string `json:"status"`
+	LatencyMs int64  `json:"latencyMs"`
+	Error     string `json:"error,omitempty"`
+}
+
+// HealthReport is down when a required component is, and degraded when only
+// optional ones are. Components is left out unless detail was asked for.
+type HealthReport struct {
+	Status     string                      `json:"status"`
+	Components map[string]*ComponentHealth `json:"components,omitempty"`
+}
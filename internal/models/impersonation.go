@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// Impersonation is a session in which an admin acts as a user. Token is only
+// set when the session is started.
+type Impersonation struct {
+	ID          string     `json:"id,omitempty" firestore:"-"`
+	AdminUserID string     `json:"adminUserId" firestore:"adminUserId"`
+	UserID      string     `json:"userId" firestore:"userId"`
+	Reason      string     `json:"reason" firestore:"reason"`
+	Token       string     `json:"token,omitempty" firestore:"-"`
+	CreatedAt   time.Time  `json:"createdAt" firestore:"createdAt"`
+	ExpiresAt   time.Time  `json:"expiresAt" firestore:"expiresAt"`
+	EndedAt     *time.Time `json:"endedAt,omitempty" firestore:"endedAt,omitempty"`
+}
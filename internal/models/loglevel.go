@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// LogLevel is the level the server logs at. A temporary level reverts to
+// the previous one at RevertAt.
+type LogLevel struct {
+	Level           string     `json:"level"`
+	DurationSeconds int        `json:"durationSeconds,omitempty"`
+	RevertAt        *time.Time `json:"revertAt,omitempty"`
+}
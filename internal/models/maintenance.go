@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// Maintenance is the service-wide maintenance switch. While it's enabled,
+// writes are refused and reads carry on.
+type Maintenance struct {
+	Enabled           bool      `json:"enabled" firestore:"enabled"`
+	Message           string    `json:"message,omitempty" firestore:"message,omitempty"`
+	RetryAfterSeconds int       `json:"retryAfterSeconds,omitempty" firestore:"retryAfterSeconds,omitempty"`
+	UpdatedBy         string    `json:"updatedBy,omitempty" firestore:"updatedBy,omitempty"`
+	UpdatedAt         time.Time `json:"updatedAt" firestore:"updatedAt"`
+}
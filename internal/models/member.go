@@ -0,0 +1,17 @@
+package models
+
+import (
+	"time"
+
+	"github.com/spalqui/habitattrack-api/pkg/auth"
+)
+
+// Member grants a user a role in an organization.
+type Member struct {
+	ID        string    `json:"id,omitempty" firestore:"-"`
+	OrgID     string    `json:"orgId" firestore:"orgId"`
+	UserID    string    `json:"userId" firestore:"userId"`
+	Role      auth.Role `json:"role" firestore:"role"`
+	CreatedAt time.Time `json:"createdAt" firestore:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt" firestore:"updatedAt"`
+}
@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// OAuthClient is a machine client that authenticates with the client
+// credentials grant and acts on its organization's data within its scopes.
+type OAuthClient struct {
+	ID         string     `json:"clientId,omitempty" firestore:"-"`
+	Name       string     `json:"name" firestore:"name"`
+	Secret     string     `json:"clientSecret,omitempty" firestore:"-"`
+	SecretHash string     `json:"-" firestore:"secretHash"`
+	Scopes     []string   `json:"scopes" firestore:"scopes"`
+	OrgID      string     `json:"-" firestore:"orgId"`
+	CreatedAt  time.Time  `json:"createdAt" firestore:"createdAt"`
+	UpdatedAt  time.Time  `json:"updatedAt" firestore:"updatedAt"`
+	RevokedAt  *time.Time `json:"revokedAt,omitempty" firestore:"revokedAt,omitempty"`
+}
+
+type AccessToken struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+	Scope       string `json:"scope"`
+}
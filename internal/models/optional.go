@@ -0,0 +1,34 @@
+package models
+
+import "encoding/json"
+
+// Optional is a field of a partial update. Set reports whether the request
+// included the field at all and Null whether it was null, so a client can
+// leave a field alone, change it or clear it.
+type Optional[T any] struct {
+	Value T
+	Set   bool
+	Null  bool
+}
+
+// UnmarshalJSON is only called for fields present in the request.
+func (o *Optional[T]) UnmarshalJSON(data []byte) error {
+	o.Set = true
+	if string(data) == "null" {
+		o.Null = true
+		return nil
+	}
+	return json.Unmarshal(data, &o.Value)
+}
+
+// ApplyTo writes the field's value to dst, or its zero value when cleared.
+// A field that wasn't set leaves dst alone.
+func (o Optional[T]) ApplyTo(dst *T) {
+	switch {
+	case o.Null:
+		var zero T
+		*dst = zero
+	case o.Set:
+		*dst = o.Value
+	}
+}
@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// FutureDatePolicy is what happens to transactions dated after today. An
+// organization without one allows them.
+type FutureDatePolicy string
+
+const (
+	FutureDatesAllow  FutureDatePolicy = "allow"
+	FutureDatesWarn   FutureDatePolicy = "warn"
+	FutureDatesReject FutureDatePolicy = "reject"
+)
+
+func (p FutureDatePolicy) Valid() bool {
+	switch p {
+	case "", FutureDatesAllow, FutureDatesWarn, FutureDatesReject:
+		return true
+	default:
+		return false
+	}
+}
+
+type Organization struct {
+	ID          string           `json:"id,omitempty" firestore:"-"`
+	Name        string           `json:"name" firestore:"name"`
+	FutureDates FutureDatePolicy `json:"futureDates,omitempty" firestore:"futureDates,omitempty"`
+	CreatedBy   string           `json:"createdBy" firestore:"createdBy"`
+	CreatedAt   time.Time        `json:"createdAt" firestore:"createdAt"`
+	UpdatedAt   time.Time        `json:"updatedAt" firestore:"updatedAt"`
+}
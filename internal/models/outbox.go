@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// OutboxEvent is a domain event waiting to be relayed, written in the same
+// Firestore transaction as the change it describes. Payload is the event's
+// data encoded as JSON. AvailableAt is when the event may next be claimed:
+// claims and failed attempts push it into the future.
+type OutboxEvent struct {
+	ID          string    `json:"id" firestore:"-"`
+	Type        string    `json:"type" firestore:"type"`
+	OrgID       string    `json:"orgId,omitempty" firestore:"orgId"`
+	Payload     []byte    `json:"-" firestore:"payload"`
+	Attempts    int       `json:"attempts" firestore:"attempts"`
+	LastError   string    `json:"lastError,omitempty" firestore:"lastError,omitempty"`
+	AvailableAt time.Time `json:"availableAt" firestore:"availableAt"`
+	CreatedAt   time.Time `json:"createdAt" firestore:"createdAt"`
+}
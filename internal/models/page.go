@@ -0,0 +1,40 @@
+package models
+
+const MaxPageSize = 200
+
+// TotalsMode chooses how a page's total is computed.
+type TotalsMode string
+
+const (
+	// TotalsExact counts every match with an aggregation query
+	TotalsExact TotalsMode = "exact"
+	// TotalsNone skips the count, for clients that only follow page tokens
+	TotalsNone TotalsMode = "none"
+	// TotalsEstimate uses a cheaper approximate count where one exists
+	TotalsEstimate TotalsMode = "estimate"
+)
+
+// PageRequest asks a list endpoint for at most Limit items, continuing after
+// the document named by Cursor, or before it when Backward is set.
+type PageRequest struct {
+	Limit    int
+	Cursor   string
+	Backward bool
+	Totals   TotalsMode
+}
+
+// Page is the envelope returned by list endpoints when paging is requested.
+// TotalItems counts every match, not just the items in this page; it's
+// omitted when totals were skipped, and approximate when TotalsEstimated.
+// NextPageToken is empty on the last page and PrevPageToken on the first.
+// The tokens are issued from NextCursor and PrevCursor, the document IDs the
+// neighbouring pages continue from.
+type Page[T any] struct {
+	Items           []T    `json:"items"`
+	TotalItems      *int64 `json:"totalItems,omitempty"`
+	TotalsEstimated bool   `json:"totalsEstimated,omitempty"`
+	NextPageToken   string `json:"nextPageToken,omitempty"`
+	PrevPageToken   string `json:"prevPageToken,omitempty"`
+	NextCursor      string `json:"-"`
+	PrevCursor      string `json:"-"`
+}
@@ -4,9 +4,35 @@ import "time"
 
 type Property struct {
 	ID          string    `json:"id,omitempty" firestore:"-"`
+	Name        string    `json:"name,omitempty" firestore:"name,omitempty"`
 	Address     string    `json:"address" firestore:"address"`
 	Postcode    string    `json:"postcode" firestore:"postcode"`
 	Description string    `json:"description,omitempty" firestore:"description,omitempty"`
-	CreatedAt   time.Time `json:"created_at" firestore:"createdAt"`
-	UpdatedAt   time.Time `json:"updated_at" firestore:"updatedAt"`
+	Timezone    string    `json:"timezone,omitempty" firestore:"timezone,omitempty"`
+	CreatedAt   time.Time `json:"createdAt" firestore:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt" firestore:"updatedAt"`
+}
+
+// PropertyPatch is a partial update to a property. Fields left out are
+// unchanged; name, description and timezone can be cleared with null.
+type PropertyPatch struct {
+	Name        Optional[string] `json:"name"`
+	Address     Optional[string] `json:"address"`
+	Postcode    Optional[string] `json:"postcode"`
+	Description Optional[string] `json:"description"`
+	Timezone    Optional[string] `json:"timezone"`
+}
+
+type PropertyDeleteOptions struct {
+	ReassignTo string
+	Cascade    bool
+}
+
+// DisplayName is the name shown for the property on transactions, falling
+// back to the address for unnamed properties.
+func (p *Property) DisplayName() string {
+	if p.Name != "" {
+		return p.Name
+	}
+	return p.Address
 }
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// Revocation invalidates bearer tokens: a single token when TokenID is set,
+// otherwise every session of UserID that began before RevokedBefore.
+type Revocation struct {
+	ID            string    `json:"id,omitempty" firestore:"-"`
+	UserID        string    `json:"userId,omitempty" firestore:"userId,omitempty"`
+	TokenID       string    `json:"tokenId,omitempty" firestore:"tokenId,omitempty"`
+	RevokedBefore time.Time `json:"revokedBefore" firestore:"revokedBefore"`
+	Reason        string    `json:"reason,omitempty" firestore:"reason,omitempty"`
+	RevokedBy     string    `json:"revokedBy,omitempty" firestore:"revokedBy,omitempty"`
+	CreatedAt     time.Time `json:"createdAt" firestore:"createdAt"`
+}
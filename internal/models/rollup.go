@@ -0,0 +1,54 @@
+package models
+
+import (
+	"time"
+
+	"github.com/spalqui/habitattrack-api/pkg/money"
+)
+
+// RollupMonthFormat is the layout of a rollup's month.
+const RollupMonthFormat = "2006-01"
+
+// MonthlyRollup aggregates a month of one property's transactions in one
+// category, kept up to date as the transactions are written.
+type MonthlyRollup struct {
+	Month            string          `json:"month" firestore:"month"`
+	PropertyID       string          `json:"propertyId" firestore:"propertyId"`
+	CategoryID       string          `json:"categoryId" firestore:"categoryId"`
+	Type             TransactionType `json:"type" firestore:"type"`
+	Amount           money.Amount    `json:"amount" firestore:"amount"`
+	TransactionCount int64           `json:"transactionCount" firestore:"transactionCount"`
+	UpdatedAt        time.Time       `json:"updatedAt" firestore:"updatedAt"`
+}
+
+// RollupFilter narrows rollups to a property and an inclusive range of
+// months; empty fields don't filter.
+type RollupFilter struct {
+	PropertyID string
+	From       string
+	To         string
+}
+
+// MonthlySummary totals a month of a report, with the rollups it was built
+// from.
+type MonthlySummary struct {
+	Month            string           `json:"month"`
+	Income           money.Amount     `json:"income"`
+	Expense          money.Amount     `json:"expense"`
+	Net              money.Amount     `json:"net"`
+	TransactionCount int64            `json:"transactionCount"`
+	Rollups          []*MonthlyRollup `json:"rollups"`
+}
+
+// Add counts the rollup towards the month's totals.
+func (s *MonthlySummary) Add(rollup *MonthlyRollup) {
+	switch rollup.Type {
+	case TransactionTypeIncome:
+		s.Income += rollup.Amount
+	case TransactionTypeExpense:
+		s.Expense += rollup.Amount
+	}
+	s.Net = s.Income - s.Expense
+	s.TransactionCount += rollup.TransactionCount
+	s.Rollups = append(s.Rollups, rollup)
+}
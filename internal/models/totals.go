@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"github.com/spalqui/habitattrack-api/pkg/money"
+)
+
+// PropertyTotals are a property's running income and expense totals, kept up
+// to date as its transactions are written.
+type PropertyTotals struct {
+	PropertyID       string       `json:"propertyId" firestore:"-"`
+	Income           money.Amount `json:"income" firestore:"income"`
+	Expense          money.Amount `json:"expense" firestore:"expense"`
+	Net              money.Amount `json:"net" firestore:"-"`
+	TransactionCount int64        `json:"transactionCount" firestore:"transactionCount"`
+	Seeded           bool         `json:"-" firestore:"seeded"`
+	UpdatedAt        time.Time    `json:"updatedAt" firestore:"updatedAt"`
+}
+
+// Add counts an income or expense transaction towards the totals; other
+// types aren't counted.
+func (t *PropertyTotals) Add(transaction *Transaction) {
+	switch transaction.Type {
+	case TransactionTypeIncome:
+		t.Income += transaction.Amount
+	case TransactionTypeExpense:
+		t.Expense += transaction.Amount
+	default:
+		return
+	}
+	t.Net = t.Income - t.Expense
+	t.TransactionCount++
+}
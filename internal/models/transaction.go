@@ -1,6 +1,11 @@
 package models
 
-import "time"
+import (
+	"sync"
+	"time"
+
+	"github.com/spalqui/habitattrack-api/pkg/money"
+)
 
 type TransactionType string
 
@@ -10,13 +15,90 @@ const (
 )
 
 type Transaction struct {
-	ID          string          `json:"id,omitempty" firestore:"-"`
-	PropertyID  string          `json:"property_id" firestore:"propertyId"`
-	Type        TransactionType `json:"type" firestore:"type"`
-	CategoryID  string          `json:"category_id" firestore:"categoryId"`
-	Amount      float64         `json:"amount" firestore:"amount"`
-	Description string          `json:"description,omitempty" firestore:"description,omitempty"`
-	Date        time.Time       `json:"date" firestore:"date"`
-	CreatedAt   time.Time       `json:"created_at" firestore:"createdAt"`
-	UpdatedAt   time.Time       `json:"updated_at" firestore:"updatedAt"`
+	ID           string          `json:"id,omitempty" firestore:"-"`
+	PropertyID   string          `json:"propertyId" firestore:"propertyId"`
+	PropertyName string          `json:"propertyName,omitempty" firestore:"propertyName,omitempty"`
+	Type         TransactionType `json:"type" firestore:"type"`
+	CategoryID   string          `json:"categoryId" firestore:"categoryId"`
+	CategoryName string          `json:"categoryName,omitempty" firestore:"categoryName,omitempty"`
+	Amount       money.Amount    `json:"amount" firestore:"amount"`
+	Description  string          `json:"description,omitempty" firestore:"description,omitempty"`
+	Source       string          `json:"source,omitempty" firestore:"source,omitempty"`
+	ExternalID   string          `json:"externalId,omitempty" firestore:"externalId,omitempty"`
+	Date         time.Time       `json:"date" firestore:"date"`
+	Timezone     string          `json:"timezone,omitempty" firestore:"timezone,omitempty"`
+	CreatedAt    time.Time       `json:"createdAt" firestore:"createdAt"`
+	UpdatedAt    time.Time       `json:"updatedAt" firestore:"updatedAt"`
+	DeletedAt    *time.Time      `json:"deletedAt,omitempty" firestore:"deletedAt,omitempty"`
+}
+
+var locations sync.Map
+
+// Location is the time zone the transaction's date falls in for reports: its
+// property's, copied onto it when it's written, or UTC.
+func (t *Transaction) Location() *time.Location {
+	if t.Timezone == "" {
+		return time.UTC
+	}
+	if location, ok := locations.Load(t.Timezone); ok {
+		return location.(*time.Location)
+	}
+
+	location, err := time.LoadLocation(t.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	locations.Store(t.Timezone, location)
+	return location
+}
+
+// WarningPossibleDuplicate flags a transaction that looks like one already
+// recorded.
+const WarningPossibleDuplicate = "possible_duplicate"
+
+// WarningFutureDate flags a transaction dated after today, in organizations
+// that ask to be warned about them.
+const WarningFutureDate = "future_date"
+
+// Warning is a non-fatal note about a request that succeeded, for clients to
+// show the user.
+type Warning struct {
+	Code           string   `json:"code"`
+	Message        string   `json:"message"`
+	TransactionIDs []string `json:"transactionIds,omitempty"`
+}
+
+type CategoryReassignmentResult struct {
+	Matched int  `json:"matched"`
+	Updated int  `json:"updated"`
+	DryRun  bool `json:"dryRun"`
+}
+
+// Firestore limits the number of values in an "in" filter
+const MaxCategoryFilterValues = 30
+
+// TransactionOverrides holds the fields that may be changed when duplicating
+// a transaction; nil fields are copied from the original.
+type TransactionOverrides struct {
+	Date   *time.Time
+	Amount *money.Amount
+}
+
+// TransactionPatch is a partial update to a transaction. Fields left out are
+// unchanged; description and propertyId can be cleared with null.
+type TransactionPatch struct {
+	PropertyID  Optional[string]          `json:"propertyId"`
+	Type        Optional[TransactionType] `json:"type"`
+	CategoryID  Optional[string]          `json:"categoryId"`
+	Amount      Optional[money.Amount]    `json:"amount"`
+	Description Optional[string]          `json:"description"`
+	Date        Optional[time.Time]       `json:"date"`
+}
+
+type TransactionFilter struct {
+	PropertyID  string
+	HasProperty *bool
+	CategoryIDs []string
+	StartDate   time.Time
+	EndDate     time.Time
 }
@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+// Webhook is a subscription with its own signing secret. After a rotation
+// the previous secret keeps signing deliveries alongside the new one until
+// PreviousSecretExpiresAt, so receivers can switch over without dropping any.
+type Webhook struct {
+	ID                      string     `json:"id,omitempty" firestore:"-"`
+	URL                     string     `json:"url" firestore:"url"`
+	Events                  []string   `json:"events" firestore:"events"`
+	Secret                  string     `json:"secret,omitempty" firestore:"secret"`
+	PreviousSecret          string     `json:"-" firestore:"previousSecret,omitempty"`
+	PreviousSecretExpiresAt *time.Time `json:"previousSecretExpiresAt,omitempty" firestore:"previousSecretExpiresAt,omitempty"`
+	Active                  bool       `json:"active" firestore:"active"`
+	CreatedAt               time.Time  `json:"createdAt" firestore:"createdAt"`
+	UpdatedAt               time.Time  `json:"updatedAt" firestore:"updatedAt"`
+}
+
+// PendingDelivery is a delivery of one event to one webhook waiting to be
+// sent. Its ID is made from the event's and the webhook's, so relaying an
+// event again doesn't queue a second copy. Payload is the body to send.
+// AvailableAt is when it may next be claimed: claims and failed attempts
+// push it into the future.
+type PendingDelivery struct {
+	ID          string    `json:"id" firestore:"-"`
+	OrgID       string    `json:"orgId,omitempty" firestore:"orgId"`
+	WebhookID   string    `json:"webhookId" firestore:"webhookId"`
+	EventID     string    `json:"eventId" firestore:"eventId"`
+	EventType   string    `json:"eventType" firestore:"eventType"`
+	Payload     []byte    `json:"-" firestore:"payload"`
+	Attempts    int       `json:"attempts" firestore:"attempts"`
+	LastError   string    `json:"lastError,omitempty" firestore:"lastError,omitempty"`
+	AvailableAt time.Time `json:"availableAt" firestore:"availableAt"`
+	EventAt     time.Time `json:"eventAt" firestore:"eventAt"`
+	CreatedAt   time.Time `json:"createdAt" firestore:"createdAt"`
+}
+
+type WebhookDelivery struct {
+	ID         string    `json:"id,omitempty" firestore:"-"`
+	WebhookID  string    `json:"webhookId" firestore:"webhookId"`
+	EventID    string    `json:"eventId" firestore:"eventId"`
+	Event      string    `json:"event" firestore:"event"`
+	Attempt    int       `json:"attempt" firestore:"attempt"`
+	StatusCode int       `json:"statusCode,omitempty" firestore:"statusCode,omitempty"`
+	Success    bool      `json:"success" firestore:"success"`
+	Error      string    `json:"error,omitempty" firestore:"error,omitempty"`
+	Duration   int64     `json:"durationMs" firestore:"durationMs"`
+	CreatedAt  time.Time `json:"createdAt" firestore:"createdAt"`
+}
@@ -0,0 +1,18 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/spalqui/habitattrack-api/internal/models"
+)
+
+// AccountRepository erases data across collections. Its methods ignore the
+// caller's organization scope. GetTombstone returns nil when there's no such
+// tombstone; ListUnfinishedTombstones lists those pending or failed.
+type AccountRepository interface {
+	PurgeOrganization(ctx context.Context, orgID string) error
+	PurgeUser(ctx context.Context, userID string) error
+	SaveTombstone(ctx context.Context, tombstone *models.AccountTombstone) error
+	GetTombstone(ctx context.Context, id string) (*models.AccountTombstone, error)
+	ListUnfinishedTombstones(ctx context.Context) ([]*models.AccountTombstone, error)
+}
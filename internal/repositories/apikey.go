@@ -0,0 +1,15 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/spalqui/habitattrack-api/internal/models"
+)
+
+type APIKeyRepository interface {
+	Create(ctx context.Context, apiKey *models.APIKey) error
+	GetByID(ctx context.Context, id string) (*models.APIKey, error)
+	GetByHash(ctx context.Context, keyHash string) (*models.APIKey, error)
+	GetAll(ctx context.Context) ([]*models.APIKey, error)
+	Update(ctx context.Context, apiKey *models.APIKey) error
+}
@@ -0,0 +1,12 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/spalqui/habitattrack-api/internal/models"
+)
+
+type AuditEventRepository interface {
+	Create(ctx context.Context, event *models.AuditEvent) error
+	List(ctx context.Context, filter models.AuditEventFilter) ([]*models.AuditEvent, error)
+}
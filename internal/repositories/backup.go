@@ -0,0 +1,36 @@
+package repositories
+
+import (
+	"context"
+	"io"
+
+	"github.com/spalqui/habitattrack-api/internal/models"
+)
+
+// DocumentExporter reads every document in the database, across all
+// organizations, for backups.
+type DocumentExporter interface {
+	Export(ctx context.Context, write func(record *models.BackupRecord) error) (int, error)
+}
+
+// DocumentImporter restores backed-up documents, beneath prefix, a document
+// path, when it isn't empty.
+type DocumentImporter interface {
+	IsEmpty(ctx context.Context, prefix string) (bool, error)
+	Validate(record *models.BackupRecord) error
+	Import(ctx context.Context, prefix string, records []*models.BackupRecord) error
+}
+
+type DocumentStore interface {
+	DocumentExporter
+	DocumentImporter
+}
+
+// BackupStore keeps backup files.
+type BackupStore interface {
+	Create(ctx context.Context, name string) io.WriteCloser
+	Open(ctx context.Context, name string) (io.ReadCloser, error)
+	List(ctx context.Context) ([]*models.Backup, error)
+	Delete(ctx context.Context, name string) error
+	CheckAccess(ctx context.Context) error
+}
@@ -2,14 +2,23 @@ package repositories
 
 import (
 	"context"
+	"errors"
 
 	"github.com/spalqui/habitattrack-api/internal/models"
 )
 
+var ErrCategoryNotFound = errors.New("category not found")
+
+var ErrCategoryNameExists = errors.New("a category with this name already exists for the type")
+
 type CategoryRepository interface {
 	Create(ctx context.Context, category *models.Category) error
 	GetByID(ctx context.Context, id string) (*models.Category, error)
+	GetByIDs(ctx context.Context, ids []string) (map[string]*models.Category, error)
 	GetAll(ctx context.Context) ([]*models.Category, error)
+	Count(ctx context.Context) (int64, error)
+	ListPage(ctx context.Context, transactionType models.TransactionType, page models.PageRequest) (*models.Page[*models.Category], error)
+	CountByType(ctx context.Context, transactionType models.TransactionType) (int64, error)
 	GetByType(ctx context.Context, transactionType models.TransactionType) ([]*models.Category, error)
 	Update(ctx context.Context, category *models.Category) error
 	Delete(ctx context.Context, id string) error
@@ -0,0 +1,13 @@
+package repositories
+
+import (
+	"context"
+	"time"
+)
+
+// CleanupRepository permanently removes records past their retention, across
+// every organization.
+type CleanupRepository interface {
+	PurgeDeletedTransactions(ctx context.Context, olderThan time.Time) (int, error)
+	PurgeWebhookDeliveries(ctx context.Context, olderThan time.Time) (int, error)
+}
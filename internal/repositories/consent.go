@@ -0,0 +1,13 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/spalqui/habitattrack-api/internal/models"
+)
+
+type ConsentRepository interface {
+	Save(ctx context.Context, consent *models.Consent) error
+	Exists(ctx context.Context, userID, document, version string) (bool, error)
+	GetByUserID(ctx context.Context, userID string) ([]*models.Consent, error)
+}
@@ -0,0 +1,16 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/spalqui/habitattrack-api/internal/models"
+)
+
+// DeadLetterRepository spans every organization; dead letters are managed by
+// administrators.
+type DeadLetterRepository interface {
+	Create(ctx context.Context, deadLetter *models.DeadLetter) error
+	GetByID(ctx context.Context, id string) (*models.DeadLetter, error)
+	List(ctx context.Context, filter models.DeadLetterFilter) ([]*models.DeadLetter, error)
+	Delete(ctx context.Context, id string) error
+}
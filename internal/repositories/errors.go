@@ -0,0 +1,7 @@
+package repositories
+
+import "errors"
+
+// ErrUpdateConflict refuses an update made from an out-of-date read: the
+// document has been updated since the version the update started from.
+var ErrUpdateConflict = errors.New("the record was changed by another request; fetch it again and retry")
@@ -0,0 +1,14 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/spalqui/habitattrack-api/internal/models"
+)
+
+type ImpersonationRepository interface {
+	Create(ctx context.Context, impersonation *models.Impersonation) error
+	GetByID(ctx context.Context, id string) (*models.Impersonation, error)
+	GetAll(ctx context.Context) ([]*models.Impersonation, error)
+	Update(ctx context.Context, impersonation *models.Impersonation) error
+}
@@ -0,0 +1,13 @@
+package repositories
+
+import (
+	"context"
+	"time"
+)
+
+// JobLockRepository records which instance ran each occurrence of a
+// scheduled job. Claim succeeds for the first instance to claim an
+// occurrence later than the job's last one.
+type JobLockRepository interface {
+	Claim(ctx context.Context, job string, occurrence time.Time, owner string) (bool, error)
+}
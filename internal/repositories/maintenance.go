@@ -0,0 +1,12 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/spalqui/habitattrack-api/internal/models"
+)
+
+type MaintenanceRepository interface {
+	Get(ctx context.Context) (*models.Maintenance, error)
+	Save(ctx context.Context, maintenance *models.Maintenance) error
+}
@@ -0,0 +1,18 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/spalqui/habitattrack-api/internal/models"
+)
+
+type MemberRepository interface {
+	Create(ctx context.Context, member *models.Member) error
+	GetByID(ctx context.Context, id string) (*models.Member, error)
+	GetAll(ctx context.Context) ([]*models.Member, error)
+	GetByOrgAndUser(ctx context.Context, orgID, userID string) (*models.Member, error)
+	GetByUserID(ctx context.Context, userID string) ([]*models.Member, error)
+	GetByOrgID(ctx context.Context, orgID string) ([]*models.Member, error)
+	Update(ctx context.Context, member *models.Member) error
+	Delete(ctx context.Context, id string) error
+}
@@ -0,0 +1,14 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/spalqui/habitattrack-api/internal/models"
+)
+
+type OAuthClientRepository interface {
+	Create(ctx context.Context, client *models.OAuthClient) error
+	GetByID(ctx context.Context, id string) (*models.OAuthClient, error)
+	GetAll(ctx context.Context) ([]*models.OAuthClient, error)
+	Update(ctx context.Context, client *models.OAuthClient) error
+}
@@ -0,0 +1,16 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"github.com/spalqui/habitattrack-api/internal/models"
+)
+
+var ErrOrganizationNotFound = errors.New("organization not found")
+
+type OrganizationRepository interface {
+	Create(ctx context.Context, organization *models.Organization) error
+	GetByID(ctx context.Context, id string) (*models.Organization, error)
+	Update(ctx context.Context, organization *models.Organization) error
+}
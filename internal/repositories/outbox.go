@@ -0,0 +1,18 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/spalqui/habitattrack-api/internal/models"
+)
+
+// OutboxRepository reads the events queued by repository writes. Claim
+// leases up to limit available events, oldest first, so other instances skip
+// them until the lease runs out. Requeue puts an event back under its own ID.
+type OutboxRepository interface {
+	Requeue(ctx context.Context, event *models.OutboxEvent) error
+	Claim(ctx context.Context, limit int, lease time.Duration) ([]*models.OutboxEvent, error)
+	Delete(ctx context.Context, id string) error
+	Release(ctx context.Context, id string, retryAt time.Time, cause error) error
+}
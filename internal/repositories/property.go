@@ -2,14 +2,29 @@ package repositories
 
 import (
 	"context"
+	"errors"
 
 	"github.com/spalqui/habitattrack-api/internal/models"
 )
 
+// ErrInvalidPageToken is returned for page tokens that weren't issued by a
+// previous page, or whose last item has since been deleted.
+var ErrInvalidPageToken = errors.New("page token is invalid or expired")
+
+var ErrPropertyNotFound = errors.New("property not found")
+
+var ErrPropertyNameExists = errors.New("a property with this name already exists")
+
 type PropertyRepository interface {
 	Create(ctx context.Context, property *models.Property) error
 	GetByID(ctx context.Context, id string) (*models.Property, error)
+	GetByIDs(ctx context.Context, ids []string) (map[string]*models.Property, error)
 	GetAll(ctx context.Context) ([]*models.Property, error)
+	// FindByName returns the property holding this name, compared
+	// case-insensitively as on creation, or nil when there's none.
+	FindByName(ctx context.Context, name string) (*models.Property, error)
+	ListPage(ctx context.Context, page models.PageRequest) (*models.Page[*models.Property], error)
+	Count(ctx context.Context) (int64, error)
 	Update(ctx context.Context, property *models.Property) error
 	Delete(ctx context.Context, id string) error
 }
@@ -0,0 +1,14 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/spalqui/habitattrack-api/internal/models"
+)
+
+type RevocationRepository interface {
+	Save(ctx context.Context, revocation *models.Revocation) error
+	GetByUserID(ctx context.Context, userID string) (*models.Revocation, error)
+	GetByTokenID(ctx context.Context, tokenID string) (*models.Revocation, error)
+	GetAll(ctx context.Context) ([]*models.Revocation, error)
+}
@@ -0,0 +1,14 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/spalqui/habitattrack-api/internal/models"
+)
+
+type RollupRepository interface {
+	List(ctx context.Context, filter models.RollupFilter) ([]*models.MonthlyRollup, error)
+	// Rebuild recomputes every organization's rollups from their
+	// transactions and returns how many it wrote.
+	Rebuild(ctx context.Context) (int, error)
+}
@@ -2,15 +2,39 @@ package repositories
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"github.com/spalqui/habitattrack-api/internal/models"
 )
 
+var ErrTransactionNotFound = errors.New("transaction not found")
+
+var ErrExternalIDExists = errors.New("a transaction with this external ID already exists for the source")
+
 type TransactionRepository interface {
 	Create(ctx context.Context, transaction *models.Transaction) error
 	GetByID(ctx context.Context, id string) (*models.Transaction, error)
+	GetByExternalID(ctx context.Context, source, externalID string) (*models.Transaction, error)
 	GetByPropertyID(ctx context.Context, propertyID string) ([]*models.Transaction, error)
 	GetAll(ctx context.Context) ([]*models.Transaction, error)
+	List(ctx context.Context, filter models.TransactionFilter) ([]*models.Transaction, error)
+	ListPage(ctx context.Context, filter models.TransactionFilter, page models.PageRequest) (*models.Page[*models.Transaction], error)
+	Count(ctx context.Context, filter models.TransactionFilter) (int64, error)
+	CountByCategoryID(ctx context.Context, categoryID string) (int64, error)
+	// EstimateCount approximates Count without reading the transactions.
+	EstimateCount(ctx context.Context, filter models.TransactionFilter) (int64, error)
+	Stream(ctx context.Context, filter models.TransactionFilter, pageSize int, fn func(transactions []*models.Transaction) error) error
+	CreateMany(ctx context.Context, transactions []*models.Transaction) (*models.BulkWriteResult, error)
 	Update(ctx context.Context, transaction *models.Transaction) error
+	GetPropertyTotals(ctx context.Context, propertyID string) (*models.PropertyTotals, error)
 	Delete(ctx context.Context, id string) error
+	UpdatePropertyName(ctx context.Context, propertyID, name string) error
+	UpdateCategoryName(ctx context.Context, categoryID, name string) error
+	ReassignCategory(ctx context.Context, ids []string, categoryID, categoryName string) (int, error)
+	ReassignProperty(ctx context.Context, ids []string, propertyID, propertyName, timezone string) (int, error)
+	SetTimezone(ctx context.Context, ids []string, timezone string) (int, error)
+	DeleteMany(ctx context.Context, ids []string) (int, error)
+	ListDeleted(ctx context.Context) ([]*models.Transaction, error)
+	PurgeDeleted(ctx context.Context, olderThan time.Time) (int, error)
 }
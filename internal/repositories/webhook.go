@@ -0,0 +1,38 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/spalqui/habitattrack-api/internal/models"
+)
+
+var ErrWebhookNotFound = errors.New("webhook not found")
+
+type WebhookRepository interface {
+	Create(ctx context.Context, webhook *models.Webhook) error
+	GetByID(ctx context.Context, id string) (*models.Webhook, error)
+	GetAll(ctx context.Context) ([]*models.Webhook, error)
+	GetByEvent(ctx context.Context, event string) ([]*models.Webhook, error)
+	Update(ctx context.Context, webhook *models.Webhook) error
+	Delete(ctx context.Context, id string) error
+}
+
+type WebhookDeliveryRepository interface {
+	Create(ctx context.Context, delivery *models.WebhookDelivery) error
+	GetByWebhookID(ctx context.Context, webhookID string) ([]*models.WebhookDelivery, error)
+}
+
+// DeliveryQueueRepository holds the webhook deliveries still to be sent.
+// Enqueue adds them all in one write, replacing any still queued under the
+// same IDs. Claim leases up to limit available deliveries, oldest first, so
+// other instances skip them until the lease runs out. CountDue counts the
+// deliveries available now.
+type DeliveryQueueRepository interface {
+	Enqueue(ctx context.Context, deliveries []*models.PendingDelivery) error
+	Claim(ctx context.Context, limit int, lease time.Duration) ([]*models.PendingDelivery, error)
+	Delete(ctx context.Context, id string) error
+	Release(ctx context.Context, id string, retryAt time.Time, cause error) error
+	CountDue(ctx context.Context) (int64, error)
+}
@@ -0,0 +1,303 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spalqui/habitattrack-api/internal/models"
+	"github.com/spalqui/habitattrack-api/internal/repositories"
+	"github.com/spalqui/habitattrack-api/pkg/auth"
+	"github.com/spalqui/habitattrack-api/pkg/tasks"
+)
+
+const deletionConfirmationTTL = 10 * time.Minute
+
+// purgeRetryDelay is how long a purge may stay pending before the scheduled
+// job assumes its task was lost and runs it again.
+const purgeRetryDelay = 10 * time.Minute
+
+// TaskPurgeAccount erases a deleted account's data in the background.
+const TaskPurgeAccount = "accounts.purge"
+
+type accountPurgeTask struct {
+	TombstoneID string `json:"tombstoneId"`
+}
+
+// RegisterAccountTasks registers the handlers for the tasks the account
+// service queues.
+func RegisterAccountTasks(registry *tasks.Registry, service AccountService) {
+	registry.Register(TaskPurgeAccount, func(ctx context.Context, payload json.RawMessage) error {
+		var task accountPurgeTask
+		if err := json.Unmarshal(payload, &task); err != nil {
+			return err
+		}
+
+		return service.PurgeAccount(ctx, task.TombstoneID)
+	})
+}
+
+type AccountService interface {
+	RequestDeletion(ctx context.Context) (*models.DeletionConfirmation, error)
+	DeleteAccount(ctx context.Context, confirmationToken string) (*models.AccountTombstone, error)
+	PurgeAccount(ctx context.Context, tombstoneID string) error
+	RunScheduled(ctx context.Context) error
+}
+
+type accountService struct {
+	accountRepo       repositories.AccountRepository
+	memberRepo        repositories.MemberRepository
+	revocationService RevocationService
+	queue             tasks.Queue
+	secret            []byte
+}
+
+func NewAccountService(accountRepo repositories.AccountRepository, memberRepo repositories.MemberRepository, revocationService RevocationService, queue tasks.Queue, secret []byte) AccountService {
+	return &accountService{
+		accountRepo:       accountRepo,
+		memberRepo:        memberRepo,
+		revocationService: revocationService,
+		queue:             queue,
+		secret:            secret,
+	}
+}
+
+// RequestDeletion issues a short-lived token that DeleteAccount requires.
+func (s *accountService) RequestDeletion(ctx context.Context) (*models.DeletionConfirmation, error) {
+	userID, err := s.accountUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	expiresAt := time.Now().Add(deletionConfirmationTTL).Truncate(time.Second)
+	payload := base64.RawURLEncoding.EncodeToString([]byte(userID + "|" + strconv.FormatInt(expiresAt.Unix(), 10)))
+
+	return &models.DeletionConfirmation{
+		Token:     payload + "." + s.sign(payload),
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// DeleteAccount erases the caller's personal organization, any organization
+// they are the only member of, their memberships and API keys, and signs out
+// their sessions. The purge runs as a queued task; the returned tombstone is
+// updated once it finishes, and RunScheduled retries it until it does. The
+// Firebase user itself is not deleted.
+func (s *accountService) DeleteAccount(ctx context.Context, confirmationToken string) (*models.AccountTombstone, error) {
+	userID, err := s.accountUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if !s.validConfirmation(confirmationToken, userID) {
+		return nil, errors.New("invalid or expired confirmation token")
+	}
+
+	orgIDs, err := s.organizationsToPurge(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256([]byte(userID))
+	tombstone := &models.AccountTombstone{
+		ID:          hex.EncodeToString(sum[:]),
+		Status:      models.DeletionStatusPending,
+		UserID:      userID,
+		OrgIDs:      orgIDs,
+		RequestedAt: time.Now(),
+	}
+	if err := s.accountRepo.SaveTombstone(ctx, tombstone); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.revocationService.RevokeUserSessions(ctx, userID, "account deleted"); err != nil {
+		return nil, err
+	}
+
+	task, err := tasks.NewTask(ctx, TaskPurgeAccount, accountPurgeTask{TombstoneID: tombstone.ID})
+	if err != nil {
+		return nil, err
+	}
+	// The tombstone is saved, so the scheduled job runs the purge even if
+	// the task can't be queued
+	if err := s.queue.Enqueue(ctx, task); err != nil {
+		log.Printf("Failed to queue purge of account %s: %v", tombstone.ID, err)
+	}
+
+	return tombstone, nil
+}
+
+// PurgeAccount runs the purge recorded by a tombstone, unless it has already
+// completed.
+func (s *accountService) PurgeAccount(ctx context.Context, tombstoneID string) error {
+	tombstone, err := s.accountRepo.GetTombstone(ctx, tombstoneID)
+	if err != nil {
+		return err
+	}
+	if tombstone == nil || tombstone.Status == models.DeletionStatusCompleted {
+		return nil
+	}
+
+	return s.purge(ctx, tombstone)
+}
+
+// RunScheduled retries every purge that failed, or that is still pending
+// long after it was requested, such as one cut short by a restart.
+func (s *accountService) RunScheduled(ctx context.Context) error {
+	tombstones, err := s.accountRepo.ListUnfinishedTombstones(ctx)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	retried := 0
+	for _, tombstone := range tombstones {
+		if tombstone.Status == models.DeletionStatusPending && time.Since(tombstone.RequestedAt) < purgeRetryDelay {
+			continue
+		}
+		retried++
+		if err := s.purge(ctx, tombstone); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if retried > 0 {
+		log.Printf("Retried %d account purges, %d failed", retried, len(errs))
+	}
+	return errors.Join(errs...)
+}
+
+// purge erases the tombstone's user and organizations and records the
+// outcome. Every step can be run again, so a purge cut short is simply
+// repeated. The user and organization IDs are dropped once it completes.
+func (s *accountService) purge(ctx context.Context, tombstone *models.AccountTombstone) error {
+	if tombstone.UserID == "" {
+		return errors.New("tombstone " + tombstone.ID + " doesn't record the account to purge")
+	}
+
+	tombstone.Attempts++
+	tombstone.PurgedOrganizations = 0
+	err := s.accountRepo.PurgeUser(ctx, tombstone.UserID)
+	for _, orgID := range tombstone.OrgIDs {
+		if err != nil {
+			break
+		}
+		if err = s.accountRepo.PurgeOrganization(ctx, orgID); err == nil {
+			tombstone.PurgedOrganizations++
+		}
+	}
+
+	if err != nil {
+		log.Printf("Failed to purge account %s: %v", tombstone.ID, err)
+		tombstone.Status = models.DeletionStatusFailed
+		tombstone.Error = err.Error()
+		if err := s.accountRepo.SaveTombstone(ctx, tombstone); err != nil {
+			log.Printf("Failed to update tombstone for account %s: %v", tombstone.ID, err)
+		}
+		return err
+	}
+
+	completedAt := time.Now()
+	tombstone.CompletedAt = &completedAt
+	tombstone.Status = models.DeletionStatusCompleted
+	tombstone.Error = ""
+	tombstone.UserID = ""
+	tombstone.OrgIDs = nil
+	return s.accountRepo.SaveTombstone(ctx, tombstone)
+}
+
+// organizationsToPurge lists the organizations deleted with the account. It
+// refuses when the user is the last owner of an organization others still
+// use, since deleting it would erase their data too.
+func (s *accountService) organizationsToPurge(ctx context.Context, userID string) ([]string, error) {
+	memberships, err := s.memberRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	orgIDs := []string{userID}
+	var blocking []string
+	for _, membership := range memberships {
+		if membership.OrgID == userID || membership.Role != auth.RoleOwner {
+			continue
+		}
+
+		members, err := s.memberRepo.GetByOrgID(ctx, membership.OrgID)
+		if err != nil {
+			return nil, err
+		}
+
+		others, otherOwners := 0, 0
+		for _, member := range members {
+			if member.UserID == userID {
+				continue
+			}
+			others++
+			if member.Role == auth.RoleOwner {
+				otherOwners++
+			}
+		}
+
+		switch {
+		case others == 0:
+			orgIDs = append(orgIDs, membership.OrgID)
+		case otherOwners == 0:
+			blocking = append(blocking, membership.OrgID)
+		}
+	}
+
+	if len(blocking) > 0 {
+		return nil, errors.New("transfer ownership of these organizations before deleting your account: " + strings.Join(blocking, ", "))
+	}
+
+	return orgIDs, nil
+}
+
+// accountUser returns the signed-in user. API keys, machine clients and
+// admins impersonating the user can't manage the account itself.
+func (s *accountService) accountUser(ctx context.Context) (string, error) {
+	principal, ok := auth.FromContext(ctx)
+	if !ok || principal.UserID == "" {
+		return "", errors.New("authentication is required to delete an account")
+	}
+
+	if principal.APIKeyID != "" || principal.ImpersonatorID != "" {
+		return "", errors.New("account deletion requires signing in as the user")
+	}
+
+	return principal.UserID, nil
+}
+
+func (s *accountService) validConfirmation(token, userID string) bool {
+	payload, signature, ok := strings.Cut(token, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(s.sign(payload))) {
+		return false
+	}
+
+	decoded, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return false
+	}
+
+	subject, expires, ok := strings.Cut(string(decoded), "|")
+	if !ok || subject != userID {
+		return false
+	}
+
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	return err == nil && time.Now().Unix() < expiresAt
+}
+
+func (s *accountService) sign(payload string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte("account-deletion:" + payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,161 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spalqui/habitattrack-api/internal/models"
+	"github.com/spalqui/habitattrack-api/internal/repositories"
+	"github.com/spalqui/habitattrack-api/pkg/auth"
+)
+
+const apiKeyPrefix = "hat_"
+
+type APIKeyService interface {
+	CreateAPIKey(ctx context.Context, apiKey *models.APIKey) error
+	GetAllAPIKeys(ctx context.Context) ([]*models.APIKey, error)
+	RotateAPIKey(ctx context.Context, id string) (*models.APIKey, error)
+	RevokeAPIKey(ctx context.Context, id string) error
+	VerifyAPIKey(ctx context.Context, key string) (*auth.Principal, error)
+}
+
+type apiKeyService struct {
+	apiKeyRepo repositories.APIKeyRepository
+}
+
+func NewAPIKeyService(apiKeyRepo repositories.APIKeyRepository) APIKeyService {
+	return &apiKeyService{
+		apiKeyRepo: apiKeyRepo,
+	}
+}
+
+// CreateAPIKey issues a new key for the caller. The plaintext key is only
+// set on the returned model; just its hash is stored.
+func (s *apiKeyService) CreateAPIKey(ctx context.Context, apiKey *models.APIKey) error {
+	userID := auth.UserID(ctx)
+	if userID == "" {
+		return errors.New("authentication is required to manage API keys")
+	}
+
+	if strings.TrimSpace(apiKey.Name) == "" {
+		return errors.New("API key name is required")
+	}
+
+	for _, scope := range apiKey.Scopes {
+		if !auth.ValidScope(scope) {
+			return fmt.Errorf("unknown scope: %s", scope)
+		}
+	}
+
+	if apiKey.ExpiresAt != nil && !apiKey.ExpiresAt.After(time.Now()) {
+		return errors.New("expiresAt must be in the future")
+	}
+
+	if err := issueKey(apiKey); err != nil {
+		return err
+	}
+
+	apiKey.OwnerUserID = userID
+	apiKey.RevokedAt = nil
+	return s.apiKeyRepo.Create(ctx, apiKey)
+}
+
+func (s *apiKeyService) GetAllAPIKeys(ctx context.Context) ([]*models.APIKey, error) {
+	return s.apiKeyRepo.GetAll(ctx)
+}
+
+// RotateAPIKey replaces the key's secret, immediately invalidating the old one.
+func (s *apiKeyService) RotateAPIKey(ctx context.Context, id string) (*models.APIKey, error) {
+	apiKey, err := s.getActiveAPIKey(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := issueKey(apiKey); err != nil {
+		return nil, err
+	}
+
+	if err := s.apiKeyRepo.Update(ctx, apiKey); err != nil {
+		return nil, err
+	}
+
+	return apiKey, nil
+}
+
+func (s *apiKeyService) RevokeAPIKey(ctx context.Context, id string) error {
+	apiKey, err := s.getActiveAPIKey(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	revokedAt := time.Now()
+	apiKey.RevokedAt = &revokedAt
+	return s.apiKeyRepo.Update(ctx, apiKey)
+}
+
+func (s *apiKeyService) VerifyAPIKey(ctx context.Context, key string) (*auth.Principal, error) {
+	if !strings.HasPrefix(key, apiKeyPrefix) {
+		return nil, auth.ErrInvalidToken
+	}
+
+	apiKey, err := s.apiKeyRepo.GetByHash(ctx, hashAPIKey(key))
+	if err != nil {
+		return nil, err
+	}
+
+	if apiKey == nil || apiKey.RevokedAt != nil || expired(apiKey) {
+		return nil, auth.ErrInvalidToken
+	}
+
+	return &auth.Principal{UserID: apiKey.OwnerUserID, APIKeyID: apiKey.ID, Scopes: apiKey.Scopes}, nil
+}
+
+func (s *apiKeyService) getActiveAPIKey(ctx context.Context, id string) (*models.APIKey, error) {
+	if strings.TrimSpace(id) == "" {
+		return nil, errors.New("API key ID is required")
+	}
+
+	apiKey, err := s.apiKeyRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, errors.New("API key not found")
+	}
+
+	if apiKey.RevokedAt != nil {
+		return nil, errors.New("API key has been revoked")
+	}
+
+	if expired(apiKey) {
+		return nil, errors.New("API key has expired")
+	}
+
+	return apiKey, nil
+}
+
+func expired(apiKey *models.APIKey) bool {
+	return apiKey.ExpiresAt != nil && !apiKey.ExpiresAt.After(time.Now())
+}
+
+func issueKey(apiKey *models.APIKey) error {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return err
+	}
+
+	apiKey.Key = apiKeyPrefix + base64.RawURLEncoding.EncodeToString(secret)
+	apiKey.Prefix = apiKey.Key[:len(apiKeyPrefix)+6]
+	apiKey.KeyHash = hashAPIKey(apiKey.Key)
+	return nil
+}
+
+// Keys are long random strings, so a fast unsalted hash is sufficient
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,73 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/spalqui/habitattrack-api/internal/models"
+	"github.com/spalqui/habitattrack-api/internal/repositories"
+	"github.com/spalqui/habitattrack-api/pkg/auth"
+	"github.com/spalqui/habitattrack-api/pkg/requestid"
+)
+
+type AuditService interface {
+	EventPublisher
+	ListAuditEvents(ctx context.Context, filter models.AuditEventFilter) ([]*models.AuditEvent, error)
+}
+
+type auditService struct {
+	auditRepo repositories.AuditEventRepository
+}
+
+func NewAuditService(auditRepo repositories.AuditEventRepository) AuditService {
+	return &auditService{
+		auditRepo: auditRepo,
+	}
+}
+
+// Publish records the event along with the principal and request that caused
+// it. Failures are logged rather than failing the change being audited.
+func (s *auditService) Publish(ctx context.Context, eventType string, data interface{}) {
+	event := &models.AuditEvent{
+		Type:      eventType,
+		RequestID: requestid.FromContext(ctx),
+		Data:      data,
+		CreatedAt: time.Now(),
+	}
+
+	if principal, ok := auth.FromContext(ctx); ok {
+		event.UserID = principal.UserID
+		event.Impersonated = principal.ImpersonatorID != ""
+		event.ImpersonatorID = principal.ImpersonatorID
+		switch {
+		case principal.APIKeyID != "":
+			event.Actor, event.ActorType = principal.APIKeyID, models.ActorTypeAPIKey
+		case principal.ClientID != "":
+			event.Actor, event.ActorType = principal.ClientID, models.ActorTypeClient
+		default:
+			event.Actor, event.ActorType = principal.UserID, models.ActorTypeUser
+		}
+	}
+
+	if err := s.auditRepo.Create(context.WithoutCancel(ctx), event); err != nil {
+		log.Printf("Failed to record audit event %s (request %s): %v", eventType, event.RequestID, err)
+	}
+}
+
+func (s *auditService) ListAuditEvents(ctx context.Context, filter models.AuditEventFilter) ([]*models.AuditEvent, error) {
+	return s.auditRepo.List(ctx, filter)
+}
+
+// MultiPublisher sends each event to every publisher in turn.
+func MultiPublisher(publishers ...EventPublisher) EventPublisher {
+	return multiPublisher(publishers)
+}
+
+type multiPublisher []EventPublisher
+
+func (m multiPublisher) Publish(ctx context.Context, eventType string, data interface{}) {
+	for _, publisher := range m {
+		publisher.Publish(ctx, eventType, data)
+	}
+}
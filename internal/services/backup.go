@@ -0,0 +1,267 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spalqui/habitattrack-api/internal/models"
+	"github.com/spalqui/habitattrack-api/internal/repositories"
+)
+
+type BackupService interface {
+	StartBackup(ctx context.Context) *models.Backup
+	CreateBackup(ctx context.Context, name string) (*models.Backup, error)
+	ListBackups(ctx context.Context) ([]*models.Backup, error)
+	DeleteBackup(ctx context.Context, name string) error
+	PruneBackups(ctx context.Context) (int, error)
+	RunScheduled(ctx context.Context) error
+	OpenBackup(ctx context.Context, name string) (io.ReadCloser, error)
+	RestoreBackup(ctx context.Context, open func() (io.ReadCloser, error), options models.RestoreOptions, progress func(restored int)) (int, error)
+}
+
+type backupService struct {
+	documents repositories.DocumentStore
+	store     repositories.BackupStore
+	retention time.Duration
+}
+
+// NewBackupService exports the database to store. Backups older than
+// retention are pruned, always keeping the newest; zero keeps every backup.
+func NewBackupService(documents repositories.DocumentStore, store repositories.BackupStore, retention time.Duration) BackupService {
+	return &backupService{
+		documents: documents,
+		store:     store,
+		retention: retention,
+	}
+}
+
+func backupName(now time.Time) string {
+	return now.UTC().Format("20060102T150405Z") + ".ndjson"
+}
+
+func validBackupName(name string) bool {
+	return strings.TrimSpace(name) != "" && !strings.Contains(name, "/")
+}
+
+// StartBackup exports in the background and returns the backup it will
+// write; it appears in the list once complete.
+func (s *backupService) StartBackup(ctx context.Context) *models.Backup {
+	now := time.Now()
+	backup := &models.Backup{Name: backupName(now), CreatedAt: now}
+
+	go func(ctx context.Context) {
+		if _, err := s.CreateBackup(ctx, backup.Name); err != nil {
+			log.Printf("Backup %s failed: %v", backup.Name, err)
+		}
+	}(context.WithoutCancel(ctx))
+
+	return backup
+}
+
+// CreateBackup writes every document to the named backup as newline-delimited
+// JSON.
+func (s *backupService) CreateBackup(ctx context.Context, name string) (*models.Backup, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	writer := s.store.Create(ctx, name)
+	buffered := bufio.NewWriter(writer)
+	encoder := json.NewEncoder(buffered)
+
+	count, err := s.documents.Export(ctx, func(record *models.BackupRecord) error {
+		return encoder.Encode(record)
+	})
+	if err == nil {
+		err = buffered.Flush()
+	}
+	if err != nil {
+		// Cancelling before closing discards the partial backup
+		cancel()
+		writer.Close()
+		return nil, err
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	log.Printf("Backup %s: exported %d documents", name, count)
+	return &models.Backup{Name: name, CreatedAt: time.Now()}, nil
+}
+
+// ListBackups returns the backups, newest first.
+func (s *backupService) ListBackups(ctx context.Context) ([]*models.Backup, error) {
+	backups, err := s.store.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].CreatedAt.After(backups[j].CreatedAt)
+	})
+	return backups, nil
+}
+
+func (s *backupService) DeleteBackup(ctx context.Context, name string) error {
+	if !validBackupName(name) {
+		return errors.New("invalid backup name")
+	}
+
+	return s.store.Delete(ctx, name)
+}
+
+// PruneBackups deletes backups past the retention period and returns how
+// many it deleted. The newest backup is always kept.
+func (s *backupService) PruneBackups(ctx context.Context) (int, error) {
+	if s.retention <= 0 {
+		return 0, nil
+	}
+
+	backups, err := s.ListBackups(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	cutoff := time.Now().Add(-s.retention)
+	pruned := 0
+	for i, backup := range backups {
+		if i == 0 || backup.CreatedAt.After(cutoff) {
+			continue
+		}
+		if err := s.store.Delete(ctx, backup.Name); err != nil {
+			return pruned, err
+		}
+		pruned++
+	}
+
+	return pruned, nil
+}
+
+func (s *backupService) OpenBackup(ctx context.Context, name string) (io.ReadCloser, error) {
+	if !validBackupName(name) {
+		return nil, errors.New("invalid backup name")
+	}
+
+	return s.store.Open(ctx, name)
+}
+
+// restoreBatchSize is how many documents are written between progress
+// reports.
+const restoreBatchSize = 500
+
+// RestoreBackup replays a backup read from open. The whole backup is
+// validated before anything is written, so a corrupt file leaves the target
+// untouched; open is called again for the second pass. Unless forced, the
+// target must be empty. It returns the number of documents restored, or that
+// would be with DryRun.
+func (s *backupService) RestoreBackup(ctx context.Context, open func() (io.ReadCloser, error), options models.RestoreOptions, progress func(restored int)) (int, error) {
+	if options.Prefix != "" {
+		if segments := strings.Split(options.Prefix, "/"); len(segments)%2 != 0 || slices.Contains(segments, "") {
+			return 0, errors.New("prefix must be a document path")
+		}
+	}
+
+	if !options.Force {
+		empty, err := s.documents.IsEmpty(ctx, options.Prefix)
+		if err != nil {
+			return 0, err
+		}
+		if !empty {
+			return 0, errors.New("restore target is not empty")
+		}
+	}
+
+	total, err := s.readBackup(open, func(records []*models.BackupRecord) error {
+		return nil
+	})
+	if err != nil || options.DryRun {
+		return total, err
+	}
+
+	restored := 0
+	_, err = s.readBackup(open, func(records []*models.BackupRecord) error {
+		if err := s.documents.Import(ctx, options.Prefix, records); err != nil {
+			return err
+		}
+		restored += len(records)
+		if progress != nil {
+			progress(restored)
+		}
+		return nil
+	})
+	return restored, err
+}
+
+// readBackup validates every record and passes them on in batches.
+func (s *backupService) readBackup(open func() (io.ReadCloser, error), batch func(records []*models.BackupRecord) error) (int, error) {
+	reader, err := open()
+	if err != nil {
+		return 0, err
+	}
+	defer reader.Close()
+
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+
+	var records []*models.BackupRecord
+	count, line := 0, 0
+	for scanner.Scan() {
+		line++
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+
+		var record models.BackupRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return count, fmt.Errorf("line %d: %w", line, err)
+		}
+		if err := s.documents.Validate(&record); err != nil {
+			return count, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		records = append(records, &record)
+		count++
+		if len(records) == restoreBatchSize {
+			if err := batch(records); err != nil {
+				return count, err
+			}
+			records = nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return count, err
+	}
+
+	if len(records) > 0 {
+		if err := batch(records); err != nil {
+			return count, err
+		}
+	}
+	return count, nil
+}
+
+// RunScheduled backs up, then prunes backups past their retention.
+func (s *backupService) RunScheduled(ctx context.Context) error {
+	if _, err := s.CreateBackup(ctx, backupName(time.Now())); err != nil {
+		return err
+	}
+
+	pruned, err := s.PruneBackups(ctx)
+	if err != nil {
+		return fmt.Errorf("pruning backups: %w", err)
+	}
+	if pruned > 0 {
+		log.Printf("Pruned %d backups", pruned)
+	}
+	return nil
+}
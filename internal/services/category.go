@@ -7,24 +7,40 @@ import (
 
 	"github.com/spalqui/habitattrack-api/internal/models"
 	"github.com/spalqui/habitattrack-api/internal/repositories"
+	"github.com/spalqui/habitattrack-api/pkg/sanitize"
 )
 
 type CategoryService interface {
 	CreateCategory(ctx context.Context, category *models.Category) error
 	GetCategory(ctx context.Context, id string) (*models.Category, error)
+	GetCategoriesByIDs(ctx context.Context, ids []string) (map[string]*models.Category, error)
 	GetAllCategories(ctx context.Context) ([]*models.Category, error)
+	CountCategories(ctx context.Context) (int64, error)
+	ListCategoriesPage(ctx context.Context, transactionType models.TransactionType, page models.PageRequest) (*models.Page[*models.Category], error)
 	GetCategoriesByType(ctx context.Context, transactionType models.TransactionType) ([]*models.Category, error)
+	ValidateCategory(ctx context.Context, category *models.Category) error
 	UpdateCategory(ctx context.Context, category *models.Category) error
-	DeleteCategory(ctx context.Context, id string) error
+	DeleteCategory(ctx context.Context, id string, options models.CategoryDeleteOptions) error
 }
 
 type categoryService struct {
-	categoryRepo repositories.CategoryRepository
+	categoryRepo    repositories.CategoryRepository
+	transactionRepo repositories.TransactionRepository
+	publisher       EventPublisher
+	text            sanitize.Policy
 }
 
-func NewCategoryService(categoryRepo repositories.CategoryRepository) CategoryService {
+func NewCategoryService(
+	categoryRepo repositories.CategoryRepository,
+	transactionRepo repositories.TransactionRepository,
+	publisher EventPublisher,
+	text sanitize.Policy,
+) CategoryService {
 	return &categoryService{
-		categoryRepo: categoryRepo,
+		categoryRepo:    categoryRepo,
+		transactionRepo: transactionRepo,
+		publisher:       publisher,
+		text:            text,
 	}
 }
 
@@ -33,56 +49,191 @@ func (s *categoryService) CreateCategory(ctx context.Context, category *models.C
 		return err
 	}
 
-	return s.categoryRepo.Create(ctx, category)
+	if err := s.categoryRepo.Create(ctx, category); err != nil {
+		return err
+	}
+
+	s.publisher.Publish(ctx, models.EventCategoryCreated, category)
+	return nil
 }
 
 func (s *categoryService) GetCategory(ctx context.Context, id string) (*models.Category, error) {
 	if strings.TrimSpace(id) == "" {
-		return nil, errors.New("category ID is required")
+		return nil, invalid("category ID is required")
 	}
 
 	return s.categoryRepo.GetByID(ctx, id)
 }
 
+// GetCategoriesByIDs reads the categories in one round trip, keyed by ID.
+// IDs that don't exist are left out.
+func (s *categoryService) GetCategoriesByIDs(ctx context.Context, ids []string) (map[string]*models.Category, error) {
+	return s.categoryRepo.GetByIDs(ctx, ids)
+}
+
 func (s *categoryService) GetAllCategories(ctx context.Context) ([]*models.Category, error) {
 	return s.categoryRepo.GetAll(ctx)
 }
 
+func (s *categoryService) CountCategories(ctx context.Context) (int64, error) {
+	return s.categoryRepo.Count(ctx)
+}
+
+// ListCategoriesPage returns one page of categories, optionally of a single
+// type, with the total from an aggregation query.
+func (s *categoryService) ListCategoriesPage(ctx context.Context, transactionType models.TransactionType, page models.PageRequest) (*models.Page[*models.Category], error) {
+	if transactionType != "" && transactionType != models.TransactionTypeIncome && transactionType != models.TransactionTypeExpense {
+		return nil, invalid("invalid transaction type")
+	}
+
+	return listPage(ctx, page, func(ctx context.Context) (*models.Page[*models.Category], error) {
+		return s.categoryRepo.ListPage(ctx, transactionType, page)
+	}, func(ctx context.Context) (int64, error) {
+		return s.categoryRepo.CountByType(ctx, transactionType)
+	}, nil)
+}
+
 func (s *categoryService) GetCategoriesByType(ctx context.Context, transactionType models.TransactionType) ([]*models.Category, error) {
 	if transactionType != models.TransactionTypeIncome && transactionType != models.TransactionTypeExpense {
-		return nil, errors.New("invalid transaction type")
+		return nil, invalid("invalid transaction type")
 	}
 
 	return s.categoryRepo.GetByType(ctx, transactionType)
 }
 
+func (s *categoryService) ValidateCategory(ctx context.Context, category *models.Category) error {
+	return s.validateCategory(category)
+}
+
 func (s *categoryService) UpdateCategory(ctx context.Context, category *models.Category) error {
 	if err := s.validateCategory(category); err != nil {
 		return err
 	}
 
 	if strings.TrimSpace(category.ID) == "" {
-		return errors.New("category ID is required for update")
+		return invalid("category ID is required for update")
 	}
 
-	return s.categoryRepo.Update(ctx, category)
+	existing, err := s.categoryRepo.GetByID(ctx, category.ID)
+	if err != nil {
+		return err
+	}
+
+	if category.Type != existing.Type {
+		count, err := s.transactionRepo.CountByCategoryID(ctx, category.ID)
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrCategoryTypeInUse
+		}
+	}
+
+	category.CreatedAt = existing.CreatedAt
+	// Without a version from the caller, the update applies to the one read here
+	if category.UpdatedAt.IsZero() {
+		category.UpdatedAt = existing.UpdatedAt
+	}
+	if err := s.categoryRepo.Update(ctx, category); err != nil {
+		return err
+	}
+
+	if category.Name != existing.Name {
+		if err := s.transactionRepo.UpdateCategoryName(ctx, category.ID, category.Name); err != nil {
+			return err
+		}
+	}
+
+	s.publisher.Publish(ctx, models.EventCategoryUpdated, category)
+	return nil
 }
 
-func (s *categoryService) DeleteCategory(ctx context.Context, id string) error {
+// DeleteCategory refuses to delete a category that transactions still
+// refer to, unless they're reassigned to another category first.
+func (s *categoryService) DeleteCategory(ctx context.Context, id string, options models.CategoryDeleteOptions) error {
 	if strings.TrimSpace(id) == "" {
-		return errors.New("category ID is required")
+		return invalid("category ID is required")
 	}
 
-	return s.categoryRepo.Delete(ctx, id)
+	if options.ReassignTo != "" {
+		if err := s.reassignTransactions(ctx, id, options.ReassignTo); err != nil {
+			return err
+		}
+	} else {
+		count, err := s.transactionRepo.CountByCategoryID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			return &InUseError{Resource: "category", Dependents: map[string]int64{"transactions": count}}
+		}
+	}
+
+	if err := s.categoryRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	s.publisher.Publish(ctx, models.EventCategoryDeleted, map[string]string{"id": id})
+	return nil
+}
+
+func (s *categoryService) reassignTransactions(ctx context.Context, id, targetID string) error {
+	if targetID == id {
+		return invalid("cannot reassign transactions to the category being deleted")
+	}
+
+	source, err := s.categoryRepo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	target, err := s.categoryRepo.GetByID(ctx, targetID)
+	if errors.Is(err, repositories.ErrCategoryNotFound) {
+		return invalid("target category not found")
+	}
+	if err != nil {
+		return err
+	}
+
+	if source.Type != target.Type {
+		return invalid("target category type does not match source category type")
+	}
+
+	transactions, err := s.transactionRepo.List(ctx, models.TransactionFilter{CategoryIDs: []string{id}})
+	if err != nil {
+		return err
+	}
+
+	ids := make([]string, len(transactions))
+	for i, transaction := range transactions {
+		ids[i] = transaction.ID
+	}
+
+	updated, err := s.transactionRepo.ReassignCategory(ctx, ids, target.ID, target.Name)
+	for _, transaction := range transactions[:updated] {
+		transaction.CategoryID = target.ID
+		transaction.CategoryName = target.Name
+		s.publisher.Publish(ctx, models.EventTransactionUpdated, transaction)
+	}
+	return err
 }
 
 func (s *categoryService) validateCategory(category *models.Category) error {
+	for _, err := range []error{
+		s.text.Name("name", &category.Name),
+		s.text.Description("description", &category.Description),
+	} {
+		if err != nil {
+			return invalid(err.Error())
+		}
+	}
+
 	if strings.TrimSpace(category.Name) == "" {
-		return errors.New("category name is required")
+		return invalid("category name is required")
 	}
 
 	if category.Type != models.TransactionTypeIncome && category.Type != models.TransactionTypeExpense {
-		return errors.New("invalid transaction type")
+		return invalid("invalid transaction type")
 	}
 
 	return nil
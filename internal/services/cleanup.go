@@ -0,0 +1,64 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/spalqui/habitattrack-api/internal/models"
+	"github.com/spalqui/habitattrack-api/internal/repositories"
+)
+
+type CleanupService interface {
+	RunCleanup(ctx context.Context) (*models.CleanupResult, error)
+	RunScheduled(ctx context.Context) error
+}
+
+type cleanupService struct {
+	cleanupRepo                 repositories.CleanupRepository
+	deletedTransactionRetention time.Duration
+	webhookDeliveryRetention    time.Duration
+}
+
+// NewCleanupService purges records once they're older than their retention;
+// a zero retention keeps that kind of record forever.
+func NewCleanupService(cleanupRepo repositories.CleanupRepository, deletedTransactionRetention, webhookDeliveryRetention time.Duration) CleanupService {
+	return &cleanupService{
+		cleanupRepo:                 cleanupRepo,
+		deletedTransactionRetention: deletedTransactionRetention,
+		webhookDeliveryRetention:    webhookDeliveryRetention,
+	}
+}
+
+// RunCleanup purges soft-deleted transactions and webhook delivery logs past
+// their retention. The counts cover what was purged before any error.
+func (s *cleanupService) RunCleanup(ctx context.Context) (*models.CleanupResult, error) {
+	result := &models.CleanupResult{}
+	now := time.Now()
+
+	if s.deletedTransactionRetention > 0 {
+		purged, err := s.cleanupRepo.PurgeDeletedTransactions(ctx, now.Add(-s.deletedTransactionRetention))
+		result.DeletedTransactions = purged
+		if err != nil {
+			return result, err
+		}
+	}
+
+	if s.webhookDeliveryRetention > 0 {
+		purged, err := s.cleanupRepo.PurgeWebhookDeliveries(ctx, now.Add(-s.webhookDeliveryRetention))
+		result.WebhookDeliveries = purged
+		if err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
+
+// RunScheduled runs the cleanup, logging what was purged even when it fails
+// part way.
+func (s *cleanupService) RunScheduled(ctx context.Context) error {
+	result, err := s.RunCleanup(ctx)
+	log.Printf("Cleanup purged %d deleted transactions and %d webhook deliveries", result.DeletedTransactions, result.WebhookDeliveries)
+	return err
+}
@@ -0,0 +1,126 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spalqui/habitattrack-api/internal/models"
+	"github.com/spalqui/habitattrack-api/internal/repositories"
+	"github.com/spalqui/habitattrack-api/pkg/auth"
+)
+
+type ConsentService interface {
+	AcceptConsent(ctx context.Context, consent *models.Consent) error
+	GetConsents(ctx context.Context) ([]*models.Consent, error)
+	GetRequirements() models.ConsentRequirements
+	MissingConsents(ctx context.Context, userID string) ([]string, error)
+}
+
+type consentService struct {
+	consentRepo  repositories.ConsentRepository
+	requirements models.ConsentRequirements
+
+	// Acceptances are permanent, so once seen they are remembered
+	mu       sync.Mutex
+	accepted map[string]bool
+}
+
+func NewConsentService(consentRepo repositories.ConsentRepository, requirements models.ConsentRequirements) ConsentService {
+	return &consentService{
+		consentRepo:  consentRepo,
+		requirements: requirements,
+		accepted:     make(map[string]bool),
+	}
+}
+
+// AcceptConsent records acceptance of the currently required version of a
+// document.
+func (s *consentService) AcceptConsent(ctx context.Context, consent *models.Consent) error {
+	userID := auth.UserID(ctx)
+	if userID == "" {
+		return errors.New("authentication is required to record consent")
+	}
+
+	if principal, _ := auth.FromContext(ctx); principal.APIKeyID != "" || principal.ImpersonatorID != "" {
+		return errors.New("consent must be given by the user")
+	}
+
+	if consent.Document != models.ConsentDocumentTerms && consent.Document != models.ConsentDocumentPrivacy {
+		return errors.New("document must be terms or privacy")
+	}
+
+	if strings.TrimSpace(consent.Version) == "" {
+		return errors.New("version is required")
+	}
+
+	if required, ok := s.requirements[consent.Document]; ok && consent.Version != required {
+		return fmt.Errorf("only the current version can be accepted: %s", required)
+	}
+
+	consent.UserID = userID
+	consent.AcceptedAt = time.Now()
+	if err := s.consentRepo.Save(ctx, consent); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.accepted[acceptanceKey(userID, consent.Document, consent.Version)] = true
+	s.mu.Unlock()
+
+	return nil
+}
+
+func (s *consentService) GetConsents(ctx context.Context) ([]*models.Consent, error) {
+	userID := auth.UserID(ctx)
+	if userID == "" {
+		return nil, errors.New("authentication is required to record consent")
+	}
+
+	return s.consentRepo.GetByUserID(ctx, userID)
+}
+
+func (s *consentService) GetRequirements() models.ConsentRequirements {
+	return s.requirements
+}
+
+// MissingConsents lists the documents whose required version the user has
+// not accepted yet.
+func (s *consentService) MissingConsents(ctx context.Context, userID string) ([]string, error) {
+	var missing []string
+	for document, version := range s.requirements {
+		key := acceptanceKey(userID, document, version)
+
+		s.mu.Lock()
+		accepted := s.accepted[key]
+		s.mu.Unlock()
+		if accepted {
+			continue
+		}
+
+		exists, err := s.consentRepo.Exists(ctx, userID, document, version)
+		if err != nil {
+			return nil, err
+		}
+
+		if !exists {
+			missing = append(missing, document)
+			continue
+		}
+
+		s.mu.Lock()
+		s.accepted[key] = true
+		s.mu.Unlock()
+	}
+
+	sort.Strings(missing)
+	return missing, nil
+}
+
+func acceptanceKey(userID, document, version string) string {
+	return userID + "\x00" + document + "\x00" + version
+}
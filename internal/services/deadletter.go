@@ -0,0 +1,113 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"github.com/spalqui/habitattrack-api/internal/models"
+	"github.com/spalqui/habitattrack-api/internal/repositories"
+	"github.com/spalqui/habitattrack-api/pkg/auth"
+)
+
+var ErrDeadLetterNotFound = errors.New("dead letter not found")
+
+type DeadLetterService interface {
+	ListDeadLetters(ctx context.Context, filter models.DeadLetterFilter) ([]*models.DeadLetter, error)
+	ReplayDeadLetter(ctx context.Context, id string) error
+	ReplayDeadLetters(ctx context.Context, filter models.DeadLetterFilter) (*models.ReplayResult, error)
+}
+
+type deadLetterService struct {
+	deadLetterRepo repositories.DeadLetterRepository
+	webhookRepo    repositories.WebhookRepository
+	outboxRepo     repositories.OutboxRepository
+	dispatcher     WebhookDispatcher
+}
+
+func NewDeadLetterService(
+	deadLetterRepo repositories.DeadLetterRepository,
+	webhookRepo repositories.WebhookRepository,
+	outboxRepo repositories.OutboxRepository,
+	dispatcher WebhookDispatcher,
+) DeadLetterService {
+	return &deadLetterService{
+		deadLetterRepo: deadLetterRepo,
+		webhookRepo:    webhookRepo,
+		outboxRepo:     outboxRepo,
+		dispatcher:     dispatcher,
+	}
+}
+
+func (s *deadLetterService) ListDeadLetters(ctx context.Context, filter models.DeadLetterFilter) ([]*models.DeadLetter, error) {
+	if filter.Kind != "" && filter.Kind != models.DeadLetterWebhook && filter.Kind != models.DeadLetterEvent {
+		return nil, errors.New("kind must be webhook or event")
+	}
+
+	return s.deadLetterRepo.List(ctx, filter)
+}
+
+// ReplayDeadLetter hands the dead letter back for delivery with a fresh set
+// of retries and removes it; a replay that fails again is dead-lettered anew.
+func (s *deadLetterService) ReplayDeadLetter(ctx context.Context, id string) error {
+	deadLetter, err := s.deadLetterRepo.GetByID(ctx, id)
+	if err != nil {
+		return ErrDeadLetterNotFound
+	}
+
+	return s.replay(ctx, deadLetter)
+}
+
+// ReplayDeadLetters replays every dead letter matching the filter, stopping
+// at the first that can't be replayed.
+func (s *deadLetterService) ReplayDeadLetters(ctx context.Context, filter models.DeadLetterFilter) (*models.ReplayResult, error) {
+	deadLetters, err := s.ListDeadLetters(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.ReplayResult{}
+	for _, deadLetter := range deadLetters {
+		if err := s.replay(ctx, deadLetter); err != nil {
+			return result, err
+		}
+		result.Replayed++
+	}
+	return result, nil
+}
+
+func (s *deadLetterService) replay(ctx context.Context, deadLetter *models.DeadLetter) error {
+	switch deadLetter.Kind {
+	case models.DeadLetterWebhook:
+		// The webhook is looked up in the organization it belongs to
+		orgCtx := ctx
+		if deadLetter.OrgID != "" {
+			orgCtx = auth.WithPrincipal(ctx, &auth.Principal{OrgID: deadLetter.OrgID})
+		}
+
+		webhook, err := s.webhookRepo.GetByID(orgCtx, deadLetter.WebhookID)
+		if err != nil {
+			return errors.New("webhook no longer exists")
+		}
+		if !webhook.Active {
+			return errors.New("webhook is inactive")
+		}
+
+		event := &models.Event{ID: deadLetter.EventID, Type: deadLetter.EventType, CreatedAt: deadLetter.EventAt}
+		if err := s.dispatcher.Redeliver(orgCtx, webhook, event, deadLetter.Payload); err != nil {
+			return err
+		}
+	case models.DeadLetterEvent:
+		err := s.outboxRepo.Requeue(ctx, &models.OutboxEvent{
+			ID:        deadLetter.EventID,
+			Type:      deadLetter.EventType,
+			OrgID:     deadLetter.OrgID,
+			Payload:   deadLetter.Payload,
+			CreatedAt: deadLetter.EventAt,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return s.deadLetterRepo.Delete(ctx, deadLetter.ID)
+}
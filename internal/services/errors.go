@@ -0,0 +1,72 @@
+package services
+
+import (
+	"errors"
+
+	"github.com/spalqui/habitattrack-api/internal/models"
+)
+
+// ErrFutureDate rejects a transaction dated after today in an organization
+// whose policy refuses them.
+var ErrFutureDate = errors.New("transaction date is in the future")
+
+// ErrCategoryTypeInUse refuses to switch a category between income and
+// expense while transactions use it, which would flip the sign of their
+// amounts in past reports.
+var ErrCategoryTypeInUse = errors.New("a category with transactions can't change between income and expense; create a new category instead")
+
+// ValidationError rejects a request that's invalid in itself, as opposed to
+// one that failed for reasons outside the caller's control.
+type ValidationError struct {
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Message
+}
+
+func invalid(message string) error {
+	return &ValidationError{Message: message}
+}
+
+// InUseError refuses to delete a resource that other records still refer
+// to, with how many of each kind do.
+type InUseError struct {
+	Resource   string
+	Dependents map[string]int64
+}
+
+func (e *InUseError) Error() string {
+	return e.Resource + " is in use"
+}
+
+// FieldError is a validation failure of one request field.
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+func (e *FieldError) Error() string {
+	return e.Message
+}
+
+// LimitError rejects a request whose scope exceeds a configured cap on one
+// of its fields.
+type LimitError struct {
+	Field   string
+	Message string
+}
+
+func (e *LimitError) Error() string {
+	return e.Message
+}
+
+// ValidateTransactionFilter checks a filter's dates make a range: a filter
+// whose end precedes its start can't match anything, and is more likely a
+// mistake than a query.
+func ValidateTransactionFilter(filter models.TransactionFilter) error {
+	if !filter.StartDate.IsZero() && !filter.EndDate.IsZero() && filter.EndDate.Before(filter.StartDate) {
+		return &FieldError{Field: "endDate", Message: "endDate must not be before startDate"}
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/spalqui/habitattrack-api/internal/models"
+)
+
+// HealthCheck probes a dependency, returning an error when it's unusable.
+type HealthCheck func(ctx context.Context) error
+
+// HealthComponent is a named dependency. The instance can't serve traffic
+// without its required components; the rest only degrade it.
+type HealthComponent struct {
+	Name     string
+	Check    HealthCheck
+	Required bool
+}
+
+type HealthService interface {
+	CheckReadiness(ctx context.Context) *models.HealthReport
+	CheckHealth(ctx context.Context) *models.HealthReport
+}
+
+type healthService struct {
+	components []HealthComponent
+	timeout    time.Duration
+}
+
+// NewHealthService probes the components, giving each at most timeout so a
+// hung dependency can't hold up the probe.
+func NewHealthService(components []HealthComponent, timeout time.Duration) HealthService {
+	return &healthService{
+		components: components,
+		timeout:    timeout,
+	}
+}
+
+// CheckReadiness probes only the required components.
+func (s *healthService) CheckReadiness(ctx context.Context) *models.HealthReport {
+	var required []HealthComponent
+	for _, component := range s.components {
+		if component.Required {
+			required = append(required, component)
+		}
+	}
+	return s.check(ctx, required)
+}
+
+// CheckHealth probes every component.
+func (s *healthService) CheckHealth(ctx context.Context) *models.HealthReport {
+	return s.check(ctx, s.components)
+}
+
+// check runs the probes concurrently.
+func (s *healthService) check(ctx context.Context, components []HealthComponent) *models.HealthReport {
+	report := &models.HealthReport{
+		Status:     models.HealthStatusUp,
+		Components: make(map[string]*models.ComponentHealth, len(components)),
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, component := range components {
+		wg.Add(1)
+		go func(component HealthComponent) {
+			defer wg.Done()
+			result := s.probe(ctx, component.Check)
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Components[component.Name] = result
+			if result.Status == models.HealthStatusUp {
+				return
+			}
+			if component.Required {
+				report.Status = models.HealthStatusDown
+			} else if report.Status == models.HealthStatusUp {
+				report.Status = models.HealthStatusDegraded
+			}
+		}(component)
+	}
+	wg.Wait()
+
+	return report
+}
+
+func (s *healthService) probe(ctx context.Context, check HealthCheck) *models.ComponentHealth {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check(ctx)
+	result := &models.ComponentHealth{
+		Status:    models.HealthStatusUp,
+		LatencyMs: time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		result.Status = models.HealthStatusDown
+		result.Error = err.Error()
+	}
+	return result
+}
@@ -0,0 +1,110 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/spalqui/habitattrack-api/internal/models"
+	"github.com/spalqui/habitattrack-api/internal/repositories"
+	"github.com/spalqui/habitattrack-api/pkg/auth"
+)
+
+type ImpersonationService interface {
+	StartImpersonation(ctx context.Context, userID, reason string) (*models.Impersonation, error)
+	EndImpersonation(ctx context.Context, id string) (*models.Impersonation, error)
+	GetAllImpersonations(ctx context.Context) ([]*models.Impersonation, error)
+}
+
+type impersonationService struct {
+	impersonationRepo repositories.ImpersonationRepository
+	revocationService RevocationService
+	tokens            *auth.ClientTokens
+}
+
+func NewImpersonationService(impersonationRepo repositories.ImpersonationRepository, revocationService RevocationService, tokens *auth.ClientTokens) ImpersonationService {
+	return &impersonationService{
+		impersonationRepo: impersonationRepo,
+		revocationService: revocationService,
+		tokens:            tokens,
+	}
+}
+
+// StartImpersonation records the session and returns a token acting as the
+// user. Every change made with it is flagged in the audit log.
+func (s *impersonationService) StartImpersonation(ctx context.Context, userID, reason string) (*models.Impersonation, error) {
+	principal, ok := auth.FromContext(ctx)
+	if !ok || !principal.Admin || principal.ImpersonatorID != "" {
+		return nil, errors.New("administrator access required")
+	}
+
+	if strings.TrimSpace(userID) == "" {
+		return nil, errors.New("user ID is required")
+	}
+
+	if userID == principal.UserID {
+		return nil, errors.New("you cannot impersonate yourself")
+	}
+
+	if strings.TrimSpace(reason) == "" {
+		return nil, errors.New("a reason is required to impersonate a user")
+	}
+
+	impersonation := &models.Impersonation{
+		ID:          newEventID(),
+		AdminUserID: principal.UserID,
+		UserID:      userID,
+		Reason:      reason,
+		CreatedAt:   time.Now(),
+	}
+
+	token, ttl, err := s.tokens.IssueImpersonation(impersonation.ID, principal.UserID, userID)
+	if err != nil {
+		return nil, err
+	}
+	impersonation.ExpiresAt = impersonation.CreatedAt.Add(ttl)
+
+	if err := s.impersonationRepo.Create(ctx, impersonation); err != nil {
+		return nil, err
+	}
+
+	log.Printf("Admin %s started impersonating user %s (session %s): %s", principal.UserID, userID, impersonation.ID, reason)
+
+	impersonation.Token = token
+	return impersonation, nil
+}
+
+// EndImpersonation revokes the session's token before it expires.
+func (s *impersonationService) EndImpersonation(ctx context.Context, id string) (*models.Impersonation, error) {
+	if strings.TrimSpace(id) == "" {
+		return nil, errors.New("impersonation ID is required")
+	}
+
+	impersonation, err := s.impersonationRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, errors.New("impersonation not found")
+	}
+
+	if impersonation.EndedAt != nil {
+		return impersonation, nil
+	}
+
+	if _, err := s.revocationService.RevokeToken(ctx, impersonation.ID, "impersonation ended"); err != nil {
+		return nil, err
+	}
+
+	endedAt := time.Now()
+	impersonation.EndedAt = &endedAt
+	if err := s.impersonationRepo.Update(ctx, impersonation); err != nil {
+		return nil, err
+	}
+
+	log.Printf("Impersonation session %s of user %s ended by %s", impersonation.ID, impersonation.UserID, auth.UserID(ctx))
+	return impersonation, nil
+}
+
+func (s *impersonationService) GetAllImpersonations(ctx context.Context) ([]*models.Impersonation, error) {
+	return s.impersonationRepo.GetAll(ctx)
+}
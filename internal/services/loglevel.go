@@ -0,0 +1,89 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spalqui/habitattrack-api/internal/models"
+)
+
+// maxLogLevelDuration caps temporary levels, so debug logging can't be left
+// on by mistake for days.
+const maxLogLevelDuration = 24 * time.Hour
+
+type LogLevelService interface {
+	GetLogLevel() *models.LogLevel
+	SetLogLevel(logLevel *models.LogLevel) error
+}
+
+type logLevelService struct {
+	level *slog.LevelVar
+
+	mu       sync.Mutex
+	revert   *time.Timer
+	revertAt *time.Time
+}
+
+// NewLogLevelService changes level, which the process's slog handler reads
+// on every record. The change applies to this instance only.
+func NewLogLevelService(level *slog.LevelVar) LogLevelService {
+	return &logLevelService{level: level}
+}
+
+func (s *logLevelService) GetLogLevel() *models.LogLevel {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return &models.LogLevel{Level: strings.ToLower(s.level.Level().String()), RevertAt: s.revertAt}
+}
+
+// SetLogLevel switches the level, for DurationSeconds when it's set or
+// until changed again otherwise.
+func (s *logLevelService) SetLogLevel(logLevel *models.LogLevel) error {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(logLevel.Level)); err != nil {
+		return errors.New("level must be debug, info, warn or error")
+	}
+	duration := time.Duration(logLevel.DurationSeconds) * time.Second
+	if duration < 0 || duration > maxLogLevelDuration {
+		return errors.New("durationSeconds must be between 0 and 86400")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// A new level replaces any pending revert
+	previous := s.level.Level()
+	if s.revert != nil {
+		s.revert.Stop()
+		s.revert, s.revertAt = nil, nil
+	}
+
+	s.level.Set(level)
+	log.Printf("Log level set to %s", level)
+
+	if duration > 0 {
+		revertAt := time.Now().Add(duration)
+		s.revertAt = &revertAt
+		var timer *time.Timer
+		timer = time.AfterFunc(duration, func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			if s.revert != timer {
+				return
+			}
+			s.level.Set(previous)
+			s.revert, s.revertAt = nil, nil
+			log.Printf("Log level reverted to %s", previous)
+		})
+		s.revert = timer
+	}
+
+	logLevel.Level = strings.ToLower(level.String())
+	logLevel.RevertAt = s.revertAt
+	return nil
+}
@@ -0,0 +1,87 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/spalqui/habitattrack-api/internal/models"
+	"github.com/spalqui/habitattrack-api/internal/repositories"
+	"github.com/spalqui/habitattrack-api/pkg/auth"
+)
+
+// maintenanceCacheTTL bounds how long other instances take to notice the
+// switch, in exchange for not reading Firestore on every write.
+const maintenanceCacheTTL = 10 * time.Second
+
+// defaultMaintenanceRetryAfter is suggested to clients when maintenance is
+// enabled without an estimate.
+const defaultMaintenanceRetryAfter = 300
+
+type MaintenanceService interface {
+	GetMaintenance(ctx context.Context) (*models.Maintenance, error)
+	SetMaintenance(ctx context.Context, maintenance *models.Maintenance) error
+	InMaintenance(ctx context.Context) (bool, time.Duration, error)
+}
+
+type maintenanceService struct {
+	maintenanceRepo repositories.MaintenanceRepository
+
+	mu      sync.Mutex
+	cached  *models.Maintenance
+	expires time.Time
+}
+
+func NewMaintenanceService(maintenanceRepo repositories.MaintenanceRepository) MaintenanceService {
+	return &maintenanceService{
+		maintenanceRepo: maintenanceRepo,
+	}
+}
+
+func (s *maintenanceService) GetMaintenance(ctx context.Context) (*models.Maintenance, error) {
+	return s.maintenanceRepo.Get(ctx)
+}
+
+func (s *maintenanceService) SetMaintenance(ctx context.Context, maintenance *models.Maintenance) error {
+	if maintenance.RetryAfterSeconds < 0 {
+		return errors.New("retryAfterSeconds cannot be negative")
+	}
+
+	if maintenance.Enabled && maintenance.RetryAfterSeconds == 0 {
+		maintenance.RetryAfterSeconds = defaultMaintenanceRetryAfter
+	}
+	maintenance.UpdatedBy = auth.UserID(ctx)
+	if err := s.maintenanceRepo.Save(ctx, maintenance); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.cached = maintenance
+	s.expires = time.Now().Add(maintenanceCacheTTL)
+	s.mu.Unlock()
+
+	return nil
+}
+
+// InMaintenance reports whether writes are currently refused, and how long
+// clients should wait before retrying them.
+func (s *maintenanceService) InMaintenance(ctx context.Context) (bool, time.Duration, error) {
+	s.mu.Lock()
+	maintenance, expires := s.cached, s.expires
+	s.mu.Unlock()
+
+	if maintenance == nil || time.Now().After(expires) {
+		var err error
+		if maintenance, err = s.maintenanceRepo.Get(ctx); err != nil {
+			return false, 0, err
+		}
+
+		s.mu.Lock()
+		s.cached = maintenance
+		s.expires = time.Now().Add(maintenanceCacheTTL)
+		s.mu.Unlock()
+	}
+
+	return maintenance.Enabled, time.Duration(maintenance.RetryAfterSeconds) * time.Second, nil
+}
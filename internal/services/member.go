@@ -0,0 +1,110 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/spalqui/habitattrack-api/internal/models"
+	"github.com/spalqui/habitattrack-api/internal/repositories"
+	"github.com/spalqui/habitattrack-api/pkg/auth"
+)
+
+type MemberService interface {
+	CreateMember(ctx context.Context, member *models.Member) error
+	GetAllMembers(ctx context.Context) ([]*models.Member, error)
+	UpdateMember(ctx context.Context, member *models.Member) error
+	DeleteMember(ctx context.Context, id string) error
+	ResolveRole(ctx context.Context, orgID, userID string) (auth.Role, error)
+}
+
+type memberService struct {
+	memberRepo repositories.MemberRepository
+}
+
+func NewMemberService(memberRepo repositories.MemberRepository) MemberService {
+	return &memberService{
+		memberRepo: memberRepo,
+	}
+}
+
+func (s *memberService) CreateMember(ctx context.Context, member *models.Member) error {
+	if err := s.validateMember(ctx, member); err != nil {
+		return err
+	}
+
+	member.OrgID = auth.OrgID(ctx)
+	existing, err := s.memberRepo.GetByOrgAndUser(ctx, member.OrgID, member.UserID)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return errors.New("user is already a member")
+	}
+
+	return s.memberRepo.Create(ctx, member)
+}
+
+func (s *memberService) GetAllMembers(ctx context.Context) ([]*models.Member, error) {
+	return s.memberRepo.GetAll(ctx)
+}
+
+func (s *memberService) UpdateMember(ctx context.Context, member *models.Member) error {
+	if strings.TrimSpace(member.ID) == "" {
+		return errors.New("member ID is required for update")
+	}
+
+	existing, err := s.memberRepo.GetByID(ctx, member.ID)
+	if err != nil {
+		return errors.New("member not found")
+	}
+
+	// Only the role can change; re-point a grant by deleting it instead
+	member.UserID = existing.UserID
+	member.OrgID = existing.OrgID
+	if err := s.validateMember(ctx, member); err != nil {
+		return err
+	}
+
+	member.CreatedAt = existing.CreatedAt
+	return s.memberRepo.Update(ctx, member)
+}
+
+func (s *memberService) DeleteMember(ctx context.Context, id string) error {
+	if strings.TrimSpace(id) == "" {
+		return errors.New("member ID is required")
+	}
+
+	return s.memberRepo.Delete(ctx, id)
+}
+
+func (s *memberService) ResolveRole(ctx context.Context, orgID, userID string) (auth.Role, error) {
+	member, err := s.memberRepo.GetByOrgAndUser(ctx, orgID, userID)
+	if err != nil || member == nil {
+		return "", err
+	}
+
+	return member.Role, nil
+}
+
+func (s *memberService) validateMember(ctx context.Context, member *models.Member) error {
+	if auth.OrgID(ctx) == "" {
+		return errors.New("authentication is required to manage members")
+	}
+
+	if strings.TrimSpace(member.UserID) == "" {
+		return errors.New("member user ID is required")
+	}
+
+	// Stops owners locking themselves out, and personal organizations always
+	// belong to their user
+	if member.UserID == auth.UserID(ctx) || member.UserID == auth.OrgID(ctx) {
+		return errors.New("you cannot change your own membership")
+	}
+
+	if !member.Role.Valid() {
+		return errors.New("role must be owner, editor or accountant")
+	}
+
+	return nil
+}
@@ -0,0 +1,135 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spalqui/habitattrack-api/internal/models"
+	"github.com/spalqui/habitattrack-api/internal/repositories"
+	"github.com/spalqui/habitattrack-api/pkg/auth"
+)
+
+var (
+	ErrInvalidClient = errors.New("invalid_client")
+	ErrInvalidScope  = errors.New("invalid_scope")
+)
+
+type OAuthClientService interface {
+	CreateClient(ctx context.Context, client *models.OAuthClient) error
+	GetAllClients(ctx context.Context) ([]*models.OAuthClient, error)
+	RevokeClient(ctx context.Context, id string) error
+	IssueToken(ctx context.Context, clientID, clientSecret string, scopes []string) (*models.AccessToken, error)
+}
+
+type oauthClientService struct {
+	clientRepo repositories.OAuthClientRepository
+	tokens     *auth.ClientTokens
+}
+
+func NewOAuthClientService(clientRepo repositories.OAuthClientRepository, tokens *auth.ClientTokens) OAuthClientService {
+	return &oauthClientService{
+		clientRepo: clientRepo,
+		tokens:     tokens,
+	}
+}
+
+// CreateClient registers a machine client for the current organization. The
+// plaintext secret is only set on the returned model.
+func (s *oauthClientService) CreateClient(ctx context.Context, client *models.OAuthClient) error {
+	if auth.OrgID(ctx) == "" {
+		return errors.New("authentication is required to manage OAuth clients")
+	}
+
+	if strings.TrimSpace(client.Name) == "" {
+		return errors.New("client name is required")
+	}
+
+	if len(client.Scopes) == 0 {
+		return errors.New("at least one scope is required")
+	}
+
+	for _, scope := range client.Scopes {
+		if !auth.ValidScope(scope) {
+			return fmt.Errorf("unknown scope: %s", scope)
+		}
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return err
+	}
+
+	client.Secret = base64.RawURLEncoding.EncodeToString(secret)
+	client.SecretHash = hashAPIKey(client.Secret)
+	client.RevokedAt = nil
+	return s.clientRepo.Create(ctx, client)
+}
+
+func (s *oauthClientService) GetAllClients(ctx context.Context) ([]*models.OAuthClient, error) {
+	return s.clientRepo.GetAll(ctx)
+}
+
+func (s *oauthClientService) RevokeClient(ctx context.Context, id string) error {
+	if strings.TrimSpace(id) == "" {
+		return errors.New("client ID is required")
+	}
+
+	client, err := s.clientRepo.GetByID(ctx, id)
+	if err != nil {
+		return errors.New("client not found")
+	}
+
+	if client.RevokedAt != nil {
+		return nil
+	}
+
+	revokedAt := time.Now()
+	client.RevokedAt = &revokedAt
+	return s.clientRepo.Update(ctx, client)
+}
+
+// IssueToken implements the client credentials grant. Requested scopes must
+// be a subset of the client's; none requested means all of them.
+func (s *oauthClientService) IssueToken(ctx context.Context, clientID, clientSecret string, scopes []string) (*models.AccessToken, error) {
+	if clientID == "" || clientSecret == "" {
+		return nil, ErrInvalidClient
+	}
+
+	client, err := s.clientRepo.GetByID(ctx, clientID)
+	if err != nil || client.RevokedAt != nil {
+		return nil, ErrInvalidClient
+	}
+
+	if !hmac.Equal([]byte(client.SecretHash), []byte(hashAPIKey(clientSecret))) {
+		return nil, ErrInvalidClient
+	}
+
+	if len(scopes) == 0 {
+		scopes = client.Scopes
+	}
+
+	for _, scope := range scopes {
+		if !slices.Contains(client.Scopes, scope) {
+			return nil, ErrInvalidScope
+		}
+	}
+
+	token, ttl, err := s.tokens.Issue(client.ID, client.OrgID, scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.AccessToken{
+		AccessToken: token,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(ttl.Seconds()),
+		Scope:       strings.Join(scopes, " "),
+	}, nil
+}
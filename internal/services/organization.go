@@ -0,0 +1,139 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/spalqui/habitattrack-api/internal/models"
+	"github.com/spalqui/habitattrack-api/internal/repositories"
+	"github.com/spalqui/habitattrack-api/pkg/auth"
+)
+
+type OrganizationService interface {
+	CreateOrganization(ctx context.Context, organization *models.Organization) error
+	GetOrganization(ctx context.Context, id string) (*models.Organization, error)
+	GetAllOrganizations(ctx context.Context) ([]*models.Organization, error)
+	UpdateOrganization(ctx context.Context, organization *models.Organization) error
+}
+
+type organizationService struct {
+	organizationRepo repositories.OrganizationRepository
+	memberRepo       repositories.MemberRepository
+}
+
+func NewOrganizationService(organizationRepo repositories.OrganizationRepository, memberRepo repositories.MemberRepository) OrganizationService {
+	return &organizationService{
+		organizationRepo: organizationRepo,
+		memberRepo:       memberRepo,
+	}
+}
+
+// CreateOrganization creates an organization with the caller as its owner.
+func (s *organizationService) CreateOrganization(ctx context.Context, organization *models.Organization) error {
+	userID := auth.UserID(ctx)
+	if userID == "" {
+		return errors.New("authentication is required to manage organizations")
+	}
+
+	if err := validateOrganization(organization); err != nil {
+		return err
+	}
+
+	organization.CreatedBy = userID
+	if err := s.organizationRepo.Create(ctx, organization); err != nil {
+		return err
+	}
+
+	return s.memberRepo.Create(ctx, &models.Member{
+		OrgID:  organization.ID,
+		UserID: userID,
+		Role:   auth.RoleOwner,
+	})
+}
+
+func (s *organizationService) GetOrganization(ctx context.Context, id string) (*models.Organization, error) {
+	if strings.TrimSpace(id) == "" {
+		return nil, errors.New("organization ID is required")
+	}
+
+	if _, err := s.requireRole(ctx, id, auth.PermissionRead); err != nil {
+		return nil, err
+	}
+
+	return s.organizationRepo.GetByID(ctx, id)
+}
+
+// GetAllOrganizations lists the organizations the caller belongs to, not
+// including their personal organization.
+func (s *organizationService) GetAllOrganizations(ctx context.Context) ([]*models.Organization, error) {
+	members, err := s.memberRepo.GetByUserID(ctx, auth.UserID(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	organizations := make([]*models.Organization, 0, len(members))
+	for _, member := range members {
+		organization, err := s.organizationRepo.GetByID(ctx, member.OrgID)
+		if err != nil {
+			return nil, err
+		}
+		organizations = append(organizations, organization)
+	}
+
+	return organizations, nil
+}
+
+func (s *organizationService) UpdateOrganization(ctx context.Context, organization *models.Organization) error {
+	if strings.TrimSpace(organization.ID) == "" {
+		return errors.New("organization ID is required for update")
+	}
+
+	if err := validateOrganization(organization); err != nil {
+		return err
+	}
+
+	if _, err := s.requireRole(ctx, organization.ID, auth.PermissionManage); err != nil {
+		return err
+	}
+
+	existing, err := s.organizationRepo.GetByID(ctx, organization.ID)
+	if err != nil {
+		return errors.New("organization not found")
+	}
+
+	organization.CreatedBy = existing.CreatedBy
+	organization.CreatedAt = existing.CreatedAt
+	return s.organizationRepo.Update(ctx, organization)
+}
+
+func validateOrganization(organization *models.Organization) error {
+	if strings.TrimSpace(organization.Name) == "" {
+		return errors.New("organization name is required")
+	}
+
+	if !organization.FutureDates.Valid() {
+		return errors.New("futureDates must be allow, warn or reject")
+	}
+
+	return nil
+}
+
+// requireRole checks the caller's membership directly, since organization
+// routes name the organization in the path rather than X-Organization-ID.
+func (s *organizationService) requireRole(ctx context.Context, orgID string, permission auth.Permission) (auth.Role, error) {
+	member, err := s.memberRepo.GetByOrgAndUser(ctx, orgID, auth.UserID(ctx))
+	if err != nil {
+		return "", err
+	}
+
+	if member == nil {
+		return "", errors.New("organization not found")
+	}
+
+	if !member.Role.Can(permission) {
+		return "", errors.New("insufficient permissions")
+	}
+
+	return member.Role, nil
+}
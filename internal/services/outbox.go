@@ -0,0 +1,153 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/spalqui/habitattrack-api/internal/models"
+	"github.com/spalqui/habitattrack-api/internal/repositories"
+	"github.com/spalqui/habitattrack-api/pkg/auth"
+)
+
+// EventSink receives events relayed from the outbox. An error leaves the
+// event in the outbox to be sent again later.
+type EventSink interface {
+	Send(ctx context.Context, event *models.Event) error
+}
+
+// OutboxRelay publishes the events repositories queue in the outbox
+// alongside their writes, so a change's notifications survive a crash
+// between the write and its publication. Events are sent at least once:
+// one that fails for any sink is retried for every sink, until maxAttempts
+// failures move it to the dead letters.
+type OutboxRelay interface {
+	RelayPending(ctx context.Context) (int, error)
+	Schedule(ctx context.Context, interval time.Duration)
+}
+
+type outboxRelay struct {
+	outboxRepo     repositories.OutboxRepository
+	deadLetterRepo repositories.DeadLetterRepository
+	sinks          []EventSink
+	batchSize      int
+	lease          time.Duration
+	backoff        time.Duration
+	maxBackoff     time.Duration
+	maxAttempts    int
+}
+
+func NewOutboxRelay(outboxRepo repositories.OutboxRepository, deadLetterRepo repositories.DeadLetterRepository, sinks ...EventSink) OutboxRelay {
+	return &outboxRelay{
+		outboxRepo:     outboxRepo,
+		deadLetterRepo: deadLetterRepo,
+		sinks:          sinks,
+		batchSize:      100,
+		lease:          time.Minute,
+		backoff:        5 * time.Second,
+		maxBackoff:     time.Hour,
+		maxAttempts:    15,
+	}
+}
+
+// RelayPending sends every available event, returning how many were sent.
+func (r *outboxRelay) RelayPending(ctx context.Context) (int, error) {
+	sent := 0
+	for {
+		events, err := r.outboxRepo.Claim(ctx, r.batchSize, r.lease)
+		if err != nil {
+			return sent, err
+		}
+
+		for _, event := range events {
+			if err := r.relay(ctx, event); err != nil {
+				log.Printf("Failed to relay event %s (attempt %d): %v", event.ID, event.Attempts+1, err)
+				if err := r.fail(ctx, event, err); err != nil {
+					return sent, err
+				}
+				continue
+			}
+
+			if err := r.outboxRepo.Delete(ctx, event.ID); err != nil {
+				return sent, err
+			}
+			sent++
+		}
+
+		if len(events) < r.batchSize {
+			return sent, nil
+		}
+	}
+}
+
+// fail schedules the event's next attempt, or moves it to the dead letters
+// once it has none left.
+func (r *outboxRelay) fail(ctx context.Context, event *models.OutboxEvent, cause error) error {
+	if event.Attempts+1 < r.maxAttempts {
+		return r.outboxRepo.Release(ctx, event.ID, time.Now().Add(retryDelay(r.backoff, r.maxBackoff, event.Attempts)), cause)
+	}
+
+	log.Printf("Giving up relaying event %s after %d attempts", event.ID, event.Attempts+1)
+	err := r.deadLetterRepo.Create(ctx, &models.DeadLetter{
+		Kind:      models.DeadLetterEvent,
+		OrgID:     event.OrgID,
+		EventID:   event.ID,
+		EventType: event.Type,
+		Payload:   event.Payload,
+		Attempts:  event.Attempts + 1,
+		LastError: cause.Error(),
+		EventAt:   event.CreatedAt,
+	})
+	if err != nil {
+		return err
+	}
+	return r.outboxRepo.Delete(ctx, event.ID)
+}
+
+// relay sends the event on behalf of the organization it belongs to, so
+// sinks find that organization's subscribers.
+func (r *outboxRelay) relay(ctx context.Context, outboxEvent *models.OutboxEvent) error {
+	if outboxEvent.OrgID != "" {
+		ctx = auth.WithPrincipal(ctx, &auth.Principal{OrgID: outboxEvent.OrgID})
+	}
+
+	event := &models.Event{
+		ID:        outboxEvent.ID,
+		Type:      outboxEvent.Type,
+		Data:      json.RawMessage(outboxEvent.Payload),
+		CreatedAt: outboxEvent.CreatedAt,
+	}
+	for _, sink := range r.sinks {
+		if err := sink.Send(ctx, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// retryDelay doubles backoff with each failed attempt, up to maxBackoff.
+func retryDelay(backoff, maxBackoff time.Duration, attempts int) time.Duration {
+	delay := backoff
+	for i := 0; i < attempts && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, maxBackoff)
+}
+
+// Schedule relays pending events every interval until ctx is done.
+func (r *outboxRelay) Schedule(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := r.RelayPending(ctx); err != nil {
+				log.Printf("Outbox relay failed: %v", err)
+			}
+		}
+	}
+}
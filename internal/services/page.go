@@ -0,0 +1,48 @@
+package services
+
+import (
+	"context"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/spalqui/habitattrack-api/internal/models"
+)
+
+// listPage fetches a page and its total concurrently, so a paged list costs
+// one round trip instead of two. The total is skipped, counted exactly or
+// estimated as the page request asks; without an estimate, estimated totals
+// are counted exactly.
+func listPage[T any](ctx context.Context, page models.PageRequest, list func(ctx context.Context) (*models.Page[T], error), count, estimate func(ctx context.Context) (int64, error)) (*models.Page[T], error) {
+	if page.Totals == models.TotalsNone {
+		return list(ctx)
+	}
+
+	estimated := page.Totals == models.TotalsEstimate && estimate != nil
+	if estimated {
+		count = estimate
+	}
+
+	group, ctx := errgroup.WithContext(ctx)
+
+	var result *models.Page[T]
+	group.Go(func() error {
+		var err error
+		result, err = list(ctx)
+		return err
+	})
+
+	var total int64
+	group.Go(func() error {
+		var err error
+		total, err = count(ctx)
+		return err
+	})
+
+	if err := group.Wait(); err != nil {
+		return nil, err
+	}
+
+	result.TotalItems = &total
+	result.TotalsEstimated = estimated
+	return result, nil
+}
@@ -3,27 +3,48 @@ package services
 import (
 	"context"
 	"errors"
+	"fmt"
 	"strings"
+	"time"
 
 	"github.com/spalqui/habitattrack-api/internal/models"
 	"github.com/spalqui/habitattrack-api/internal/repositories"
+	"github.com/spalqui/habitattrack-api/pkg/sanitize"
 )
 
 type PropertyService interface {
 	CreateProperty(ctx context.Context, property *models.Property) error
 	GetProperty(ctx context.Context, id string) (*models.Property, error)
+	GetPropertiesByIDs(ctx context.Context, ids []string) (map[string]*models.Property, error)
 	GetAllProperties(ctx context.Context) ([]*models.Property, error)
+	CountProperties(ctx context.Context) (int64, error)
+	GetPropertyTotals(ctx context.Context, id string) (*models.PropertyTotals, error)
+	ListPropertiesPage(ctx context.Context, page models.PageRequest) (*models.Page[*models.Property], error)
+	CloneProperty(ctx context.Context, id string) (*models.Property, error)
+	ValidateProperty(ctx context.Context, property *models.Property) error
 	UpdateProperty(ctx context.Context, property *models.Property) error
-	DeleteProperty(ctx context.Context, id string) error
+	PatchProperty(ctx context.Context, id string, patch models.PropertyPatch) (*models.Property, error)
+	DeleteProperty(ctx context.Context, id string, options models.PropertyDeleteOptions) error
 }
 
 type propertyService struct {
-	propertyRepo repositories.PropertyRepository
+	propertyRepo    repositories.PropertyRepository
+	transactionRepo repositories.TransactionRepository
+	publisher       EventPublisher
+	text            sanitize.Policy
 }
 
-func NewPropertyService(propertyRepo repositories.PropertyRepository) PropertyService {
+func NewPropertyService(
+	propertyRepo repositories.PropertyRepository,
+	transactionRepo repositories.TransactionRepository,
+	publisher EventPublisher,
+	text sanitize.Policy,
+) PropertyService {
 	return &propertyService{
-		propertyRepo: propertyRepo,
+		propertyRepo:    propertyRepo,
+		transactionRepo: transactionRepo,
+		publisher:       publisher,
+		text:            text,
 	}
 }
 
@@ -32,48 +53,282 @@ func (s *propertyService) CreateProperty(ctx context.Context, property *models.P
 		return err
 	}
 
-	return s.propertyRepo.Create(ctx, property)
+	// The repository reserves the name as it creates the property
+	if err := s.propertyRepo.Create(ctx, property); err != nil {
+		return err
+	}
+
+	s.publisher.Publish(ctx, models.EventPropertyCreated, property)
+	return nil
 }
 
 func (s *propertyService) GetProperty(ctx context.Context, id string) (*models.Property, error) {
 	if strings.TrimSpace(id) == "" {
-		return nil, errors.New("property ID is required")
+		return nil, invalid("property ID is required")
 	}
 
 	return s.propertyRepo.GetByID(ctx, id)
 }
 
+// GetPropertiesByIDs reads the properties in one round trip, keyed by ID.
+// IDs that don't exist are left out.
+func (s *propertyService) GetPropertiesByIDs(ctx context.Context, ids []string) (map[string]*models.Property, error) {
+	return s.propertyRepo.GetByIDs(ctx, ids)
+}
+
 func (s *propertyService) GetAllProperties(ctx context.Context) ([]*models.Property, error) {
 	return s.propertyRepo.GetAll(ctx)
 }
 
+func (s *propertyService) GetPropertyTotals(ctx context.Context, id string) (*models.PropertyTotals, error) {
+	if strings.TrimSpace(id) == "" {
+		return nil, invalid("property ID is required")
+	}
+
+	return s.transactionRepo.GetPropertyTotals(ctx, id)
+}
+
+func (s *propertyService) CountProperties(ctx context.Context) (int64, error) {
+	return s.propertyRepo.Count(ctx)
+}
+
+// ListPropertiesPage returns one page of properties. The total comes from an
+// aggregation query, so it costs a single read however many properties match.
+func (s *propertyService) ListPropertiesPage(ctx context.Context, page models.PageRequest) (*models.Page[*models.Property], error) {
+	return listPage(ctx, page, func(ctx context.Context) (*models.Page[*models.Property], error) {
+		return s.propertyRepo.ListPage(ctx, page)
+	}, s.propertyRepo.Count, nil)
+}
+
+// maxCloneCopies bounds the numbered names tried for a clone, "(copy)",
+// "(copy 2)" and so on.
+const maxCloneCopies = 100
+
+// CloneProperty copies a property's details into a new property, named as
+// the first free copy of the original. Properties have no units, recurring
+// templates or budgets yet, so there is nothing else to carry over.
+func (s *propertyService) CloneProperty(ctx context.Context, id string) (*models.Property, error) {
+	original, err := s.GetProperty(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	for copies := 1; copies <= maxCloneCopies; copies++ {
+		name := original.DisplayName() + " (copy)"
+		if copies > 1 {
+			name = fmt.Sprintf("%s (copy %d)", original.DisplayName(), copies)
+		}
+
+		clone := &models.Property{
+			Name:        name,
+			Address:     original.Address,
+			Postcode:    original.Postcode,
+			Description: original.Description,
+			Timezone:    original.Timezone,
+		}
+
+		err := s.CreateProperty(ctx, clone)
+		if errors.Is(err, repositories.ErrPropertyNameExists) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return clone, nil
+	}
+
+	return nil, repositories.ErrPropertyNameExists
+}
+
+func (s *propertyService) ValidateProperty(ctx context.Context, property *models.Property) error {
+	return s.validateProperty(property)
+}
+
 func (s *propertyService) UpdateProperty(ctx context.Context, property *models.Property) error {
 	if err := s.validateProperty(property); err != nil {
 		return err
 	}
 
 	if strings.TrimSpace(property.ID) == "" {
-		return errors.New("property ID is required for update")
+		return invalid("property ID is required for update")
+	}
+
+	existing, err := s.propertyRepo.GetByID(ctx, property.ID)
+	if err != nil {
+		return err
+	}
+
+	property.CreatedAt = existing.CreatedAt
+	// Without a version from the caller, the update applies to the one read here
+	if property.UpdatedAt.IsZero() {
+		property.UpdatedAt = existing.UpdatedAt
+	}
+	if err := s.propertyRepo.Update(ctx, property); err != nil {
+		return err
+	}
+
+	if property.DisplayName() != existing.DisplayName() {
+		if err := s.transactionRepo.UpdatePropertyName(ctx, property.ID, property.DisplayName()); err != nil {
+			return err
+		}
 	}
 
-	return s.propertyRepo.Update(ctx, property)
+	if property.Timezone != existing.Timezone {
+		if err := s.moveToTimezone(ctx, property); err != nil {
+			return err
+		}
+	}
+
+	s.publisher.Publish(ctx, models.EventPropertyUpdated, property)
+	return nil
 }
 
-func (s *propertyService) DeleteProperty(ctx context.Context, id string) error {
+// PatchProperty applies a partial update to the property. Clearing its
+// timezone puts its transactions back in UTC.
+func (s *propertyService) PatchProperty(ctx context.Context, id string, patch models.PropertyPatch) (*models.Property, error) {
+	property, err := s.GetProperty(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, err := range []error{
+		applyRequired("address", patch.Address, &property.Address),
+		applyRequired("postcode", patch.Postcode, &property.Postcode),
+	} {
+		if err != nil {
+			return nil, err
+		}
+	}
+	patch.Name.ApplyTo(&property.Name)
+	patch.Description.ApplyTo(&property.Description)
+	patch.Timezone.ApplyTo(&property.Timezone)
+
+	if err := s.UpdateProperty(ctx, property); err != nil {
+		return nil, err
+	}
+
+	return property, nil
+}
+
+// DeleteProperty refuses to delete a property that transactions still refer
+// to, unless they're reassigned to another property or deleted with it.
+func (s *propertyService) DeleteProperty(ctx context.Context, id string, options models.PropertyDeleteOptions) error {
 	if strings.TrimSpace(id) == "" {
-		return errors.New("property ID is required")
+		return invalid("property ID is required")
+	}
+
+	if options.ReassignTo != "" && options.Cascade {
+		return invalid("reassignTo and cascade cannot be combined")
+	}
+
+	if options.ReassignTo != "" || options.Cascade {
+		if err := s.handlePropertyTransactions(ctx, id, options); err != nil {
+			return err
+		}
+	} else {
+		count, err := s.transactionRepo.Count(ctx, models.TransactionFilter{PropertyID: id})
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			return &InUseError{Resource: "property", Dependents: map[string]int64{"transactions": count}}
+		}
 	}
 
-	return s.propertyRepo.Delete(ctx, id)
+	if err := s.propertyRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	s.publisher.Publish(ctx, models.EventPropertyDeleted, map[string]string{"id": id})
+	return nil
+}
+
+func (s *propertyService) handlePropertyTransactions(ctx context.Context, id string, options models.PropertyDeleteOptions) error {
+	var target *models.Property
+	if options.ReassignTo != "" {
+		if options.ReassignTo == id {
+			return invalid("cannot reassign transactions to the property being deleted")
+		}
+
+		var err error
+		target, err = s.propertyRepo.GetByID(ctx, options.ReassignTo)
+		if errors.Is(err, repositories.ErrPropertyNotFound) {
+			return invalid("target property not found")
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	transactions, err := s.transactionRepo.List(ctx, models.TransactionFilter{PropertyID: id})
+	if err != nil {
+		return err
+	}
+
+	ids := make([]string, len(transactions))
+	for i, transaction := range transactions {
+		ids[i] = transaction.ID
+	}
+
+	if target != nil {
+		updated, err := s.transactionRepo.ReassignProperty(ctx, ids, target.ID, target.DisplayName(), target.Timezone)
+		for _, transaction := range transactions[:updated] {
+			transaction.PropertyID = target.ID
+			transaction.PropertyName = target.DisplayName()
+			transaction.Timezone = target.Timezone
+			s.publisher.Publish(ctx, models.EventTransactionUpdated, transaction)
+		}
+		return err
+	}
+
+	deleted, err := s.transactionRepo.DeleteMany(ctx, ids)
+	for _, transaction := range transactions[:deleted] {
+		s.publisher.Publish(ctx, models.EventTransactionDeleted, map[string]string{"id": transaction.ID})
+	}
+	return err
+}
+
+// moveToTimezone re-buckets the property's transactions into the months
+// their dates fall in within the property's new time zone.
+func (s *propertyService) moveToTimezone(ctx context.Context, property *models.Property) error {
+	transactions, err := s.transactionRepo.List(ctx, models.TransactionFilter{PropertyID: property.ID})
+	if err != nil {
+		return err
+	}
+
+	ids := make([]string, len(transactions))
+	for i, transaction := range transactions {
+		ids[i] = transaction.ID
+	}
+
+	_, err = s.transactionRepo.SetTimezone(ctx, ids, property.Timezone)
+	return err
 }
 
 func (s *propertyService) validateProperty(property *models.Property) error {
+	for _, err := range []error{
+		s.text.Name("name", &property.Name),
+		s.text.Name("address", &property.Address),
+		s.text.Name("postcode", &property.Postcode),
+		s.text.Description("description", &property.Description),
+	} {
+		if err != nil {
+			return invalid(err.Error())
+		}
+	}
+
 	if strings.TrimSpace(property.Address) == "" {
-		return errors.New("address is required")
+		return invalid("address is required")
 	}
 
 	if strings.TrimSpace(property.Postcode) == "" {
-		return errors.New("postcode is required")
+		return invalid("postcode is required")
+	}
+
+	if property.Timezone != "" {
+		if _, err := time.LoadLocation(property.Timezone); err != nil || property.Timezone == "Local" {
+			return invalid("timezone must be an IANA time zone name such as Europe/London")
+		}
 	}
 
 	return nil
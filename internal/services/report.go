@@ -0,0 +1,68 @@
+package services
+
+import (
+	"context"
+	"log"
+
+	"github.com/spalqui/habitattrack-api/internal/models"
+	"github.com/spalqui/habitattrack-api/internal/repositories"
+)
+
+type ReportService interface {
+	GetMonthlyReport(ctx context.Context, filter models.RollupFilter) ([]*models.MonthlySummary, error)
+	RebuildRollups(ctx context.Context) (int, error)
+	RunScheduled(ctx context.Context) error
+}
+
+type reportService struct {
+	rollupRepo repositories.RollupRepository
+}
+
+// NewReportService builds reports from the monthly rollups, so their cost
+// grows with the number of months, properties and categories rather than
+// transactions.
+func NewReportService(rollupRepo repositories.RollupRepository) ReportService {
+	return &reportService{
+		rollupRepo: rollupRepo,
+	}
+}
+
+// GetMonthlyReport totals each month that has transactions matching the
+// filter, in month order.
+func (s *reportService) GetMonthlyReport(ctx context.Context, filter models.RollupFilter) ([]*models.MonthlySummary, error) {
+	if filter.From != "" && filter.To != "" && filter.From > filter.To {
+		return nil, &FieldError{Field: "to", Message: "to must not be before from"}
+	}
+
+	rollups, err := s.rollupRepo.List(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := make([]*models.MonthlySummary, 0)
+	for _, rollup := range rollups {
+		if len(summaries) == 0 || summaries[len(summaries)-1].Month != rollup.Month {
+			summaries = append(summaries, &models.MonthlySummary{Month: rollup.Month, Rollups: []*models.MonthlyRollup{}})
+		}
+
+		summaries[len(summaries)-1].Add(rollup)
+	}
+
+	return summaries, nil
+}
+
+func (s *reportService) RebuildRollups(ctx context.Context) (int, error) {
+	return s.rollupRepo.Rebuild(ctx)
+}
+
+// RunScheduled rebuilds the rollups, correcting any drift left by bulk
+// writes that failed part way.
+func (s *reportService) RunScheduled(ctx context.Context) error {
+	rebuilt, err := s.RebuildRollups(ctx)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Rebuilt %d rollups", rebuilt)
+	return nil
+}
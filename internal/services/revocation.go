@@ -0,0 +1,124 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spalqui/habitattrack-api/internal/models"
+	"github.com/spalqui/habitattrack-api/internal/repositories"
+	"github.com/spalqui/habitattrack-api/pkg/auth"
+)
+
+// revocationCacheTTL bounds how long other instances keep accepting a token
+// after it is revoked, in exchange for not reading Firestore on every request.
+const revocationCacheTTL = 30 * time.Second
+
+type RevocationService interface {
+	auth.RevocationChecker
+	RevokeUserSessions(ctx context.Context, userID, reason string) (*models.Revocation, error)
+	RevokeToken(ctx context.Context, tokenID, reason string) (*models.Revocation, error)
+	GetAllRevocations(ctx context.Context) ([]*models.Revocation, error)
+}
+
+type revocationService struct {
+	revocationRepo repositories.RevocationRepository
+
+	mu    sync.Mutex
+	cache map[string]cachedRevocation
+}
+
+type cachedRevocation struct {
+	revocation *models.Revocation
+	expires    time.Time
+}
+
+func NewRevocationService(revocationRepo repositories.RevocationRepository) RevocationService {
+	return &revocationService{
+		revocationRepo: revocationRepo,
+		cache:          make(map[string]cachedRevocation),
+	}
+}
+
+// RevokeUserSessions signs the user out everywhere: tokens from any sign-in
+// before now are rejected, and the user has to sign in again.
+func (s *revocationService) RevokeUserSessions(ctx context.Context, userID, reason string) (*models.Revocation, error) {
+	if strings.TrimSpace(userID) == "" {
+		return nil, errors.New("user ID is required")
+	}
+
+	return s.save(ctx, &models.Revocation{UserID: userID, Reason: reason})
+}
+
+func (s *revocationService) RevokeToken(ctx context.Context, tokenID, reason string) (*models.Revocation, error) {
+	if strings.TrimSpace(tokenID) == "" {
+		return nil, errors.New("token ID is required")
+	}
+
+	return s.save(ctx, &models.Revocation{TokenID: tokenID, Reason: reason})
+}
+
+func (s *revocationService) save(ctx context.Context, revocation *models.Revocation) (*models.Revocation, error) {
+	revocation.RevokedBefore = time.Now()
+	revocation.RevokedBy = auth.UserID(ctx)
+
+	if err := s.revocationRepo.Save(ctx, revocation); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	delete(s.cache, revocation.ID)
+	s.mu.Unlock()
+
+	return revocation, nil
+}
+
+func (s *revocationService) GetAllRevocations(ctx context.Context) ([]*models.Revocation, error) {
+	return s.revocationRepo.GetAll(ctx)
+}
+
+func (s *revocationService) IsRevoked(ctx context.Context, principal *auth.Principal) (bool, error) {
+	if principal.TokenID != "" {
+		revocation, err := s.lookup(ctx, "token:"+principal.TokenID, s.revocationRepo.GetByTokenID, principal.TokenID)
+		if err != nil {
+			return false, err
+		}
+		if revocation != nil {
+			return true, nil
+		}
+	}
+
+	if principal.UserID != "" {
+		revocation, err := s.lookup(ctx, "user:"+principal.UserID, s.revocationRepo.GetByUserID, principal.UserID)
+		if err != nil {
+			return false, err
+		}
+		if revocation != nil && principal.IssuedAt.Before(revocation.RevokedBefore) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (s *revocationService) lookup(ctx context.Context, key string, get func(context.Context, string) (*models.Revocation, error), id string) (*models.Revocation, error) {
+	s.mu.Lock()
+	cached, ok := s.cache[key]
+	s.mu.Unlock()
+	if ok && time.Now().Before(cached.expires) {
+		return cached.revocation, nil
+	}
+
+	revocation, err := get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.cache[key] = cachedRevocation{revocation: revocation, expires: time.Now().Add(revocationCacheTTL)}
+	s.mu.Unlock()
+
+	return revocation, nil
+}
@@ -2,51 +2,282 @@ package services
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"log"
 	"strings"
+	"time"
 
 	"github.com/spalqui/habitattrack-api/internal/models"
 	"github.com/spalqui/habitattrack-api/internal/repositories"
+	"github.com/spalqui/habitattrack-api/pkg/auth"
+	"github.com/spalqui/habitattrack-api/pkg/sanitize"
+	"github.com/spalqui/habitattrack-api/pkg/tasks"
 )
 
 type TransactionService interface {
 	CreateTransaction(ctx context.Context, transaction *models.Transaction) error
+	CreateTransactions(ctx context.Context, transactions []*models.Transaction) (*models.BulkWriteResult, error)
+	Warnings(ctx context.Context, transaction *models.Transaction) ([]models.Warning, error)
 	GetTransaction(ctx context.Context, id string) (*models.Transaction, error)
 	GetTransactionsByProperty(ctx context.Context, propertyID string) ([]*models.Transaction, error)
 	GetAllTransactions(ctx context.Context) ([]*models.Transaction, error)
+	ListTransactions(ctx context.Context, filter models.TransactionFilter) ([]*models.Transaction, error)
+	CountTransactions(ctx context.Context, filter models.TransactionFilter) (int64, error)
+	ListTransactionsPage(ctx context.Context, filter models.TransactionFilter, page models.PageRequest) (*models.Page[*models.Transaction], error)
+	ExportTransactions(ctx context.Context, filter models.TransactionFilter, fn func(transactions []*models.Transaction) error) error
+	DuplicateTransaction(ctx context.Context, id string, overrides models.TransactionOverrides) (*models.Transaction, error)
+	ValidateTransaction(ctx context.Context, transaction *models.Transaction) error
 	UpdateTransaction(ctx context.Context, transaction *models.Transaction) error
+	PatchTransaction(ctx context.Context, id string, patch models.TransactionPatch) (*models.Transaction, error)
+	UpsertExternalTransaction(ctx context.Context, transaction *models.Transaction) (bool, error)
 	DeleteTransaction(ctx context.Context, id string) error
+	ListDeletedTransactions(ctx context.Context) ([]*models.Transaction, error)
+	PurgeDeletedTransactions(ctx context.Context, olderThan time.Time) (int, error)
+	QueuePurgeDeletedTransactions(ctx context.Context, olderThan time.Time) error
+	ReassignCategory(ctx context.Context, filter models.TransactionFilter, targetCategoryID string, dryRun bool) (*models.CategoryReassignmentResult, error)
 }
 
 type transactionService struct {
 	transactionRepo repositories.TransactionRepository
 	categoryRepo    repositories.CategoryRepository
 	propertyRepo    repositories.PropertyRepository
+	orgRepo         repositories.OrganizationRepository
+	publisher       EventPublisher
+	queue           tasks.Queue
+	maxDateRange    time.Duration
+	text            sanitize.Policy
 }
 
 func NewTransactionService(
 	transactionRepo repositories.TransactionRepository,
 	categoryRepo repositories.CategoryRepository,
 	propertyRepo repositories.PropertyRepository,
+	orgRepo repositories.OrganizationRepository,
+	publisher EventPublisher,
+	queue tasks.Queue,
+	maxDateRange time.Duration,
+	text sanitize.Policy,
 ) TransactionService {
 	return &transactionService{
 		transactionRepo: transactionRepo,
 		categoryRepo:    categoryRepo,
 		propertyRepo:    propertyRepo,
+		orgRepo:         orgRepo,
+		publisher:       publisher,
+		queue:           queue,
+		maxDateRange:    maxDateRange,
+		text:            text,
 	}
 }
 
+// TaskPurgeDeletedTransactions purges soft-deleted transactions in the
+// background.
+const TaskPurgeDeletedTransactions = "transactions.purge"
+
+type purgeTask struct {
+	OlderThan time.Time `json:"olderThan"`
+}
+
+// RegisterTransactionTasks registers the handlers for the tasks the
+// transaction service queues.
+func RegisterTransactionTasks(registry *tasks.Registry, service TransactionService) {
+	registry.Register(TaskPurgeDeletedTransactions, func(ctx context.Context, payload json.RawMessage) error {
+		var task purgeTask
+		if err := json.Unmarshal(payload, &task); err != nil {
+			return err
+		}
+
+		purged, err := service.PurgeDeletedTransactions(ctx, task.OlderThan)
+		if err != nil {
+			return err
+		}
+		log.Printf("Purged %d deleted transactions", purged)
+		return nil
+	})
+}
+
 func (s *transactionService) CreateTransaction(ctx context.Context, transaction *models.Transaction) error {
 	if err := s.validateTransaction(ctx, transaction); err != nil {
 		return err
 	}
 
-	return s.transactionRepo.Create(ctx, transaction)
+	if err := s.transactionRepo.Create(ctx, transaction); err != nil {
+		return err
+	}
+
+	s.publisher.Publish(ctx, models.EventTransactionCreated, transaction)
+	return nil
+}
+
+// duplicateWindow is how far apart two transactions' dates can be for them
+// to look like duplicates.
+const duplicateWindow = 3 * 24 * time.Hour
+
+// Warnings notes what looks wrong with a transaction without being a reason
+// to refuse it: other transactions for the same property and amount within
+// duplicateWindow of its date, since repeats can be legitimate, and a future
+// date where the organization asks to be warned.
+func (s *transactionService) Warnings(ctx context.Context, transaction *models.Transaction) ([]models.Warning, error) {
+	var warnings []models.Warning
+
+	policy, err := s.futureDatePolicy(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if policy == models.FutureDatesWarn && isFutureDated(transaction) {
+		warnings = append(warnings, models.Warning{
+			Code:    models.WarningFutureDate,
+			Message: "the transaction is dated in the future",
+		})
+	}
+
+	filter := models.TransactionFilter{
+		PropertyID: transaction.PropertyID,
+		StartDate:  transaction.Date.Add(-duplicateWindow),
+		EndDate:    transaction.Date.Add(duplicateWindow),
+	}
+	if transaction.PropertyID == "" {
+		hasProperty := false
+		filter.HasProperty = &hasProperty
+	}
+	similar, err := s.transactionRepo.List(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []string
+	for _, other := range similar {
+		if other.ID != transaction.ID && other.Amount == transaction.Amount {
+			ids = append(ids, other.ID)
+		}
+	}
+	if len(ids) > 0 {
+		warnings = append(warnings, models.Warning{
+			Code:           models.WarningPossibleDuplicate,
+			Message:        "a similar transaction already exists",
+			TransactionIDs: ids,
+		})
+	}
+
+	return warnings, nil
+}
+
+// futureDatePolicy is the caller's organization's policy for future-dated
+// transactions. Personal workspaces have no organization and allow them.
+func (s *transactionService) futureDatePolicy(ctx context.Context) (models.FutureDatePolicy, error) {
+	orgID := auth.OrgID(ctx)
+	if orgID == "" {
+		return models.FutureDatesAllow, nil
+	}
+
+	organization, err := s.orgRepo.GetByID(ctx, orgID)
+	if errors.Is(err, repositories.ErrOrganizationNotFound) {
+		return models.FutureDatesAllow, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return organization.FutureDates, nil
+}
+
+// checkFutureDate applies the policy to the transaction.
+func checkFutureDate(transaction *models.Transaction, policy models.FutureDatePolicy) error {
+	if policy == models.FutureDatesReject && isFutureDated(transaction) {
+		return ErrFutureDate
+	}
+	return nil
+}
+
+// isFutureDated reports whether the transaction is dated after today where
+// its property is.
+func isFutureDated(transaction *models.Transaction) bool {
+	location := transaction.Location()
+	year, month, day := time.Now().In(location).Date()
+	return !transaction.Date.Before(time.Date(year, month, day+1, 0, 0, 0, 0, location))
+}
+
+// maxBulkTransactions bounds the size of one bulk create request.
+const maxBulkTransactions = 500
+
+// CreateTransactions creates the valid transactions and reports the outcome
+// of each one; an invalid transaction doesn't stop the others.
+func (s *transactionService) CreateTransactions(ctx context.Context, transactions []*models.Transaction) (*models.BulkWriteResult, error) {
+	if len(transactions) == 0 {
+		return nil, invalid("at least one transaction is required")
+	}
+
+	if len(transactions) > maxBulkTransactions {
+		return nil, invalid("at most 500 transactions can be created at once")
+	}
+
+	policy, err := s.futureDatePolicy(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	errs := make([]error, len(transactions))
+	var propertyIDs, categoryIDs []string
+	for i, transaction := range transactions {
+		if transaction == nil {
+			errs[i] = invalid("transaction is required")
+			continue
+		}
+		if errs[i] = s.validateTransactionFields(transaction); errs[i] == nil {
+			propertyIDs = append(propertyIDs, transaction.PropertyID)
+			categoryIDs = append(categoryIDs, transaction.CategoryID)
+		}
+	}
+
+	// Every referenced property and category is read in one round trip each
+	properties, err := s.propertyRepo.GetByIDs(ctx, propertyIDs)
+	if err != nil {
+		return nil, err
+	}
+	categories, err := s.categoryRepo.GetByIDs(ctx, categoryIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.BulkWriteResult{}
+	var valid []*models.Transaction
+	var positions []int
+	for i, transaction := range transactions {
+		if errs[i] == nil {
+			errs[i] = applyReferences(transaction, properties[transaction.PropertyID], categories[transaction.CategoryID])
+		}
+		if errs[i] == nil {
+			errs[i] = checkFutureDate(transaction, policy)
+		}
+		if errs[i] != nil {
+			result.Add(i, "", errs[i])
+			continue
+		}
+		valid = append(valid, transaction)
+		positions = append(positions, i)
+	}
+
+	if len(valid) > 0 {
+		written, err := s.transactionRepo.CreateMany(ctx, valid)
+		if err != nil {
+			return nil, err
+		}
+		result.Merge(written, positions)
+	}
+
+	for _, transaction := range valid {
+		if transaction.ID != "" {
+			s.publisher.Publish(ctx, models.EventTransactionCreated, transaction)
+		}
+	}
+
+	return result, nil
 }
 
 func (s *transactionService) GetTransaction(ctx context.Context, id string) (*models.Transaction, error) {
 	if strings.TrimSpace(id) == "" {
-		return nil, errors.New("transaction ID is required")
+		return nil, invalid("transaction ID is required")
 	}
 
 	return s.transactionRepo.GetByID(ctx, id)
@@ -54,7 +285,7 @@ func (s *transactionService) GetTransaction(ctx context.Context, id string) (*mo
 
 func (s *transactionService) GetTransactionsByProperty(ctx context.Context, propertyID string) ([]*models.Transaction, error) {
 	if strings.TrimSpace(propertyID) == "" {
-		return nil, errors.New("property ID is required")
+		return nil, invalid("property ID is required")
 	}
 
 	return s.transactionRepo.GetByPropertyID(ctx, propertyID)
@@ -64,57 +295,413 @@ func (s *transactionService) GetAllTransactions(ctx context.Context) ([]*models.
 	return s.transactionRepo.GetAll(ctx)
 }
 
+// checkQuery validates the filter of a listing or count, refusing date
+// ranges wider than maxDateRange, when it's set. A missing end date runs to
+// now and a missing start date from the beginning of time, so a query
+// without a start date is always too wide.
+func (s *transactionService) checkQuery(filter models.TransactionFilter) error {
+	if err := ValidateTransactionFilter(filter); err != nil {
+		return err
+	}
+
+	if s.maxDateRange <= 0 {
+		return nil
+	}
+
+	days := int(s.maxDateRange / (24 * time.Hour))
+	if filter.StartDate.IsZero() {
+		return &LimitError{Field: "startDate", Message: fmt.Sprintf("a startDate is required; the date range can span at most %d days", days)}
+	}
+
+	endDate := filter.EndDate
+	if endDate.IsZero() {
+		endDate = time.Now()
+	}
+	if endDate.Sub(filter.StartDate) > s.maxDateRange {
+		return &LimitError{Field: "endDate", Message: fmt.Sprintf("the date range can span at most %d days", days)}
+	}
+	return nil
+}
+
+func (s *transactionService) ListTransactions(ctx context.Context, filter models.TransactionFilter) ([]*models.Transaction, error) {
+	if err := s.checkQuery(filter); err != nil {
+		return nil, err
+	}
+
+	return s.transactionRepo.List(ctx, filter)
+}
+
+// exportPageSize is how many transactions an export reads from Firestore at
+// a time.
+const exportPageSize = 500
+
+// ExportTransactions passes every matching transaction to fn, a page at a
+// time, for exports too large to load at once.
+func (s *transactionService) ExportTransactions(ctx context.Context, filter models.TransactionFilter, fn func(transactions []*models.Transaction) error) error {
+	if err := ValidateTransactionFilter(filter); err != nil {
+		return err
+	}
+
+	return s.transactionRepo.Stream(ctx, filter, exportPageSize, fn)
+}
+
+func (s *transactionService) CountTransactions(ctx context.Context, filter models.TransactionFilter) (int64, error) {
+	if err := s.checkQuery(filter); err != nil {
+		return 0, err
+	}
+
+	return s.transactionRepo.Count(ctx, filter)
+}
+
+// ListTransactionsPage returns one page of matching transactions. The total
+// comes from an aggregation query rather than reading every match, or when
+// estimated, from the monthly rollups.
+func (s *transactionService) ListTransactionsPage(ctx context.Context, filter models.TransactionFilter, page models.PageRequest) (*models.Page[*models.Transaction], error) {
+	if err := s.checkQuery(filter); err != nil {
+		return nil, err
+	}
+
+	return listPage(ctx, page, func(ctx context.Context) (*models.Page[*models.Transaction], error) {
+		return s.transactionRepo.ListPage(ctx, filter, page)
+	}, func(ctx context.Context) (int64, error) {
+		return s.transactionRepo.Count(ctx, filter)
+	}, func(ctx context.Context) (int64, error) {
+		return s.transactionRepo.EstimateCount(ctx, filter)
+	})
+}
+
+func (s *transactionService) DuplicateTransaction(ctx context.Context, id string, overrides models.TransactionOverrides) (*models.Transaction, error) {
+	original, err := s.GetTransaction(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	duplicate := &models.Transaction{
+		PropertyID:  original.PropertyID,
+		Type:        original.Type,
+		CategoryID:  original.CategoryID,
+		Amount:      original.Amount,
+		Description: original.Description,
+		Date:        original.Date,
+	}
+
+	if overrides.Date != nil {
+		duplicate.Date = *overrides.Date
+	}
+
+	if overrides.Amount != nil {
+		duplicate.Amount = *overrides.Amount
+	}
+
+	if err := s.CreateTransaction(ctx, duplicate); err != nil {
+		return nil, err
+	}
+
+	return duplicate, nil
+}
+
+func (s *transactionService) ValidateTransaction(ctx context.Context, transaction *models.Transaction) error {
+	return s.validateTransaction(ctx, transaction)
+}
+
 func (s *transactionService) UpdateTransaction(ctx context.Context, transaction *models.Transaction) error {
 	if err := s.validateTransaction(ctx, transaction); err != nil {
 		return err
 	}
 
 	if strings.TrimSpace(transaction.ID) == "" {
-		return errors.New("transaction ID is required for update")
+		return invalid("transaction ID is required for update")
+	}
+
+	existing, err := s.transactionRepo.GetByID(ctx, transaction.ID)
+	if err != nil {
+		return err
 	}
 
-	return s.transactionRepo.Update(ctx, transaction)
+	// External references are fixed once recorded so the unique index stays valid
+	transaction.Source = existing.Source
+	transaction.ExternalID = existing.ExternalID
+	transaction.CreatedAt = existing.CreatedAt
+	// Without a version from the caller, the update applies to the one read here
+	if transaction.UpdatedAt.IsZero() {
+		transaction.UpdatedAt = existing.UpdatedAt
+	}
+
+	if err := s.transactionRepo.Update(ctx, transaction); err != nil {
+		return err
+	}
+
+	s.publisher.Publish(ctx, models.EventTransactionUpdated, transaction)
+	return nil
+}
+
+// PatchTransaction applies a partial update to the transaction. Its
+// propertyId can be changed, or cleared with null to make it a general
+// transaction that belongs to no property.
+func (s *transactionService) PatchTransaction(ctx context.Context, id string, patch models.TransactionPatch) (*models.Transaction, error) {
+	transaction, err := s.GetTransaction(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, err := range []error{
+		applyRequired("type", patch.Type, &transaction.Type),
+		applyRequired("categoryId", patch.CategoryID, &transaction.CategoryID),
+		applyRequired("amount", patch.Amount, &transaction.Amount),
+		applyRequired("date", patch.Date, &transaction.Date),
+	} {
+		if err != nil {
+			return nil, err
+		}
+	}
+	// Clearing the property makes it a general transaction
+	patch.PropertyID.ApplyTo(&transaction.PropertyID)
+	patch.Description.ApplyTo(&transaction.Description)
+
+	if err := s.UpdateTransaction(ctx, transaction); err != nil {
+		return nil, err
+	}
+
+	return transaction, nil
+}
+
+// applyRequired applies a field of a partial update that can be changed but
+// not cleared.
+func applyRequired[T any](name string, field models.Optional[T], dst *T) error {
+	if field.Null {
+		return invalid(name + " cannot be cleared")
+	}
+	field.ApplyTo(dst)
+	return nil
+}
+
+// UpsertExternalTransaction creates or replaces the transaction recorded under
+// the transaction's source and external ID, reporting whether it was created.
+func (s *transactionService) UpsertExternalTransaction(ctx context.Context, transaction *models.Transaction) (bool, error) {
+	if strings.TrimSpace(transaction.Source) == "" {
+		return false, invalid("source is required")
+	}
+
+	if strings.TrimSpace(transaction.ExternalID) == "" {
+		return false, invalid("external ID is required")
+	}
+
+	existing, err := s.transactionRepo.GetByExternalID(ctx, transaction.Source, transaction.ExternalID)
+	if err != nil {
+		return false, err
+	}
+
+	if existing == nil {
+		transaction.ID = ""
+		return true, s.CreateTransaction(ctx, transaction)
+	}
+
+	// External systems don't know our versions, so the upsert replaces whatever is stored
+	transaction.ID = existing.ID
+	transaction.UpdatedAt = existing.UpdatedAt
+	return false, s.UpdateTransaction(ctx, transaction)
 }
 
 func (s *transactionService) DeleteTransaction(ctx context.Context, id string) error {
 	if strings.TrimSpace(id) == "" {
-		return errors.New("transaction ID is required")
+		return invalid("transaction ID is required")
+	}
+
+	if err := s.transactionRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	s.publisher.Publish(ctx, models.EventTransactionDeleted, map[string]string{"id": id})
+	return nil
+}
+
+func (s *transactionService) ListDeletedTransactions(ctx context.Context) ([]*models.Transaction, error) {
+	return s.transactionRepo.ListDeleted(ctx)
+}
+
+func (s *transactionService) PurgeDeletedTransactions(ctx context.Context, olderThan time.Time) (int, error) {
+	if err := validatePurgeCutoff(olderThan); err != nil {
+		return 0, err
+	}
+
+	return s.transactionRepo.PurgeDeleted(ctx, olderThan)
+}
+
+func validatePurgeCutoff(olderThan time.Time) error {
+	if olderThan.IsZero() {
+		return invalid("olderThan is required")
+	}
+
+	if olderThan.After(time.Now()) {
+		return invalid("olderThan must not be in the future")
+	}
+	return nil
+}
+
+// QueuePurgeDeletedTransactions validates the purge now and runs it as a
+// task.
+func (s *transactionService) QueuePurgeDeletedTransactions(ctx context.Context, olderThan time.Time) error {
+	if err := validatePurgeCutoff(olderThan); err != nil {
+		return err
 	}
 
-	return s.transactionRepo.Delete(ctx, id)
+	task, err := tasks.NewTask(ctx, TaskPurgeDeletedTransactions, purgeTask{OlderThan: olderThan})
+	if err != nil {
+		return err
+	}
+	return s.queue.Enqueue(ctx, task)
+}
+
+func (s *transactionService) ReassignCategory(ctx context.Context, filter models.TransactionFilter, targetCategoryID string, dryRun bool) (*models.CategoryReassignmentResult, error) {
+	if len(filter.CategoryIDs) != 1 {
+		return nil, invalid("exactly one source category ID is required")
+	}
+
+	if strings.TrimSpace(targetCategoryID) == "" {
+		return nil, invalid("target category ID is required")
+	}
+
+	if filter.CategoryIDs[0] == targetCategoryID {
+		return nil, invalid("source and target categories must differ")
+	}
+
+	if err := ValidateTransactionFilter(filter); err != nil {
+		return nil, err
+	}
+
+	source, err := s.categoryRepo.GetByID(ctx, filter.CategoryIDs[0])
+	if errors.Is(err, repositories.ErrCategoryNotFound) {
+		return nil, invalid("source category not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	target, err := s.categoryRepo.GetByID(ctx, targetCategoryID)
+	if errors.Is(err, repositories.ErrCategoryNotFound) {
+		return nil, invalid("target category not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if source.Type != target.Type {
+		return nil, invalid("target category type does not match source category type")
+	}
+
+	if dryRun {
+		count, err := s.transactionRepo.Count(ctx, filter)
+		if err != nil {
+			return nil, err
+		}
+		return &models.CategoryReassignmentResult{Matched: int(count), DryRun: true}, nil
+	}
+
+	transactions, err := s.transactionRepo.List(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, len(transactions))
+	for i, transaction := range transactions {
+		ids[i] = transaction.ID
+	}
+
+	updated, err := s.transactionRepo.ReassignCategory(ctx, ids, target.ID, target.Name)
+	for _, transaction := range transactions[:updated] {
+		transaction.CategoryID = target.ID
+		transaction.CategoryName = target.Name
+		s.publisher.Publish(ctx, models.EventTransactionUpdated, transaction)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.CategoryReassignmentResult{Matched: len(transactions), Updated: updated}, nil
 }
 
 func (s *transactionService) validateTransaction(ctx context.Context, transaction *models.Transaction) error {
-	if strings.TrimSpace(transaction.PropertyID) == "" {
-		return errors.New("property ID is required")
+	if err := s.validateTransactionFields(transaction); err != nil {
+		return err
+	}
+
+	// A missing property or category is the caller's mistake, which
+	// applyReferences reports
+	var property *models.Property
+	if transaction.PropertyID != "" {
+		var err error
+		property, err = s.propertyRepo.GetByID(ctx, transaction.PropertyID)
+		if err != nil && !errors.Is(err, repositories.ErrPropertyNotFound) {
+			return err
+		}
+	}
+
+	category, err := s.categoryRepo.GetByID(ctx, transaction.CategoryID)
+	if err != nil && !errors.Is(err, repositories.ErrCategoryNotFound) {
+		return err
+	}
+
+	if err := applyReferences(transaction, property, category); err != nil {
+		return err
+	}
+
+	policy, err := s.futureDatePolicy(ctx)
+	if err != nil {
+		return err
+	}
+	return checkFutureDate(transaction, policy)
+}
+
+// validateTransactionFields checks what can be checked without reading the
+// transaction's property and category, and cleans its description.
+func (s *transactionService) validateTransactionFields(transaction *models.Transaction) error {
+	if err := s.text.Description("description", &transaction.Description); err != nil {
+		return invalid(err.Error())
 	}
 
 	if strings.TrimSpace(transaction.CategoryID) == "" {
-		return errors.New("category ID is required")
+		return invalid("category ID is required")
 	}
 
 	if transaction.Amount <= 0 {
-		return errors.New("amount must be greater than zero")
+		return invalid("amount must be greater than zero")
 	}
 
 	if transaction.Type != models.TransactionTypeIncome && transaction.Type != models.TransactionTypeExpense {
-		return errors.New("invalid transaction type")
+		return invalid("invalid transaction type")
 	}
 
-	// Verify property exists
-	if _, err := s.propertyRepo.GetByID(ctx, transaction.PropertyID); err != nil {
-		return errors.New("property not found")
+	if transaction.ExternalID != "" && strings.TrimSpace(transaction.Source) == "" {
+		return invalid("source is required")
 	}
 
-	// Verify category exists and matches transaction type
-	category, err := s.categoryRepo.GetByID(ctx, transaction.CategoryID)
-	if err != nil {
-		return errors.New("category not found")
+	return nil
+}
+
+// applyReferences checks the transaction against its property and category,
+// either of which is nil when not found, and copies their names onto it. A
+// general transaction has no property.
+func applyReferences(transaction *models.Transaction, property *models.Property, category *models.Category) error {
+	if property == nil && transaction.PropertyID != "" {
+		return invalid(repositories.ErrPropertyNotFound.Error())
+	}
+
+	if category == nil {
+		return invalid(repositories.ErrCategoryNotFound.Error())
 	}
 
 	if category.Type != transaction.Type {
-		return errors.New("category type does not match transaction type")
+		return invalid("category type does not match transaction type")
+	}
+
+	// Names are denormalized so list views need no secondary lookups
+	transaction.PropertyName = ""
+	transaction.Timezone = ""
+	if property != nil {
+		transaction.PropertyName = property.DisplayName()
+		transaction.Timezone = property.Timezone
 	}
+	transaction.CategoryName = category.Name
 
 	return nil
 }
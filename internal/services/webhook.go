@@ -0,0 +1,545 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/spalqui/habitattrack-api/internal/models"
+	"github.com/spalqui/habitattrack-api/internal/repositories"
+	"github.com/spalqui/habitattrack-api/pkg/auth"
+)
+
+type EventPublisher interface {
+	Publish(ctx context.Context, eventType string, data interface{})
+}
+
+// WebhookDispatcher queues a delivery of each event to every subscribed
+// webhook, and sends the queued deliveries with retries in the background.
+// Queued deliveries are stored, so they survive a restart.
+type WebhookDispatcher interface {
+	EventPublisher
+	EventSink
+	Redeliver(ctx context.Context, webhook *models.Webhook, event *models.Event, payload []byte) error
+	DeliverPending(ctx context.Context) (int, error)
+	Schedule(ctx context.Context, interval time.Duration)
+	Check(ctx context.Context) error
+}
+
+type WebhookService interface {
+	CreateWebhook(ctx context.Context, webhook *models.Webhook) error
+	GetWebhook(ctx context.Context, id string) (*models.Webhook, error)
+	GetAllWebhooks(ctx context.Context) ([]*models.Webhook, error)
+	UpdateWebhook(ctx context.Context, webhook *models.Webhook) error
+	DeleteWebhook(ctx context.Context, id string) error
+	RotateWebhookSecret(ctx context.Context, id string, gracePeriod time.Duration) (*models.Webhook, error)
+	GetWebhookDeliveries(ctx context.Context, webhookID string) ([]*models.WebhookDelivery, error)
+}
+
+type webhookService struct {
+	webhookRepo  repositories.WebhookRepository
+	deliveryRepo repositories.WebhookDeliveryRepository
+}
+
+func NewWebhookService(webhookRepo repositories.WebhookRepository, deliveryRepo repositories.WebhookDeliveryRepository) WebhookService {
+	return &webhookService{
+		webhookRepo:  webhookRepo,
+		deliveryRepo: deliveryRepo,
+	}
+}
+
+// CreateWebhook generates a signing secret unless one is supplied. The secret
+// is only returned here and on rotation.
+func (s *webhookService) CreateWebhook(ctx context.Context, webhook *models.Webhook) error {
+	if strings.TrimSpace(webhook.Secret) == "" {
+		webhook.Secret = newWebhookSecret()
+	}
+
+	if err := s.validateWebhook(webhook); err != nil {
+		return err
+	}
+
+	webhook.PreviousSecret = ""
+	webhook.PreviousSecretExpiresAt = nil
+
+	webhook.Active = true
+	return s.webhookRepo.Create(ctx, webhook)
+}
+
+func (s *webhookService) GetWebhook(ctx context.Context, id string) (*models.Webhook, error) {
+	if strings.TrimSpace(id) == "" {
+		return nil, invalid("webhook ID is required")
+	}
+
+	return s.webhookRepo.GetByID(ctx, id)
+}
+
+func (s *webhookService) GetAllWebhooks(ctx context.Context) ([]*models.Webhook, error) {
+	return s.webhookRepo.GetAll(ctx)
+}
+
+func (s *webhookService) UpdateWebhook(ctx context.Context, webhook *models.Webhook) error {
+	if strings.TrimSpace(webhook.ID) == "" {
+		return invalid("webhook ID is required for update")
+	}
+
+	existing, err := s.webhookRepo.GetByID(ctx, webhook.ID)
+	if err != nil {
+		return err
+	}
+
+	// Secrets are never returned to clients, so keep the stored one unless a new one is supplied
+	if webhook.Secret == "" {
+		webhook.Secret = existing.Secret
+	}
+
+	if err := s.validateWebhook(webhook); err != nil {
+		return err
+	}
+
+	webhook.PreviousSecret = existing.PreviousSecret
+	webhook.PreviousSecretExpiresAt = existing.PreviousSecretExpiresAt
+	webhook.CreatedAt = existing.CreatedAt
+	return s.webhookRepo.Update(ctx, webhook)
+}
+
+// RotateWebhookSecret issues a new secret. Deliveries are signed with both
+// the new and the old secret until the grace period ends; a zero grace period
+// retires the old secret immediately.
+func (s *webhookService) RotateWebhookSecret(ctx context.Context, id string, gracePeriod time.Duration) (*models.Webhook, error) {
+	if strings.TrimSpace(id) == "" {
+		return nil, invalid("webhook ID is required")
+	}
+
+	if gracePeriod < 0 || gracePeriod > maxSecretGracePeriod {
+		return nil, invalid("grace period must be between 0 and 168 hours")
+	}
+
+	webhook, err := s.webhookRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	webhook.PreviousSecret = ""
+	webhook.PreviousSecretExpiresAt = nil
+	if gracePeriod > 0 {
+		expiresAt := time.Now().Add(gracePeriod)
+		webhook.PreviousSecret = webhook.Secret
+		webhook.PreviousSecretExpiresAt = &expiresAt
+	}
+	webhook.Secret = newWebhookSecret()
+
+	if err := s.webhookRepo.Update(ctx, webhook); err != nil {
+		return nil, err
+	}
+
+	return webhook, nil
+}
+
+func (s *webhookService) DeleteWebhook(ctx context.Context, id string) error {
+	if strings.TrimSpace(id) == "" {
+		return invalid("webhook ID is required")
+	}
+
+	return s.webhookRepo.Delete(ctx, id)
+}
+
+func (s *webhookService) GetWebhookDeliveries(ctx context.Context, webhookID string) ([]*models.WebhookDelivery, error) {
+	if strings.TrimSpace(webhookID) == "" {
+		return nil, invalid("webhook ID is required")
+	}
+
+	// Deliveries aren't owned directly, so check the caller can see the webhook
+	if _, err := s.webhookRepo.GetByID(ctx, webhookID); err != nil {
+		return nil, err
+	}
+
+	return s.deliveryRepo.GetByWebhookID(ctx, webhookID)
+}
+
+func (s *webhookService) validateWebhook(webhook *models.Webhook) error {
+	u, err := url.Parse(webhook.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return invalid("a valid http or https URL is required")
+	}
+
+	if internalHost(u.Hostname()) {
+		return invalid("webhook URL must not point to a private or internal address")
+	}
+
+	if len(webhook.Events) == 0 {
+		return invalid("at least one event is required")
+	}
+
+	for _, event := range webhook.Events {
+		if event != models.EventAll && !slices.Contains(models.EventTypes, event) {
+			return invalid(fmt.Sprintf("unknown event: %s", event))
+		}
+	}
+
+	if strings.TrimSpace(webhook.Secret) == "" {
+		return invalid("secret is required")
+	}
+
+	return nil
+}
+
+type webhookDispatcher struct {
+	webhookRepo    repositories.WebhookRepository
+	deliveryRepo   repositories.WebhookDeliveryRepository
+	queueRepo      repositories.DeliveryQueueRepository
+	deadLetterRepo repositories.DeadLetterRepository
+	client         *http.Client
+	batchSize      int
+	lease          time.Duration
+	maxAttempts    int
+	backoff        time.Duration
+	maxBackoff     time.Duration
+	maxPending     int64
+}
+
+func NewWebhookDispatcher(webhookRepo repositories.WebhookRepository, deliveryRepo repositories.WebhookDeliveryRepository, queueRepo repositories.DeliveryQueueRepository, deadLetterRepo repositories.DeadLetterRepository) WebhookDispatcher {
+	return &webhookDispatcher{
+		webhookRepo:    webhookRepo,
+		deliveryRepo:   deliveryRepo,
+		queueRepo:      queueRepo,
+		deadLetterRepo: deadLetterRepo,
+		client:         newWebhookClient(10 * time.Second),
+		batchSize:      20,
+		lease:          time.Minute,
+		maxAttempts:    5,
+		backoff:        time.Second,
+		maxBackoff:     time.Minute,
+		maxPending:     1000,
+	}
+}
+
+// Check fails once deliveries back up, which usually means receivers are
+// timing out and every delivery is working through its retries.
+func (d *webhookDispatcher) Check(ctx context.Context) error {
+	pending, err := d.queueRepo.CountDue(ctx)
+	if err != nil {
+		return err
+	}
+	if pending > d.maxPending {
+		return fmt.Errorf("%d webhook deliveries pending", pending)
+	}
+	return nil
+}
+
+func (d *webhookDispatcher) Publish(ctx context.Context, eventType string, data interface{}) {
+	event := &models.Event{
+		ID:        newEventID(),
+		Type:      eventType,
+		Data:      data,
+		CreatedAt: time.Now(),
+	}
+
+	if err := d.Send(context.WithoutCancel(ctx), event); err != nil {
+		log.Printf("Failed to dispatch event %s: %v", event.ID, err)
+	}
+}
+
+// Send queues a delivery of the event for each of its subscribed webhooks,
+// keeping the event's ID so receivers can recognise a redelivery. Once it
+// returns the deliveries are stored, so the outbox can let the event go;
+// DeliverPending sends them.
+func (d *webhookDispatcher) Send(ctx context.Context, event *models.Event) error {
+	webhooks, err := d.webhookRepo.GetByEvent(ctx, event.Type)
+	if err != nil {
+		return fmt.Errorf("loading webhooks for event %s: %w", event.Type, err)
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	var deliveries []*models.PendingDelivery
+	for _, webhook := range webhooks {
+		if webhook.Active {
+			deliveries = append(deliveries, newPendingDelivery(ctx, webhook, event, payload))
+		}
+	}
+	return d.queueRepo.Enqueue(ctx, deliveries)
+}
+
+// Redeliver queues an earlier delivery's payload for the webhook again, with
+// a fresh set of retries.
+func (d *webhookDispatcher) Redeliver(ctx context.Context, webhook *models.Webhook, event *models.Event, payload []byte) error {
+	return d.queueRepo.Enqueue(ctx, []*models.PendingDelivery{newPendingDelivery(ctx, webhook, event, payload)})
+}
+
+func newPendingDelivery(ctx context.Context, webhook *models.Webhook, event *models.Event, payload []byte) *models.PendingDelivery {
+	return &models.PendingDelivery{
+		ID:        event.ID + "_" + webhook.ID,
+		OrgID:     auth.OrgID(ctx),
+		WebhookID: webhook.ID,
+		EventID:   event.ID,
+		EventType: event.Type,
+		Payload:   payload,
+		EventAt:   event.CreatedAt,
+	}
+}
+
+// DeliverPending sends every available delivery, returning how many
+// succeeded. Each claimed batch is sent concurrently, so one slow receiver
+// doesn't hold up the rest or outlast the batch's lease.
+func (d *webhookDispatcher) DeliverPending(ctx context.Context) (int, error) {
+	delivered := 0
+	for {
+		pending, err := d.queueRepo.Claim(ctx, d.batchSize, d.lease)
+		if err != nil {
+			return delivered, err
+		}
+
+		results := make([]bool, len(pending))
+		errs := make([]error, len(pending))
+		var wg sync.WaitGroup
+		for i, delivery := range pending {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				results[i], errs[i] = d.attempt(ctx, delivery)
+			}()
+		}
+		wg.Wait()
+
+		for _, ok := range results {
+			if ok {
+				delivered++
+			}
+		}
+		if err := errors.Join(errs...); err != nil {
+			return delivered, err
+		}
+
+		if len(pending) < d.batchSize {
+			return delivered, nil
+		}
+	}
+}
+
+// attempt sends a queued delivery once, to the webhook as it is now, and
+// then removes it, schedules its retry or dead-letters it. A webhook deleted
+// or deactivated since the event was queued drops the delivery.
+func (d *webhookDispatcher) attempt(ctx context.Context, pending *models.PendingDelivery) (bool, error) {
+	if pending.OrgID != "" {
+		ctx = auth.WithPrincipal(ctx, &auth.Principal{OrgID: pending.OrgID})
+	}
+
+	webhook, err := d.webhookRepo.GetByID(ctx, pending.WebhookID)
+	if errors.Is(err, repositories.ErrWebhookNotFound) || (err == nil && !webhook.Active) {
+		return false, d.queueRepo.Delete(ctx, pending.ID)
+	}
+	if err != nil {
+		return false, err
+	}
+
+	event := &models.Event{ID: pending.EventID, Type: pending.EventType, CreatedAt: pending.EventAt}
+	delivery, retry := d.send(ctx, webhook, event, pending.Payload, pending.Attempts+1)
+	if err := d.deliveryRepo.Create(ctx, delivery); err != nil {
+		log.Printf("Failed to record delivery of event %s to webhook %s: %v", event.ID, webhook.ID, err)
+	}
+
+	if delivery.Success {
+		return true, d.queueRepo.Delete(ctx, pending.ID)
+	}
+	if retry && delivery.Attempt < d.maxAttempts {
+		return false, d.queueRepo.Release(ctx, pending.ID, time.Now().Add(retryDelay(d.backoff, d.maxBackoff, pending.Attempts)), errors.New(deliveryError(delivery)))
+	}
+
+	log.Printf("Giving up delivering event %s to webhook %s after %d attempts", event.ID, webhook.ID, delivery.Attempt)
+	if err := d.deadLetter(ctx, webhook, event, pending.Payload, delivery); err != nil {
+		return false, err
+	}
+	return false, d.queueRepo.Delete(ctx, pending.ID)
+}
+
+// deadLetter keeps a delivery that failed for good so it can be replayed.
+func (d *webhookDispatcher) deadLetter(ctx context.Context, webhook *models.Webhook, event *models.Event, payload []byte, delivery *models.WebhookDelivery) error {
+	return d.deadLetterRepo.Create(ctx, &models.DeadLetter{
+		Kind:      models.DeadLetterWebhook,
+		OrgID:     auth.OrgID(ctx),
+		WebhookID: webhook.ID,
+		EventID:   event.ID,
+		EventType: event.Type,
+		Payload:   payload,
+		Attempts:  delivery.Attempt,
+		LastError: deliveryError(delivery),
+		EventAt:   event.CreatedAt,
+	})
+}
+
+func deliveryError(delivery *models.WebhookDelivery) string {
+	if delivery.Error != "" {
+		return delivery.Error
+	}
+	return fmt.Sprintf("receiver responded with status %d", delivery.StatusCode)
+}
+
+// Schedule sends pending deliveries every interval until ctx is done.
+func (d *webhookDispatcher) Schedule(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := d.DeliverPending(ctx); err != nil {
+				log.Printf("Webhook delivery failed: %v", err)
+			}
+		}
+	}
+}
+
+func (d *webhookDispatcher) send(ctx context.Context, webhook *models.Webhook, event *models.Event, payload []byte, attempt int) (*models.WebhookDelivery, bool) {
+	delivery := &models.WebhookDelivery{
+		WebhookID: webhook.ID,
+		EventID:   event.ID,
+		Event:     event.Type,
+		Attempt:   attempt,
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(payload))
+	if err != nil {
+		delivery.Error = err.Error()
+		return delivery, false
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Webhook-ID", event.ID)
+	req.Header.Set("X-Webhook-Event", event.Type)
+	req.Header.Set("X-Webhook-Timestamp", timestamp)
+	req.Header.Set("X-Webhook-Signature", signatureHeader(webhook, timestamp, payload))
+
+	start := time.Now()
+	resp, err := d.client.Do(req)
+	delivery.Duration = time.Since(start).Milliseconds()
+	if err != nil {
+		delivery.Error = err.Error()
+		return delivery, !errors.Is(err, errInternalAddress)
+	}
+	defer resp.Body.Close()
+
+	delivery.StatusCode = resp.StatusCode
+	delivery.Success = resp.StatusCode >= 200 && resp.StatusCode < 300
+	if !delivery.Success {
+		delivery.Error = resp.Status
+	}
+
+	// Client errors other than timeouts and throttling will not succeed on retry
+	retry := resp.StatusCode >= 500 || resp.StatusCode == http.StatusRequestTimeout || resp.StatusCode == http.StatusTooManyRequests
+	return delivery, retry
+}
+
+// newWebhookClient refuses to connect to private and internal addresses. The
+// check is made on the address actually dialled, after DNS resolution and on
+// every redirect, so a public name that resolves to one is refused too.
+// Proxies from the environment are ignored, as they'd be dialled instead.
+func newWebhookClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			addr, err := netip.ParseAddr(host)
+			if err != nil {
+				return err
+			}
+			if !publicAddr(addr) {
+				return fmt.Errorf("%w: %s", errInternalAddress, addr)
+			}
+			return nil
+		},
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
+
+// errInternalAddress refuses a delivery to a private or internal address.
+// Retrying won't help, so such deliveries are dead-lettered at once.
+var errInternalAddress = errors.New("webhook address is private or internal")
+
+// internalHost catches webhook hosts that are plainly internal when the
+// webhook is saved. Names are only resolved when dialling.
+func internalHost(host string) bool {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") || host == "metadata.google.internal" {
+		return true
+	}
+	addr, err := netip.ParseAddr(host)
+	return err == nil && !publicAddr(addr)
+}
+
+// publicAddr reports whether addr is outside the loopback, private,
+// shared, link-local (including the 169.254.169.254 metadata server),
+// multicast and unspecified ranges.
+func publicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return !addr.IsLoopback() && !addr.IsPrivate() && !addr.IsLinkLocalUnicast() &&
+		!addr.IsLinkLocalMulticast() && !addr.IsInterfaceLocalMulticast() && !addr.IsMulticast() &&
+		!addr.IsUnspecified() && !sharedAddressSpace.Contains(addr)
+}
+
+// sharedAddressSpace is the carrier-grade NAT range of RFC 6598.
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// signatureHeader lists a signature per active secret, newest first, e.g.
+// "sha256=<new>,sha256=<old>" during a rotation grace period. Receivers
+// accept the delivery if any signature matches a secret they hold.
+func signatureHeader(webhook *models.Webhook, timestamp string, payload []byte) string {
+	header := "sha256=" + signPayload(webhook.Secret, timestamp, payload)
+	if webhook.PreviousSecret != "" && webhook.PreviousSecretExpiresAt != nil && time.Now().Before(*webhook.PreviousSecretExpiresAt) {
+		header += ",sha256=" + signPayload(webhook.PreviousSecret, timestamp, payload)
+	}
+	return header
+}
+
+func signPayload(secret, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// maxSecretGracePeriod bounds how long a rotated-out secret stays valid.
+const maxSecretGracePeriod = 7 * 24 * time.Hour
+
+func newWebhookSecret() string {
+	b := make([]byte, 32)
+	rand.Read(b)
+	return "whsec_" + hex.EncodeToString(b)
+}
+
+func newEventID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
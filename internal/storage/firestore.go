@@ -0,0 +1,89 @@
+package storage
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	gcsstorage "cloud.google.com/go/storage"
+	"google.golang.org/api/option"
+
+	"github.com/spalqui/habitattrack-api/internal/config"
+	"github.com/spalqui/habitattrack-api/pkg/breaker"
+	firestoreRepo "github.com/spalqui/habitattrack-api/pkg/firestore"
+	"github.com/spalqui/habitattrack-api/pkg/gcs"
+)
+
+// NewFirestoreClient connects to the configured Firestore database.
+func NewFirestoreClient(ctx context.Context, cfg *config.Config) (*firestore.Client, error) {
+	var options []option.ClientOption
+	if cfg.FirestoreKeyPath != "" {
+		options = append(options, option.WithCredentialsFile(cfg.FirestoreKeyPath))
+	}
+	if cfg.BreakerFailures > 0 {
+		breakers := breaker.NewSet(cfg.BreakerFailures, time.Duration(cfg.BreakerCooldown)*time.Second)
+		options = append(options, firestoreRepo.CircuitBreakers(breakers)...)
+	}
+	options = append(options, firestoreRepo.Instrument(time.Duration(cfg.SlowCallMs)*time.Millisecond, slog.Default())...)
+
+	return firestore.NewClientWithDatabase(ctx, cfg.GoogleProject, "habitattrack", options...)
+}
+
+func newFirestore(ctx context.Context, cfg *config.Config) (*Repositories, error) {
+	client, err := NewFirestoreClient(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	repos := &Repositories{
+		Properties:        firestoreRepo.NewPropertyRepository(client),
+		Transactions:      firestoreRepo.NewTransactionRepository(client),
+		Categories:        firestoreRepo.NewCachedCategoryRepository(firestoreRepo.NewCategoryRepository(client), time.Duration(cfg.CategoryCacheTTL)*time.Second),
+		Webhooks:          firestoreRepo.NewWebhookRepository(client),
+		WebhookDeliveries: firestoreRepo.NewWebhookDeliveryRepository(client),
+		DeliveryQueue:     firestoreRepo.NewDeliveryQueueRepository(client),
+		APIKeys:           firestoreRepo.NewAPIKeyRepository(client),
+		Members:           firestoreRepo.NewMemberRepository(client),
+		Organizations:     firestoreRepo.NewOrganizationRepository(client),
+		OAuthClients:      firestoreRepo.NewOAuthClientRepository(client),
+		Revocations:       firestoreRepo.NewRevocationRepository(client),
+		Accounts:          firestoreRepo.NewAccountRepository(client),
+		Impersonations:    firestoreRepo.NewImpersonationRepository(client),
+		Consents:          firestoreRepo.NewConsentRepository(client),
+		AuditEvents:       firestoreRepo.NewAuditEventRepository(client),
+		Documents:         firestoreRepo.NewDocumentStore(client),
+		Rollups:           firestoreRepo.NewRollupRepository(client),
+		Cleanup:           firestoreRepo.NewCleanupRepository(client),
+		Maintenance:       firestoreRepo.NewMaintenanceRepository(client),
+		Outbox:            firestoreRepo.NewOutboxRepository(client),
+		JobLocks:          firestoreRepo.NewJobLockRepository(client),
+		DeadLetters:       firestoreRepo.NewDeadLetterRepository(client),
+		close:             client.Close,
+	}
+	repos.ping = func(ctx context.Context) error {
+		return firestoreRepo.Ping(ctx, client)
+	}
+	repos.indexes = func(ctx context.Context) error {
+		return firestoreRepo.CheckIndexes(ctx, client)
+	}
+	repos.legacy = func(ctx context.Context) error {
+		return firestoreRepo.CheckLegacyData(ctx, client)
+	}
+
+	if cfg.BackupBucket != "" {
+		storageClient, err := gcsstorage.NewClient(ctx)
+		if err != nil {
+			client.Close()
+			return nil, err
+		}
+
+		repos.Backups = gcs.NewBackupStore(storageClient, cfg.BackupBucket)
+		repos.close = func() error {
+			storageClient.Close()
+			return client.Close()
+		}
+	}
+
+	return repos, nil
+}
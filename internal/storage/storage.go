@@ -0,0 +1,88 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spalqui/habitattrack-api/internal/config"
+	"github.com/spalqui/habitattrack-api/internal/repositories"
+)
+
+// Repositories holds every repository the services use, all from the same
+// backend.
+type Repositories struct {
+	Properties        repositories.PropertyRepository
+	Transactions      repositories.TransactionRepository
+	Categories        repositories.CategoryRepository
+	Webhooks          repositories.WebhookRepository
+	WebhookDeliveries repositories.WebhookDeliveryRepository
+	DeliveryQueue     repositories.DeliveryQueueRepository
+	APIKeys           repositories.APIKeyRepository
+	Members           repositories.MemberRepository
+	Organizations     repositories.OrganizationRepository
+	OAuthClients      repositories.OAuthClientRepository
+	Revocations       repositories.RevocationRepository
+	Accounts          repositories.AccountRepository
+	Impersonations    repositories.ImpersonationRepository
+	Consents          repositories.ConsentRepository
+	AuditEvents       repositories.AuditEventRepository
+	Documents         repositories.DocumentStore
+	Rollups           repositories.RollupRepository
+	Cleanup           repositories.CleanupRepository
+	Maintenance       repositories.MaintenanceRepository
+	Outbox            repositories.OutboxRepository
+	JobLocks          repositories.JobLockRepository
+	DeadLetters       repositories.DeadLetterRepository
+
+	// Backups is nil unless a backup bucket is configured
+	Backups repositories.BackupStore
+
+	ping    func(ctx context.Context) error
+	indexes func(ctx context.Context) error
+	legacy  func(ctx context.Context) error
+	close   func() error
+}
+
+// CheckLegacyData checks that no data from before authentication is left
+// where no organization can see it.
+func (r *Repositories) CheckLegacyData(ctx context.Context) error {
+	if r.legacy == nil {
+		return nil
+	}
+	return r.legacy(ctx)
+}
+
+// CheckIndexes checks that the backend has the indexes the repositories'
+// queries need.
+func (r *Repositories) CheckIndexes(ctx context.Context) error {
+	if r.indexes == nil {
+		return nil
+	}
+	return r.indexes(ctx)
+}
+
+// Ping checks that the backend is reachable.
+func (r *Repositories) Ping(ctx context.Context) error {
+	if r.ping == nil {
+		return nil
+	}
+	return r.ping(ctx)
+}
+
+// Close releases the backend's connections.
+func (r *Repositories) Close() error {
+	if r.close == nil {
+		return nil
+	}
+	return r.close()
+}
+
+// New builds the repositories for the backend named by cfg.StorageBackend.
+func New(ctx context.Context, cfg *config.Config) (*Repositories, error) {
+	switch cfg.StorageBackend {
+	case "firestore":
+		return newFirestore(ctx, cfg)
+	default:
+		return nil, fmt.Errorf("unsupported storage backend %q", cfg.StorageBackend)
+	}
+}
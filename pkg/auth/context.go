@@ -0,0 +1,63 @@
+package auth
+
+import (
+	"context"
+	"time"
+)
+
+// Principal is the authenticated caller of a request: a user, or a machine
+// client (ClientID) limited to Scopes. OrgID is the organization whose data
+// the request acts on; every user also has a personal organization whose ID
+// is their user ID. Admin marks operators of the service itself, and MFA
+// users who signed in with a second factor. TokenID and IssuedAt (the
+// sign-in time for users) identify the bearer token for revocation checks.
+// ImpersonatorID is set when an admin is acting as UserID.
+type Principal struct {
+	UserID   string
+	Email    string
+	APIKeyID string
+	ClientID string
+	Scopes   []string
+	OrgID    string
+	Role     Role
+	Admin    bool
+	MFA      bool
+	TokenID  string
+	IssuedAt time.Time
+
+	ImpersonatorID string
+}
+
+type contextKey struct{}
+
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, contextKey{}, principal)
+}
+
+func FromContext(ctx context.Context) (*Principal, bool) {
+	principal, ok := ctx.Value(contextKey{}).(*Principal)
+	return principal, ok && principal != nil
+}
+
+// UserID returns the authenticated user's ID, or "" when the request is
+// unauthenticated (e.g. authentication is disabled).
+func UserID(ctx context.Context) string {
+	if principal, ok := FromContext(ctx); ok {
+		return principal.UserID
+	}
+	return ""
+}
+
+// OrgID returns the ID of the organization the request acts on, or "" when
+// the request is unauthenticated.
+func OrgID(ctx context.Context) string {
+	principal, ok := FromContext(ctx)
+	if !ok {
+		return ""
+	}
+
+	if principal.OrgID != "" {
+		return principal.OrgID
+	}
+	return principal.UserID
+}
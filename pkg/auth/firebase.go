@@ -0,0 +1,217 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const firebaseCertsURL = "https://www.googleapis.com/robot/v1/metadata/x509/securetoken@system.gserviceaccount.com"
+
+var ErrInvalidToken = errors.New("invalid token")
+
+type TokenVerifier interface {
+	Verify(ctx context.Context, token string) (*Principal, error)
+}
+
+type APIKeyVerifier interface {
+	VerifyAPIKey(ctx context.Context, key string) (*Principal, error)
+}
+
+// RevocationChecker reports whether a verified bearer token has since been
+// revoked, either individually or by a cutoff on all of a user's sessions.
+type RevocationChecker interface {
+	IsRevoked(ctx context.Context, principal *Principal) (bool, error)
+}
+
+type firebaseVerifier struct {
+	projectID string
+	client    *http.Client
+
+	mu      sync.Mutex
+	keys    map[string]*rsa.PublicKey
+	expires time.Time
+}
+
+// NewFirebaseVerifier verifies Firebase Authentication ID tokens issued for
+// the given project.
+func NewFirebaseVerifier(projectID string) TokenVerifier {
+	return &firebaseVerifier{
+		projectID: projectID,
+		client:    &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+type tokenHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+type tokenClaims struct {
+	Issuer   string `json:"iss"`
+	Audience string `json:"aud"`
+	Subject  string `json:"sub"`
+	Email    string `json:"email"`
+	IssuedAt int64  `json:"iat"`
+	Expires  int64  `json:"exp"`
+	AuthTime int64  `json:"auth_time"`
+	TokenID  string `json:"jti"`
+	Role     Role   `json:"role"`
+	Admin    bool   `json:"admin"`
+	Firebase struct {
+		SecondFactor string `json:"sign_in_second_factor"`
+	} `json:"firebase"`
+}
+
+func (v *firebaseVerifier) Verify(ctx context.Context, token string) (*Principal, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrInvalidToken
+	}
+
+	var header tokenHeader
+	if err := decodeSegment(parts[0], &header); err != nil || header.Alg != "RS256" {
+		return nil, ErrInvalidToken
+	}
+
+	var claims tokenClaims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	now := time.Now().Unix()
+	switch {
+	case claims.Audience != v.projectID,
+		claims.Issuer != "https://securetoken.google.com/"+v.projectID,
+		claims.Subject == "" || len(claims.Subject) > 128,
+		claims.Expires <= now,
+		claims.IssuedAt > now+60,
+		claims.AuthTime > now+60:
+		return nil, ErrInvalidToken
+	}
+
+	key, err := v.publicKey(ctx, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature); err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	// Refreshed ID tokens get a new iat but keep the sign-in time, so
+	// revocation cutoffs are compared against auth_time
+	issuedAt := claims.AuthTime
+	if issuedAt == 0 {
+		issuedAt = claims.IssuedAt
+	}
+
+	return &Principal{
+		UserID:   claims.Subject,
+		Email:    claims.Email,
+		Role:     claims.Role,
+		Admin:    claims.Admin,
+		MFA:      claims.Firebase.SecondFactor != "",
+		TokenID:  claims.TokenID,
+		IssuedAt: time.Unix(issuedAt, 0),
+	}, nil
+}
+
+func (v *firebaseVerifier) publicKey(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if time.Now().After(v.expires) {
+		if err := v.refreshKeys(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	key, ok := v.keys[kid]
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+	return key, nil
+}
+
+// refreshKeys fetches Google's signing certificates, caching them for as long
+// as the response's Cache-Control header allows.
+func (v *firebaseVerifier) refreshKeys(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, firebaseCertsURL, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("fetching signing keys: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("fetching signing keys: unexpected status %d", resp.StatusCode)
+	}
+
+	var certs map[string]string
+	if err := json.NewDecoder(resp.Body).Decode(&certs); err != nil {
+		return fmt.Errorf("decoding signing keys: %w", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(certs))
+	for kid, certPEM := range certs {
+		block, _ := pem.Decode([]byte(certPEM))
+		if block == nil {
+			continue
+		}
+
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			continue
+		}
+
+		if key, ok := cert.PublicKey.(*rsa.PublicKey); ok {
+			keys[kid] = key
+		}
+	}
+
+	v.keys = keys
+	v.expires = time.Now().Add(maxAge(resp.Header.Get("Cache-Control")))
+	return nil
+}
+
+func maxAge(cacheControl string) time.Duration {
+	for _, directive := range strings.Split(cacheControl, ",") {
+		if value, ok := strings.CutPrefix(strings.TrimSpace(directive), "max-age="); ok {
+			if seconds, err := strconv.Atoi(value); err == nil {
+				return time.Duration(seconds) * time.Second
+			}
+		}
+	}
+	return time.Hour
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
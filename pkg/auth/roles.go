@@ -0,0 +1,43 @@
+package auth
+
+import (
+	"context"
+	"slices"
+)
+
+type Role string
+
+const (
+	RoleOwner      Role = "owner"
+	RoleEditor     Role = "editor"
+	RoleAccountant Role = "accountant"
+)
+
+type Permission string
+
+const (
+	PermissionRead   Permission = "read"
+	PermissionWrite  Permission = "write"
+	PermissionManage Permission = "manage"
+)
+
+var rolePermissions = map[Role][]Permission{
+	RoleOwner:      {PermissionRead, PermissionWrite, PermissionManage},
+	RoleEditor:     {PermissionRead, PermissionWrite},
+	RoleAccountant: {PermissionRead},
+}
+
+func (r Role) Valid() bool {
+	_, ok := rolePermissions[r]
+	return ok
+}
+
+func (r Role) Can(permission Permission) bool {
+	return slices.Contains(rolePermissions[r], permission)
+}
+
+// MembershipResolver looks up the role a user has in an organization. It
+// returns "" when the user is not a member.
+type MembershipResolver interface {
+	ResolveRole(ctx context.Context, orgID, userID string) (Role, error)
+}
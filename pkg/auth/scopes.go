@@ -0,0 +1,19 @@
+package auth
+
+import "slices"
+
+// Scopes grant machine clients and scoped API keys access per resource, named
+// "<permission>:<resource>" after the first segment of the route path.
+var Scopes = []string{
+	"read:properties", "write:properties",
+	"read:transactions", "write:transactions",
+	"read:categories", "write:categories",
+}
+
+func ValidScope(scope string) bool {
+	return slices.Contains(Scopes, scope)
+}
+
+func (p *Principal) HasScope(scope string) bool {
+	return slices.Contains(p.Scopes, scope)
+}
@@ -0,0 +1,130 @@
+// Package breaker fails calls to a struggling backend fast, instead of
+// letting every request wait for its own timeout.
+package breaker
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// OpenError is returned instead of calling a backend whose breaker is open.
+type OpenError struct {
+	Name       string
+	RetryAfter time.Duration
+}
+
+func (e *OpenError) Error() string {
+	return fmt.Sprintf("%s is unavailable after repeated failures; retry in %s", e.Name, e.RetryAfter.Round(time.Second))
+}
+
+// Breaker opens after threshold consecutive failures and rejects calls for
+// the cooldown. Then it lets a single call through: success closes it, and
+// failure opens it for another cooldown.
+type Breaker struct {
+	name      string
+	threshold int
+	cooldown  time.Duration
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+	probing   bool
+}
+
+// Allow returns an *OpenError when the call mustn't be made. Calls that are
+// allowed must report their outcome with Record.
+func (b *Breaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.failures < b.threshold {
+		return nil
+	}
+
+	now := time.Now()
+	if now.Before(b.openUntil) || b.probing {
+		return &OpenError{Name: b.name, RetryAfter: max(time.Until(b.openUntil), time.Second)}
+	}
+	b.probing = true
+	return nil
+}
+
+func (b *Breaker) Record(failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+	if !failed {
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+	if b.failures >= b.threshold {
+		b.openUntil = time.Now().Add(b.cooldown)
+	}
+}
+
+// Set keeps a breaker per name, created on first use.
+type Set struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	breakers map[string]*Breaker
+}
+
+func NewSet(threshold int, cooldown time.Duration) *Set {
+	return &Set{
+		threshold: threshold,
+		cooldown:  cooldown,
+		breakers:  make(map[string]*Breaker),
+	}
+}
+
+func (s *Set) Get(name string) *Breaker {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	breaker, ok := s.breakers[name]
+	if !ok {
+		breaker = &Breaker{name: name, threshold: s.threshold, cooldown: s.cooldown}
+		s.breakers[name] = breaker
+	}
+	return breaker
+}
+
+type tripsKey struct{}
+
+type trips struct {
+	mu  sync.Mutex
+	err *OpenError
+}
+
+// WithTrips lets calls made with the returned context record that they were
+// rejected by an open breaker, for Tripped to report.
+func WithTrips(ctx context.Context) context.Context {
+	return context.WithValue(ctx, tripsKey{}, &trips{})
+}
+
+// RecordTrip notes on ctx that a call was rejected.
+func RecordTrip(ctx context.Context, err *OpenError) {
+	if t, ok := ctx.Value(tripsKey{}).(*trips); ok {
+		t.mu.Lock()
+		t.err = err
+		t.mu.Unlock()
+	}
+}
+
+// Tripped returns the last rejection recorded on ctx, or nil.
+func Tripped(ctx context.Context) *OpenError {
+	t, ok := ctx.Value(tripsKey{}).(*trips)
+	if !ok {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.err
+}
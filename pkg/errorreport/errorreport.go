@@ -0,0 +1,116 @@
+// Package errorreport forwards server errors to an aggregation service, so
+// they can be grouped and alerted on rather than found by reading logs.
+package errorreport
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/gorilla/mux"
+
+	"github.com/spalqui/habitattrack-api/pkg/auth"
+	"github.com/spalqui/habitattrack-api/pkg/breaker"
+	"github.com/spalqui/habitattrack-api/pkg/requestid"
+)
+
+// Event describes a failed request.
+type Event struct {
+	Message   string
+	Status    int
+	Method    string
+	Route     string
+	RequestID string
+	UserID    string
+	Stack     []byte
+}
+
+type Reporter interface {
+	Report(ctx context.Context, event *Event)
+}
+
+type reportedKey struct{}
+
+// MarkReported records that the request's failure has already been reported,
+// so the error response written for it isn't reported a second time.
+func MarkReported(ctx context.Context) context.Context {
+	return context.WithValue(ctx, reportedKey{}, true)
+}
+
+// FromRequest describes a request that failed with status, capturing the
+// caller's stack.
+func FromRequest(r *http.Request, status int, message string) *Event {
+	event := &Event{
+		Message:   message,
+		Status:    status,
+		Method:    r.Method,
+		Route:     r.URL.Path,
+		RequestID: requestid.FromContext(r.Context()),
+		Stack:     debug.Stack(),
+	}
+	if route := mux.CurrentRoute(r); route != nil {
+		if template, err := route.GetPathTemplate(); err == nil {
+			event.Route = template
+		}
+	}
+	if principal, ok := auth.FromContext(r.Context()); ok {
+		event.UserID = principal.UserID
+	}
+	return event
+}
+
+// ReportResponse reports an error response with a 5xx status. A 503 is
+// deliberate, as during maintenance, so isn't reported, and nor are
+// failures caused by an open circuit breaker, which only echo the outage
+// that opened it. It does nothing without a reporter.
+func ReportResponse(reporter Reporter, r *http.Request, status int, message string) {
+	if reporter == nil || status < http.StatusInternalServerError || status == http.StatusServiceUnavailable {
+		return
+	}
+	if reported, _ := r.Context().Value(reportedKey{}).(bool); reported {
+		return
+	}
+	if breaker.Tripped(r.Context()) != nil {
+		return
+	}
+	reporter.Report(r.Context(), FromRequest(r, status, message))
+}
+
+type cloudReporter struct {
+	logger  *slog.Logger
+	service string
+	version string
+}
+
+// NewCloudReporter writes events as structured log entries that Google Cloud
+// Error Reporting picks up from Cloud Logging, so no client library or
+// credentials are needed on Cloud Run or GKE.
+func NewCloudReporter(logger *slog.Logger, service, version string) Reporter {
+	return &cloudReporter{
+		logger:  logger,
+		service: service,
+		version: version,
+	}
+}
+
+func (c *cloudReporter) Report(ctx context.Context, event *Event) {
+	c.logger.LogAttrs(ctx, slog.LevelError, event.Message,
+		slog.String("@type", "type.googleapis.com/google.devtools.clouderrorreporting.v1beta1.ReportedErrorEvent"),
+		slog.String("stack_trace", fmt.Sprintf("%s\n\n%s", event.Message, event.Stack)),
+		slog.Group("serviceContext",
+			slog.String("service", c.service),
+			slog.String("version", c.version),
+		),
+		slog.Group("context",
+			slog.Group("httpRequest",
+				slog.String("method", event.Method),
+				slog.String("url", event.Route),
+				slog.Int("responseStatusCode", event.Status),
+			),
+			slog.String("user", event.UserID),
+		),
+		slog.String("requestId", event.RequestID),
+	)
+}
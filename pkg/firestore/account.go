@@ -0,0 +1,104 @@
+package firestore
+
+import (
+	"context"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/spalqui/habitattrack-api/internal/models"
+	"github.com/spalqui/habitattrack-api/internal/repositories"
+)
+
+type accountRepository struct {
+	client     *firestore.Client
+	collection string
+}
+
+func NewAccountRepository(client *firestore.Client) repositories.AccountRepository {
+	return &accountRepository{
+		client:     client,
+		collection: "account_tombstones",
+	}
+}
+
+// PurgeOrganization deletes the organization's tenant data and its records in
+// the shared root collections.
+func (r *accountRepository) PurgeOrganization(ctx context.Context, orgID string) error {
+	deletes := newBulkWrites(ctx, r.client)
+
+	if err := deletes.deleteTree(ctx, r.client.Collection("orgs").Doc(orgID)); err != nil {
+		return deletes.abort(err)
+	}
+
+	for _, name := range []string{"members", "oauth_clients"} {
+		if err := deletes.deleteQuery(ctx, r.client.Collection(name).Where("orgId", "==", orgID)); err != nil {
+			return deletes.abort(err)
+		}
+	}
+
+	deletes.deleteRef(r.client.Collection("organizations").Doc(orgID))
+
+	return deletes.firstError()
+}
+
+// PurgeUser deletes the user's memberships and API keys.
+func (r *accountRepository) PurgeUser(ctx context.Context, userID string) error {
+	deletes := newBulkWrites(ctx, r.client)
+
+	queries := []firestore.Query{
+		r.client.Collection("members").Where("userId", "==", userID),
+		r.client.Collection("api_keys").Where("ownerUserId", "==", userID),
+	}
+	for _, query := range queries {
+		if err := deletes.deleteQuery(ctx, query); err != nil {
+			return deletes.abort(err)
+		}
+	}
+
+	return deletes.firstError()
+}
+
+func (r *accountRepository) SaveTombstone(ctx context.Context, tombstone *models.AccountTombstone) error {
+	_, err := r.client.Collection(r.collection).Doc(tombstone.ID).Set(ctx, tombstone)
+	return err
+}
+
+func (r *accountRepository) GetTombstone(ctx context.Context, id string) (*models.AccountTombstone, error) {
+	doc, err := getDoc(ctx, r.client.Collection(r.collection).Doc(id))
+	if status.Code(err) == codes.NotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var tombstone models.AccountTombstone
+	if err := doc.DataTo(&tombstone); err != nil {
+		return nil, err
+	}
+
+	tombstone.ID = doc.Ref.ID
+	return &tombstone, nil
+}
+
+func (r *accountRepository) ListUnfinishedTombstones(ctx context.Context) ([]*models.AccountTombstone, error) {
+	docs, err := getAll(ctx, r.client.Collection(r.collection).
+		Where("status", "in", []string{models.DeletionStatusPending, models.DeletionStatusFailed}))
+	if err != nil {
+		return nil, err
+	}
+
+	tombstones := make([]*models.AccountTombstone, len(docs))
+	for i, doc := range docs {
+		var tombstone models.AccountTombstone
+		if err := doc.DataTo(&tombstone); err != nil {
+			return nil, err
+		}
+		tombstone.ID = doc.Ref.ID
+		tombstones[i] = &tombstone
+	}
+
+	return tombstones, nil
+}
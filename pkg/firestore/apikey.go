@@ -0,0 +1,103 @@
+package firestore
+
+import (
+	"context"
+	"time"
+
+	"cloud.google.com/go/firestore"
+
+	"github.com/spalqui/habitattrack-api/internal/models"
+	"github.com/spalqui/habitattrack-api/internal/repositories"
+	"github.com/spalqui/habitattrack-api/pkg/auth"
+)
+
+type apiKeyRepository struct {
+	client     *firestore.Client
+	collection string
+}
+
+func NewAPIKeyRepository(client *firestore.Client) repositories.APIKeyRepository {
+	return &apiKeyRepository{
+		client:     client,
+		collection: "api_keys",
+	}
+}
+
+func (r *apiKeyRepository) Create(ctx context.Context, apiKey *models.APIKey) error {
+	apiKey.CreatedAt = time.Now()
+	apiKey.UpdatedAt = time.Now()
+
+	docRef := newDoc(r.client.Collection(r.collection))
+	if _, err := docRef.Create(ctx, apiKey); err != nil {
+		return err
+	}
+
+	apiKey.ID = docRef.ID
+	return nil
+}
+
+func (r *apiKeyRepository) GetByID(ctx context.Context, id string) (*models.APIKey, error) {
+	doc, err := getScoped(ctx, r.client.Collection(r.collection).Doc(id), "ownerUserId", auth.UserID(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	var apiKey models.APIKey
+	if err := doc.DataTo(&apiKey); err != nil {
+		return nil, err
+	}
+
+	apiKey.ID = doc.Ref.ID
+	return &apiKey, nil
+}
+
+// GetByHash is used to authenticate requests, so it is deliberately not
+// scoped to the caller. It returns nil when no key matches.
+func (r *apiKeyRepository) GetByHash(ctx context.Context, keyHash string) (*models.APIKey, error) {
+	docs, err := getAll(ctx, r.client.Collection(r.collection).Where("keyHash", "==", keyHash).Limit(1))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(docs) == 0 {
+		return nil, nil
+	}
+
+	var apiKey models.APIKey
+	if err := docs[0].DataTo(&apiKey); err != nil {
+		return nil, err
+	}
+
+	apiKey.ID = docs[0].Ref.ID
+	return &apiKey, nil
+}
+
+func (r *apiKeyRepository) GetAll(ctx context.Context) ([]*models.APIKey, error) {
+	docs, err := getAll(ctx, scopeBy(r.client.Collection(r.collection).Query, "ownerUserId", auth.UserID(ctx)))
+	if err != nil {
+		return nil, err
+	}
+
+	apiKeys := make([]*models.APIKey, len(docs))
+	for i, doc := range docs {
+		var apiKey models.APIKey
+		if err := doc.DataTo(&apiKey); err != nil {
+			return nil, err
+		}
+		apiKey.ID = doc.Ref.ID
+		apiKeys[i] = &apiKey
+	}
+
+	return apiKeys, nil
+}
+
+func (r *apiKeyRepository) Update(ctx context.Context, apiKey *models.APIKey) error {
+	docRef := r.client.Collection(r.collection).Doc(apiKey.ID)
+	if _, err := getScoped(ctx, docRef, "ownerUserId", auth.UserID(ctx)); err != nil {
+		return err
+	}
+
+	apiKey.UpdatedAt = time.Now()
+	_, err := docRef.Update(ctx, fieldUpdates(apiKey, "createdAt", "ownerUserId"))
+	return err
+}
@@ -0,0 +1,68 @@
+package firestore
+
+import (
+	"context"
+
+	"cloud.google.com/go/firestore"
+
+	"github.com/spalqui/habitattrack-api/internal/models"
+	"github.com/spalqui/habitattrack-api/internal/repositories"
+)
+
+// maxAuditEvents caps a single listing; narrow with filters for older events.
+const maxAuditEvents = 500
+
+type auditEventRepository struct {
+	client     *firestore.Client
+	collection string
+}
+
+func NewAuditEventRepository(client *firestore.Client) repositories.AuditEventRepository {
+	return &auditEventRepository{
+		client:     client,
+		collection: "audit_events",
+	}
+}
+
+func (r *auditEventRepository) Create(ctx context.Context, event *models.AuditEvent) error {
+	docRef := newDoc(tenantCollection(ctx, r.client, r.collection))
+	if _, err := docRef.Create(ctx, event); err != nil {
+		return err
+	}
+
+	event.ID = docRef.ID
+	return nil
+}
+
+func (r *auditEventRepository) List(ctx context.Context, filter models.AuditEventFilter) ([]*models.AuditEvent, error) {
+	query := tenantCollection(ctx, r.client, r.collection).Query
+
+	if filter.Actor != "" {
+		query = query.Where("actor", "==", filter.Actor)
+	}
+
+	if filter.Type != "" {
+		query = query.Where("type", "==", filter.Type)
+	}
+
+	if filter.Impersonated {
+		query = query.Where("impersonated", "==", true)
+	}
+
+	docs, err := getAll(ctx, query.OrderBy("createdAt", firestore.Desc).Limit(maxAuditEvents))
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]*models.AuditEvent, len(docs))
+	for i, doc := range docs {
+		var event models.AuditEvent
+		if err := doc.DataTo(&event); err != nil {
+			return nil, err
+		}
+		event.ID = doc.Ref.ID
+		events[i] = &event
+	}
+
+	return events, nil
+}
@@ -0,0 +1,118 @@
+package firestore
+
+import (
+	"context"
+	"encoding/base64"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/spalqui/habitattrack-api/internal/models"
+	"github.com/spalqui/habitattrack-api/internal/repositories"
+)
+
+type documentStore struct {
+	client *firestore.Client
+}
+
+func NewDocumentStore(client *firestore.Client) repositories.DocumentStore {
+	return &documentStore{client: client}
+}
+
+// Export walks every collection, including subcollections, and passes each
+// document to write. It returns the number of documents exported.
+func (s *documentStore) Export(ctx context.Context, write func(record *models.BackupRecord) error) (int, error) {
+	count := 0
+	collections := s.client.Collections(ctx)
+	for {
+		collection, err := collections.Next()
+		if err == iterator.Done {
+			return count, nil
+		}
+		if err != nil {
+			return count, err
+		}
+
+		if err := s.exportCollection(ctx, collection, write, &count); err != nil {
+			return count, err
+		}
+	}
+}
+
+func (s *documentStore) exportCollection(ctx context.Context, collection *firestore.CollectionRef, write func(record *models.BackupRecord) error, count *int) error {
+	// DocumentRefs includes documents that only exist as parents of
+	// subcollections, such as organizations' tenant documents
+	refs := collection.DocumentRefs(ctx)
+	for {
+		ref, err := refs.Next()
+		if err == iterator.Done {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		doc, err := getDoc(ctx, ref)
+		if err != nil && status.Code(err) != codes.NotFound {
+			return err
+		}
+		if err == nil {
+			if err := write(&models.BackupRecord{Path: relativePath(ref), Data: encodeBackupValue(doc.Data()).(map[string]interface{})}); err != nil {
+				return err
+			}
+			*count++
+		}
+
+		subcollections := ref.Collections(ctx)
+		for {
+			subcollection, err := subcollections.Next()
+			if err == iterator.Done {
+				break
+			}
+			if err != nil {
+				return err
+			}
+
+			if err := s.exportCollection(ctx, subcollection, write, count); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// relativePath trims the project and database from a document's path.
+func relativePath(ref *firestore.DocumentRef) string {
+	_, path, _ := strings.Cut(ref.Path, "/documents/")
+	return path
+}
+
+// encodeBackupValue converts Firestore values that JSON would lose the type
+// of into tagged objects: {"$time": ...}, {"$bytes": ...} and {"$ref": ...}.
+func encodeBackupValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		encoded := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			encoded[key] = encodeBackupValue(item)
+		}
+		return encoded
+	case []interface{}:
+		encoded := make([]interface{}, len(v))
+		for i, item := range v {
+			encoded[i] = encodeBackupValue(item)
+		}
+		return encoded
+	case time.Time:
+		return map[string]interface{}{"$time": v.Format(time.RFC3339Nano)}
+	case []byte:
+		return map[string]interface{}{"$bytes": base64.StdEncoding.EncodeToString(v)}
+	case *firestore.DocumentRef:
+		return map[string]interface{}{"$ref": relativePath(v)}
+	default:
+		return v
+	}
+}
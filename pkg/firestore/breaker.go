@@ -0,0 +1,161 @@
+package firestore
+
+import (
+	"context"
+	"errors"
+	"io"
+	"path"
+	"strings"
+
+	"cloud.google.com/go/firestore/apiv1/firestorepb"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/spalqui/habitattrack-api/pkg/breaker"
+)
+
+// CircuitBreakers returns client options that guard each collection, and so
+// each repository, with a breaker from breakers. Once a collection's calls
+// keep failing with transient errors, further calls to it fail fast with a
+// *breaker.OpenError, recorded on the request context, rather than queueing
+// up behind timeouts.
+func CircuitBreakers(breakers *breaker.Set) []option.ClientOption {
+	return []option.ClientOption{
+		option.WithGRPCDialOption(grpc.WithChainUnaryInterceptor(
+			func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+				guard := breakers.Get(requestCollection(req))
+				if err := allow(ctx, guard); err != nil {
+					return err
+				}
+				err := invoker(ctx, method, req, reply, cc, opts...)
+				guard.Record(retryable(err))
+				return err
+			},
+		)),
+		option.WithGRPCDialOption(grpc.WithChainStreamInterceptor(
+			func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+				// The collection is only known from the first request, so the
+				// stream is opened when it's sent
+				return &guardedStream{ctx: ctx, open: func() (grpc.ClientStream, error) {
+					return streamer(ctx, desc, cc, method, opts...)
+				}, breakers: breakers}, nil
+			},
+		)),
+	}
+}
+
+func allow(ctx context.Context, guard *breaker.Breaker) error {
+	err := guard.Allow()
+	var open *breaker.OpenError
+	if errors.As(err, &open) {
+		breaker.RecordTrip(ctx, open)
+	}
+	return err
+}
+
+// guardedStream checks the breaker before opening the stream and records
+// the outcome once the first response, or error, arrives.
+type guardedStream struct {
+	ctx      context.Context
+	open     func() (grpc.ClientStream, error)
+	breakers *breaker.Set
+
+	stream   grpc.ClientStream
+	guard    *breaker.Breaker
+	recorded bool
+}
+
+func (s *guardedStream) SendMsg(m any) error {
+	if s.stream == nil {
+		s.guard = s.breakers.Get(requestCollection(m))
+		if err := allow(s.ctx, s.guard); err != nil {
+			return err
+		}
+
+		stream, err := s.open()
+		if err != nil {
+			s.record(err)
+			return err
+		}
+		s.stream = stream
+	}
+	return s.stream.SendMsg(m)
+}
+
+func (s *guardedStream) RecvMsg(m any) error {
+	if s.stream == nil {
+		return io.EOF
+	}
+	err := s.stream.RecvMsg(m)
+	if errors.Is(err, io.EOF) {
+		s.record(nil)
+	} else {
+		s.record(err)
+	}
+	return err
+}
+
+func (s *guardedStream) record(err error) {
+	if !s.recorded {
+		s.recorded = true
+		s.guard.Record(retryable(err))
+	}
+}
+
+func (s *guardedStream) Header() (metadata.MD, error) {
+	if s.stream == nil {
+		return nil, nil
+	}
+	return s.stream.Header()
+}
+
+func (s *guardedStream) Trailer() metadata.MD {
+	if s.stream == nil {
+		return nil
+	}
+	return s.stream.Trailer()
+}
+
+func (s *guardedStream) CloseSend() error {
+	if s.stream == nil {
+		return nil
+	}
+	return s.stream.CloseSend()
+}
+
+func (s *guardedStream) Context() context.Context {
+	if s.stream == nil {
+		return s.ctx
+	}
+	return s.stream.Context()
+}
+
+// requestCollection is the ID of the collection a request reads or writes
+// first, or "firestore" for requests that don't name one.
+func requestCollection(request any) string {
+	var name string
+	switch req := request.(type) {
+	case *firestorepb.RunQueryRequest:
+		if from := req.GetStructuredQuery().GetFrom(); len(from) > 0 {
+			return from[0].GetCollectionId()
+		}
+	case *firestorepb.RunAggregationQueryRequest:
+		if from := req.GetStructuredAggregationQuery().GetStructuredQuery().GetFrom(); len(from) > 0 {
+			return from[0].GetCollectionId()
+		}
+	case *firestorepb.BatchGetDocumentsRequest:
+		if docs := req.GetDocuments(); len(docs) > 0 {
+			name = docs[0]
+		}
+	case *firestorepb.CommitRequest:
+		if writes := req.GetWrites(); len(writes) > 0 {
+			name = writes[0].GetUpdate().GetName() + writes[0].GetDelete()
+		}
+	}
+
+	if name == "" || !strings.Contains(name, "/documents/") {
+		return "firestore"
+	}
+	return path.Base(path.Dir(name))
+}
@@ -0,0 +1,151 @@
+package firestore
+
+import (
+	"context"
+	"sort"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/api/iterator"
+
+	"github.com/spalqui/habitattrack-api/internal/models"
+)
+
+// maxPendingBulkWrites bounds how many writes are queued before waiting for
+// them to finish, so large jobs don't hold every write in memory and the
+// BulkWriter's own rate limiting can keep up.
+const maxPendingBulkWrites = 500
+
+// bulkWrites queues writes on a BulkWriter and reports each one's outcome.
+// Writes aren't atomic as a group; callers get per-item results instead.
+type bulkWrites struct {
+	writer  *firestore.BulkWriter
+	pending []pendingWrite
+	result  models.BulkWriteResult
+	next    int
+}
+
+type pendingWrite struct {
+	index int
+	id    string
+	job   *firestore.BulkWriterJob
+}
+
+func newBulkWrites(ctx context.Context, client *firestore.Client) *bulkWrites {
+	return &bulkWrites{writer: client.BulkWriter(ctx)}
+}
+
+func (b *bulkWrites) create(index int, ref *firestore.DocumentRef, data interface{}) {
+	job, err := b.writer.Create(ref, data)
+	b.enqueue(index, ref.ID, job, err)
+}
+
+func (b *bulkWrites) set(index int, ref *firestore.DocumentRef, data interface{}) {
+	job, err := b.writer.Set(ref, data)
+	b.enqueue(index, ref.ID, job, err)
+}
+
+func (b *bulkWrites) delete(index int, ref *firestore.DocumentRef) {
+	job, err := b.writer.Delete(ref)
+	b.enqueue(index, ref.ID, job, err)
+}
+
+// record reports the outcome of an item written outside the BulkWriter.
+func (b *bulkWrites) record(index int, id string, err error) {
+	b.result.Add(index, id, err)
+}
+
+func (b *bulkWrites) enqueue(index int, id string, job *firestore.BulkWriterJob, err error) {
+	if err != nil {
+		b.record(index, id, err)
+		return
+	}
+
+	b.pending = append(b.pending, pendingWrite{index: index, id: id, job: job})
+	if len(b.pending) >= maxPendingBulkWrites {
+		b.writer.Flush()
+		b.collect()
+	}
+}
+
+func (b *bulkWrites) collect() {
+	for _, write := range b.pending {
+		_, err := write.job.Results()
+		b.result.Add(write.index, write.id, err)
+	}
+	b.pending = b.pending[:0]
+}
+
+// finish waits for every queued write and returns the outcomes in request
+// order.
+func (b *bulkWrites) finish() *models.BulkWriteResult {
+	b.writer.End()
+	b.collect()
+
+	sort.Slice(b.result.Items, func(i, j int) bool {
+		return b.result.Items[i].Index < b.result.Items[j].Index
+	})
+	return &b.result
+}
+
+// firstError waits for every queued write and returns the first failure, for
+// jobs that only care whether everything was written.
+func (b *bulkWrites) firstError() error {
+	return b.finish().Err()
+}
+
+// abort stops accepting writes after err; writes already queued still run.
+func (b *bulkWrites) abort(err error) error {
+	b.writer.End()
+	return err
+}
+
+// deleteRef queues a delete that isn't tied to a request item.
+func (b *bulkWrites) deleteRef(ref *firestore.DocumentRef) {
+	b.delete(b.next, ref)
+	b.next++
+}
+
+// deleteTree deletes the document and everything beneath it. Documents that
+// only exist as parents of subcollections are included.
+func (b *bulkWrites) deleteTree(ctx context.Context, doc *firestore.DocumentRef) error {
+	collections := doc.Collections(ctx)
+	for {
+		collection, err := collections.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		refs := collection.DocumentRefs(ctx)
+		for {
+			ref, err := refs.Next()
+			if err == iterator.Done {
+				break
+			}
+			if err != nil {
+				return err
+			}
+
+			if err := b.deleteTree(ctx, ref); err != nil {
+				return err
+			}
+		}
+	}
+
+	b.deleteRef(doc)
+	return nil
+}
+
+func (b *bulkWrites) deleteQuery(ctx context.Context, query firestore.Query) error {
+	docs, err := getAll(ctx, query.Select())
+	if err != nil {
+		return err
+	}
+
+	for _, doc := range docs {
+		b.deleteRef(doc.Ref)
+	}
+	return nil
+}
@@ -2,41 +2,80 @@ package firestore
 
 import (
 	"context"
-	"time"
+	"net/url"
+	"strings"
 
 	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	"github.com/spalqui/habitattrack-api/internal/models"
 	"github.com/spalqui/habitattrack-api/internal/repositories"
 )
 
 type categoryRepository struct {
-	client     *firestore.Client
-	collection string
+	client         *firestore.Client
+	collection     string
+	nameCollection string
 }
 
 func NewCategoryRepository(client *firestore.Client) repositories.CategoryRepository {
 	return &categoryRepository{
-		client:     client,
-		collection: "categories",
+		client:         client,
+		collection:     "categories",
+		nameCollection: "category_names",
 	}
 }
 
+// Create writes the category together with an index document keyed by its
+// type and name, in one transaction, so concurrent creates can't both claim
+// a name.
 func (r *categoryRepository) Create(ctx context.Context, category *models.Category) error {
-	category.CreatedAt = time.Now()
-	category.UpdatedAt = time.Now()
+	category.CreatedAt = storedNow()
+	category.UpdatedAt = storedNow()
 
-	docRef, _, err := r.client.Collection(r.collection).Add(ctx, category)
+	docRef := newDoc(tenantCollection(ctx, r.client, r.collection))
+	err := r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		if err := tx.Create(r.nameRef(ctx, category), map[string]interface{}{
+			"categoryId": docRef.ID,
+		}); err != nil {
+			return err
+		}
+
+		if err := tx.Set(docRef, category); err != nil {
+			return err
+		}
+
+		created := *category
+		created.ID = docRef.ID
+		return enqueueEvent(ctx, r.client, tx, models.EventCategoryCreated, &created)
+	})
+	if status.Code(err) == codes.AlreadyExists {
+		return repositories.ErrCategoryNameExists
+	}
 	if err != nil {
 		return err
 	}
 
 	category.ID = docRef.ID
-	return nil
+	return readBack(ctx, docRef, category)
+}
+
+// nameRef is the index document for the category's type and name. Names are
+// compared case-insensitively.
+func (r *categoryRepository) nameRef(ctx context.Context, category *models.Category) *firestore.DocumentRef {
+	return tenantCollection(ctx, r.client, r.nameCollection).Doc(categoryNameKey(category))
+}
+
+func categoryNameKey(category *models.Category) string {
+	return string(category.Type) + ":" + url.PathEscape(strings.ToLower(strings.TrimSpace(category.Name)))
 }
 
 func (r *categoryRepository) GetByID(ctx context.Context, id string) (*models.Category, error) {
-	doc, err := r.client.Collection(r.collection).Doc(id).Get(ctx)
+	doc, err := getDoc(ctx, tenantCollection(ctx, r.client, r.collection).Doc(id))
+	if status.Code(err) == codes.NotFound {
+		return nil, repositories.ErrCategoryNotFound
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -50,8 +89,33 @@ func (r *categoryRepository) GetByID(ctx context.Context, id string) (*models.Ca
 	return &category, nil
 }
 
+// GetByIDs reads the categories in one round trip, keyed by ID. IDs that
+// don't exist are left out.
+func (r *categoryRepository) GetByIDs(ctx context.Context, ids []string) (map[string]*models.Category, error) {
+	docs, err := getDocs(ctx, r.client, idRefs(tenantCollection(ctx, r.client, r.collection), ids))
+	if err != nil {
+		return nil, err
+	}
+
+	categories := make(map[string]*models.Category, len(docs))
+	for _, doc := range docs {
+		if !doc.Exists() {
+			continue
+		}
+
+		var category models.Category
+		if err := doc.DataTo(&category); err != nil {
+			return nil, err
+		}
+		category.ID = doc.Ref.ID
+		categories[category.ID] = &category
+	}
+
+	return categories, nil
+}
+
 func (r *categoryRepository) GetAll(ctx context.Context) ([]*models.Category, error) {
-	docs, err := r.client.Collection(r.collection).Documents(ctx).GetAll()
+	docs, err := getAll(ctx, tenantCollection(ctx, r.client, r.collection))
 	if err != nil {
 		return nil, err
 	}
@@ -70,7 +134,7 @@ func (r *categoryRepository) GetAll(ctx context.Context) ([]*models.Category, er
 }
 
 func (r *categoryRepository) GetByType(ctx context.Context, transactionType models.TransactionType) ([]*models.Category, error) {
-	docs, err := r.client.Collection(r.collection).Where("type", "==", string(transactionType)).Documents(ctx).GetAll()
+	docs, err := getAll(ctx, tenantCollection(ctx, r.client, r.collection).Where("type", "==", string(transactionType)))
 	if err != nil {
 		return nil, err
 	}
@@ -88,13 +152,118 @@ func (r *categoryRepository) GetByType(ctx context.Context, transactionType mode
 	return categories, nil
 }
 
+func (r *categoryRepository) Count(ctx context.Context) (int64, error) {
+	return countQuery(ctx, tenantCollection(ctx, r.client, r.collection).Query)
+}
+
+func (r *categoryRepository) ListPage(ctx context.Context, transactionType models.TransactionType, page models.PageRequest) (*models.Page[*models.Category], error) {
+	query, err := pageQuery(ctx, r.typeQuery(ctx, transactionType), tenantCollection(ctx, r.client, r.collection), page)
+	if err != nil {
+		return nil, err
+	}
+
+	docs, err := getAll(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	docs, next, prev := pageCursors(docs, page)
+
+	categories := make([]*models.Category, len(docs))
+	for i, doc := range docs {
+		var category models.Category
+		if err := doc.DataTo(&category); err != nil {
+			return nil, err
+		}
+		category.ID = doc.Ref.ID
+		categories[i] = &category
+	}
+
+	return &models.Page[*models.Category]{Items: categories, NextCursor: next, PrevCursor: prev}, nil
+}
+
+func (r *categoryRepository) CountByType(ctx context.Context, transactionType models.TransactionType) (int64, error) {
+	return countQuery(ctx, r.typeQuery(ctx, transactionType))
+}
+
+// typeQuery selects categories of the given type, or all of them when the
+// type is empty.
+func (r *categoryRepository) typeQuery(ctx context.Context, transactionType models.TransactionType) firestore.Query {
+	query := tenantCollection(ctx, r.client, r.collection).Query
+	if transactionType != "" {
+		query = query.Where("type", "==", string(transactionType))
+	}
+	return query
+}
+
+// Update moves the name index document when the name or type changes. Like
+// the other updates, it refuses a category updated since the version whose
+// UpdatedAt it carries.
 func (r *categoryRepository) Update(ctx context.Context, category *models.Category) error {
-	category.UpdatedAt = time.Now()
-	_, err := r.client.Collection(r.collection).Doc(category.ID).Set(ctx, category)
+	expected := category.UpdatedAt
+	category.UpdatedAt = storedNow()
+	docRef := tenantCollection(ctx, r.client, r.collection).Doc(category.ID)
+
+	err := r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(docRef)
+		if status.Code(err) == codes.NotFound {
+			return repositories.ErrCategoryNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		var existing models.Category
+		if err := doc.DataTo(&existing); err != nil {
+			return err
+		}
+		if err := checkUnchanged(existing.UpdatedAt, expected); err != nil {
+			return err
+		}
+
+		oldName, newName := r.nameRef(ctx, &existing), r.nameRef(ctx, category)
+		if oldName.ID != newName.ID {
+			if err := tx.Create(newName, map[string]interface{}{"categoryId": category.ID}); err != nil {
+				return err
+			}
+			if err := tx.Delete(oldName); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Update(docRef, fieldUpdates(category, "createdAt")); err != nil {
+			return err
+		}
+		return enqueueEvent(ctx, r.client, tx, models.EventCategoryUpdated, category)
+	})
+	if status.Code(err) == codes.AlreadyExists {
+		return repositories.ErrCategoryNameExists
+	}
 	return err
 }
 
 func (r *categoryRepository) Delete(ctx context.Context, id string) error {
-	_, err := r.client.Collection(r.collection).Doc(id).Delete(ctx)
-	return err
+	docRef := tenantCollection(ctx, r.client, r.collection).Doc(id)
+
+	return r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(docRef)
+		if status.Code(err) == codes.NotFound {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var category models.Category
+		if err := doc.DataTo(&category); err != nil {
+			return err
+		}
+
+		if err := tx.Delete(r.nameRef(ctx, &category)); err != nil {
+			return err
+		}
+		if err := enqueueEvent(ctx, r.client, tx, models.EventCategoryDeleted, map[string]string{"id": id}); err != nil {
+			return err
+		}
+		return tx.Delete(docRef)
+	})
 }
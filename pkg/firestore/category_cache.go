@@ -0,0 +1,124 @@
+package firestore
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/spalqui/habitattrack-api/internal/models"
+	"github.com/spalqui/habitattrack-api/internal/repositories"
+	"github.com/spalqui/habitattrack-api/pkg/auth"
+)
+
+// cachedCategoryRepository keeps categories read by ID in memory, since every
+// transaction write looks its category up. Entries are per organization and
+// dropped on update or delete; writes made by other instances show up once
+// the TTL runs out.
+type cachedCategoryRepository struct {
+	repositories.CategoryRepository
+	ttl time.Duration
+
+	mu      sync.Mutex
+	entries map[string]cachedCategory
+}
+
+type cachedCategory struct {
+	category models.Category
+	expires  time.Time
+}
+
+// NewCachedCategoryRepository wraps repo with a cache of categories by ID. A
+// non-positive ttl disables caching.
+func NewCachedCategoryRepository(repo repositories.CategoryRepository, ttl time.Duration) repositories.CategoryRepository {
+	if ttl <= 0 {
+		return repo
+	}
+
+	return &cachedCategoryRepository{
+		CategoryRepository: repo,
+		ttl:                ttl,
+		entries:            make(map[string]cachedCategory),
+	}
+}
+
+func (r *cachedCategoryRepository) GetByID(ctx context.Context, id string) (*models.Category, error) {
+	key := categoryCacheKey(ctx, id)
+
+	r.mu.Lock()
+	entry, ok := r.entries[key]
+	r.mu.Unlock()
+	if ok && time.Now().Before(entry.expires) {
+		category := entry.category
+		return &category, nil
+	}
+
+	category, err := r.CategoryRepository.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	r.entries[key] = cachedCategory{category: *category, expires: time.Now().Add(r.ttl)}
+	r.mu.Unlock()
+
+	return category, nil
+}
+
+// GetByIDs serves what it can from the cache and reads the rest in one
+// round trip.
+func (r *cachedCategoryRepository) GetByIDs(ctx context.Context, ids []string) (map[string]*models.Category, error) {
+	categories := make(map[string]*models.Category, len(ids))
+	var missing []string
+
+	r.mu.Lock()
+	now := time.Now()
+	for _, id := range ids {
+		entry, ok := r.entries[categoryCacheKey(ctx, id)]
+		if ok && now.Before(entry.expires) {
+			category := entry.category
+			categories[id] = &category
+		} else {
+			missing = append(missing, id)
+		}
+	}
+	r.mu.Unlock()
+
+	if len(missing) == 0 {
+		return categories, nil
+	}
+
+	fetched, err := r.CategoryRepository.GetByIDs(ctx, missing)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	expires := time.Now().Add(r.ttl)
+	for id, category := range fetched {
+		r.entries[categoryCacheKey(ctx, id)] = cachedCategory{category: *category, expires: expires}
+		categories[id] = category
+	}
+	r.mu.Unlock()
+
+	return categories, nil
+}
+
+func (r *cachedCategoryRepository) Update(ctx context.Context, category *models.Category) error {
+	defer r.invalidate(ctx, category.ID)
+	return r.CategoryRepository.Update(ctx, category)
+}
+
+func (r *cachedCategoryRepository) Delete(ctx context.Context, id string) error {
+	defer r.invalidate(ctx, id)
+	return r.CategoryRepository.Delete(ctx, id)
+}
+
+func (r *cachedCategoryRepository) invalidate(ctx context.Context, id string) {
+	r.mu.Lock()
+	delete(r.entries, categoryCacheKey(ctx, id))
+	r.mu.Unlock()
+}
+
+func categoryCacheKey(ctx context.Context, id string) string {
+	return auth.OrgID(ctx) + "/" + id
+}
@@ -0,0 +1,44 @@
+package firestore
+
+import (
+	"context"
+	"time"
+
+	"cloud.google.com/go/firestore"
+
+	"github.com/spalqui/habitattrack-api/internal/repositories"
+)
+
+// cleanupRepository queries collection groups, so each organization's
+// records are found without knowing the organizations. The queries need
+// collection group indexes on deleted_transactions.deletedAt and on
+// webhook_deliveries (success, createdAt).
+type cleanupRepository struct {
+	client *firestore.Client
+}
+
+func NewCleanupRepository(client *firestore.Client) repositories.CleanupRepository {
+	return &cleanupRepository{client: client}
+}
+
+func (r *cleanupRepository) PurgeDeletedTransactions(ctx context.Context, olderThan time.Time) (int, error) {
+	return r.purge(ctx, r.client.CollectionGroup("deleted_transactions").Where("deletedAt", "<", olderThan))
+}
+
+// PurgeWebhookDeliveries removes the logs of successful deliveries; failed
+// ones are kept for investigating the receiver.
+func (r *cleanupRepository) PurgeWebhookDeliveries(ctx context.Context, olderThan time.Time) (int, error) {
+	return r.purge(ctx, r.client.CollectionGroup("webhook_deliveries").
+		Where("success", "==", true).
+		Where("createdAt", "<", olderThan))
+}
+
+func (r *cleanupRepository) purge(ctx context.Context, query firestore.Query) (int, error) {
+	deletes := newBulkWrites(ctx, r.client)
+	if err := deletes.deleteQuery(ctx, query); err != nil {
+		return 0, deletes.abort(err)
+	}
+
+	result := deletes.finish()
+	return result.Succeeded, result.Err()
+}
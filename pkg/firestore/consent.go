@@ -0,0 +1,80 @@
+package firestore
+
+import (
+	"context"
+	"net/url"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/spalqui/habitattrack-api/internal/models"
+	"github.com/spalqui/habitattrack-api/internal/repositories"
+)
+
+type consentRepository struct {
+	client     *firestore.Client
+	collection string
+}
+
+func NewConsentRepository(client *firestore.Client) repositories.ConsentRepository {
+	return &consentRepository{
+		client:     client,
+		collection: "consents",
+	}
+}
+
+// Save keeps one record per user, document and version, so accepting the
+// same version twice keeps the original acceptance.
+func (r *consentRepository) Save(ctx context.Context, consent *models.Consent) error {
+	docRef := r.ref(consent.UserID, consent.Document, consent.Version)
+
+	_, err := docRef.Create(ctx, consent)
+	if status.Code(err) == codes.AlreadyExists {
+		doc, err := getDoc(ctx, docRef)
+		if err != nil {
+			return err
+		}
+		if err := doc.DataTo(consent); err != nil {
+			return err
+		}
+	} else if err != nil {
+		return err
+	}
+
+	consent.ID = docRef.ID
+	return nil
+}
+
+func (r *consentRepository) Exists(ctx context.Context, userID, document, version string) (bool, error) {
+	_, err := getDoc(ctx, r.ref(userID, document, version))
+	if status.Code(err) == codes.NotFound {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (r *consentRepository) GetByUserID(ctx context.Context, userID string) ([]*models.Consent, error) {
+	docs, err := getAll(ctx, r.client.Collection(r.collection).
+		Where("userId", "==", userID).
+		OrderBy("acceptedAt", firestore.Desc))
+	if err != nil {
+		return nil, err
+	}
+
+	consents := make([]*models.Consent, len(docs))
+	for i, doc := range docs {
+		var consent models.Consent
+		if err := doc.DataTo(&consent); err != nil {
+			return nil, err
+		}
+		consent.ID = doc.Ref.ID
+		consents[i] = &consent
+	}
+
+	return consents, nil
+}
+
+func (r *consentRepository) ref(userID, document, version string) *firestore.DocumentRef {
+	return r.client.Collection(r.collection).Doc(url.QueryEscape(userID) + ":" + document + ":" + url.QueryEscape(version))
+}
@@ -0,0 +1,84 @@
+package firestore
+
+import (
+	"context"
+	"time"
+
+	"cloud.google.com/go/firestore"
+
+	"github.com/spalqui/habitattrack-api/internal/models"
+	"github.com/spalqui/habitattrack-api/internal/repositories"
+)
+
+// maxDeadLetters caps a single listing; narrow with filters for older ones.
+const maxDeadLetters = 500
+
+// deadLetterRepository keeps every organization's dead letters in one root
+// collection, each recording its organization.
+type deadLetterRepository struct {
+	client     *firestore.Client
+	collection string
+}
+
+func NewDeadLetterRepository(client *firestore.Client) repositories.DeadLetterRepository {
+	return &deadLetterRepository{
+		client:     client,
+		collection: "dead_letters",
+	}
+}
+
+func (r *deadLetterRepository) Create(ctx context.Context, deadLetter *models.DeadLetter) error {
+	deadLetter.CreatedAt = time.Now()
+
+	docRef := newDoc(r.client.Collection(r.collection))
+	if _, err := docRef.Create(ctx, deadLetter); err != nil {
+		return err
+	}
+
+	deadLetter.ID = docRef.ID
+	return nil
+}
+
+func (r *deadLetterRepository) GetByID(ctx context.Context, id string) (*models.DeadLetter, error) {
+	doc, err := getDoc(ctx, r.client.Collection(r.collection).Doc(id))
+	if err != nil {
+		return nil, err
+	}
+
+	var deadLetter models.DeadLetter
+	if err := doc.DataTo(&deadLetter); err != nil {
+		return nil, err
+	}
+
+	deadLetter.ID = doc.Ref.ID
+	return &deadLetter, nil
+}
+
+func (r *deadLetterRepository) List(ctx context.Context, filter models.DeadLetterFilter) ([]*models.DeadLetter, error) {
+	query := r.client.Collection(r.collection).Query
+	query = scopeBy(query, "kind", filter.Kind)
+	query = scopeBy(query, "webhookId", filter.WebhookID)
+	query = scopeBy(query, "orgId", filter.OrgID)
+
+	docs, err := getAll(ctx, query.OrderBy("createdAt", firestore.Desc).Limit(maxDeadLetters))
+	if err != nil {
+		return nil, err
+	}
+
+	deadLetters := make([]*models.DeadLetter, len(docs))
+	for i, doc := range docs {
+		var deadLetter models.DeadLetter
+		if err := doc.DataTo(&deadLetter); err != nil {
+			return nil, err
+		}
+		deadLetter.ID = doc.Ref.ID
+		deadLetters[i] = &deadLetter
+	}
+
+	return deadLetters, nil
+}
+
+func (r *deadLetterRepository) Delete(ctx context.Context, id string) error {
+	_, err := r.client.Collection(r.collection).Doc(id).Delete(ctx)
+	return err
+}
@@ -0,0 +1,65 @@
+package firestore
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Ping reads a document that needn't exist, proving Firestore is reachable
+// and the credentials work for the cost of one read. It isn't retried, so an
+// outage shows up straight away.
+func Ping(ctx context.Context, client *firestore.Client) error {
+	_, err := client.Collection("health").Doc("ping").Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return nil
+	}
+	return err
+}
+
+// CheckIndexes runs one query per composite or collection group index the
+// repositories rely on, reporting those Firestore says are missing. Index
+// definitions apply to every collection with the same ID, so querying the
+// root collections is enough.
+func CheckIndexes(ctx context.Context, client *firestore.Client) error {
+	now := time.Now()
+	queries := map[string]firestore.Query{
+		"transactions (propertyId, date)": client.Collection("transactions").
+			Where("propertyId", "==", "").Where("date", ">=", now).OrderBy("date", firestore.Asc),
+		"audit_events (actor, createdAt)": client.Collection("audit_events").
+			Where("actor", "==", "").OrderBy("createdAt", firestore.Desc),
+		"consents (userId, acceptedAt)": client.Collection("consents").
+			Where("userId", "==", "").OrderBy("acceptedAt", firestore.Desc),
+		rollupsCollection + " (propertyId, month)": client.Collection(rollupsCollection).
+			Where("propertyId", "==", "").Where("month", ">=", "").OrderBy("month", firestore.Asc),
+		"deleted_transactions collection group (deletedAt)": client.CollectionGroup("deleted_transactions").
+			Where("deletedAt", "<", now),
+		"webhook_deliveries collection group (success, createdAt)": client.CollectionGroup("webhook_deliveries").
+			Where("success", "==", true).Where("createdAt", "<", now),
+	}
+
+	var missing []string
+	for name, query := range queries {
+		_, err := query.Limit(1).Documents(ctx).Next()
+		switch {
+		case err == nil, err == iterator.Done:
+		case status.Code(err) == codes.FailedPrecondition:
+			missing = append(missing, name)
+		default:
+			return err
+		}
+	}
+
+	if len(missing) > 0 {
+		slices.Sort(missing)
+		return fmt.Errorf("missing indexes: %s", strings.Join(missing, "; "))
+	}
+	return nil
+}
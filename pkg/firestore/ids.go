@@ -0,0 +1,13 @@
+package firestore
+
+import (
+	"cloud.google.com/go/firestore"
+	"github.com/google/uuid"
+)
+
+// newDoc references a new document in collection under a UUIDv7, which
+// starts with its creation time, so IDs sort in the order documents were
+// created. Like NewDoc, it panics if the system's random source fails.
+func newDoc(collection *firestore.CollectionRef) *firestore.DocumentRef {
+	return collection.Doc(uuid.Must(uuid.NewV7()).String())
+}
@@ -0,0 +1,77 @@
+package firestore
+
+import (
+	"context"
+
+	"cloud.google.com/go/firestore"
+
+	"github.com/spalqui/habitattrack-api/internal/models"
+	"github.com/spalqui/habitattrack-api/internal/repositories"
+)
+
+type impersonationRepository struct {
+	client     *firestore.Client
+	collection string
+}
+
+func NewImpersonationRepository(client *firestore.Client) repositories.ImpersonationRepository {
+	return &impersonationRepository{
+		client:     client,
+		collection: "impersonations",
+	}
+}
+
+// Create stores the session under impersonation.ID when it is set, since the
+// ID is embedded in the session's token before it is saved.
+func (r *impersonationRepository) Create(ctx context.Context, impersonation *models.Impersonation) error {
+	docRef := newDoc(r.client.Collection(r.collection))
+	if impersonation.ID != "" {
+		docRef = r.client.Collection(r.collection).Doc(impersonation.ID)
+	}
+
+	if _, err := docRef.Create(ctx, impersonation); err != nil {
+		return err
+	}
+
+	impersonation.ID = docRef.ID
+	return nil
+}
+
+func (r *impersonationRepository) GetByID(ctx context.Context, id string) (*models.Impersonation, error) {
+	doc, err := getDoc(ctx, r.client.Collection(r.collection).Doc(id))
+	if err != nil {
+		return nil, err
+	}
+
+	var impersonation models.Impersonation
+	if err := doc.DataTo(&impersonation); err != nil {
+		return nil, err
+	}
+
+	impersonation.ID = doc.Ref.ID
+	return &impersonation, nil
+}
+
+func (r *impersonationRepository) GetAll(ctx context.Context) ([]*models.Impersonation, error) {
+	docs, err := getAll(ctx, r.client.Collection(r.collection).OrderBy("createdAt", firestore.Desc))
+	if err != nil {
+		return nil, err
+	}
+
+	impersonations := make([]*models.Impersonation, len(docs))
+	for i, doc := range docs {
+		var impersonation models.Impersonation
+		if err := doc.DataTo(&impersonation); err != nil {
+			return nil, err
+		}
+		impersonation.ID = doc.Ref.ID
+		impersonations[i] = &impersonation
+	}
+
+	return impersonations, nil
+}
+
+func (r *impersonationRepository) Update(ctx context.Context, impersonation *models.Impersonation) error {
+	_, err := r.client.Collection(r.collection).Doc(impersonation.ID).Update(ctx, fieldUpdates(impersonation, "createdAt"))
+	return err
+}
@@ -0,0 +1,182 @@
+package firestore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/firestore/apiv1/firestorepb"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+
+	"github.com/spalqui/habitattrack-api/pkg/servertiming"
+)
+
+// writeMethods are the RPCs that change documents; the rest read.
+var writeMethods = []string{"Commit", "BatchWrite", "CreateDocument", "UpdateDocument", "DeleteDocument"}
+
+// Instrument returns client options that time every Firestore call. The
+// time counts towards the request's Server-Timing reads or writes, and
+// calls taking longer than slowThreshold, unless it's zero, are logged with
+// the shape of the request: collections, filtered and ordered fields and
+// limit, but no values. Streamed calls, such as queries, are timed until
+// the last result is read.
+func Instrument(slowThreshold time.Duration, logger *slog.Logger) []option.ClientOption {
+	observe := func(ctx context.Context, method string, request any, elapsed time.Duration, err error) {
+		metric := servertiming.FirestoreRead
+		if slices.Contains(writeMethods, path.Base(method)) {
+			metric = servertiming.FirestoreWrite
+		}
+		servertiming.FromContext(ctx).Add(metric, elapsed)
+
+		if slowThreshold <= 0 || elapsed < slowThreshold {
+			return
+		}
+		attrs := []slog.Attr{
+			slog.String("method", path.Base(method)),
+			slog.String("shape", requestShape(request)),
+			slog.Float64("durationMs", float64(elapsed.Microseconds())/1000),
+		}
+		if err != nil {
+			attrs = append(attrs, slog.String("error", err.Error()))
+		}
+		logger.LogAttrs(ctx, slog.LevelWarn, "slow Firestore call", attrs...)
+	}
+
+	return []option.ClientOption{
+		option.WithGRPCDialOption(grpc.WithChainUnaryInterceptor(
+			func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+				start := time.Now()
+				err := invoker(ctx, method, req, reply, cc, opts...)
+				observe(ctx, method, req, time.Since(start), err)
+				return err
+			},
+		)),
+		option.WithGRPCDialOption(grpc.WithChainStreamInterceptor(
+			func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+				start := time.Now()
+				stream, err := streamer(ctx, desc, cc, method, opts...)
+				if err != nil {
+					observe(ctx, method, nil, time.Since(start), err)
+					return nil, err
+				}
+				return &timedStream{ClientStream: stream, done: func(request any, err error) {
+					observe(ctx, method, request, time.Since(start), err)
+				}}, nil
+			},
+		)),
+	}
+}
+
+// timedStream reports once the stream ends, with the first request sent on
+// it.
+type timedStream struct {
+	grpc.ClientStream
+	request  any
+	done     func(request any, err error)
+	finished bool
+}
+
+func (s *timedStream) SendMsg(m any) error {
+	if s.request == nil {
+		s.request = m
+	}
+	return s.ClientStream.SendMsg(m)
+}
+
+func (s *timedStream) RecvMsg(m any) error {
+	err := s.ClientStream.RecvMsg(m)
+	if err != nil && !s.finished {
+		s.finished = true
+		if errors.Is(err, io.EOF) {
+			s.done(s.request, nil)
+		} else {
+			s.done(s.request, err)
+		}
+	}
+	return err
+}
+
+// requestShape describes a request without the values in it, so slow calls
+// with the same shape can be grouped.
+func requestShape(request any) string {
+	switch req := request.(type) {
+	case *firestorepb.RunQueryRequest:
+		return queryShape(req.GetStructuredQuery())
+	case *firestorepb.RunAggregationQueryRequest:
+		return "count " + queryShape(req.GetStructuredAggregationQuery().GetStructuredQuery())
+	case *firestorepb.BatchGetDocumentsRequest:
+		collections := map[string]bool{}
+		for _, name := range req.GetDocuments() {
+			collections[path.Base(path.Dir(name))] = true
+		}
+		names := make([]string, 0, len(collections))
+		for name := range collections {
+			names = append(names, name)
+		}
+		return fmt.Sprintf("get %d documents from %s", len(req.GetDocuments()), strings.Join(names, ", "))
+	case *firestorepb.CommitRequest:
+		return fmt.Sprintf("commit %d writes", len(req.GetWrites()))
+	case nil:
+		return ""
+	default:
+		return fmt.Sprintf("%T", request)
+	}
+}
+
+func queryShape(query *firestorepb.StructuredQuery) string {
+	var shape strings.Builder
+	for i, from := range query.GetFrom() {
+		if i > 0 {
+			shape.WriteString(", ")
+		}
+		shape.WriteString(from.GetCollectionId())
+		if from.GetAllDescendants() {
+			shape.WriteString(" (collection group)")
+		}
+	}
+
+	if where := filterShape(query.GetWhere()); where != "" {
+		shape.WriteString(" where " + where)
+	}
+
+	for i, order := range query.GetOrderBy() {
+		if i == 0 {
+			shape.WriteString(" order by ")
+		} else {
+			shape.WriteString(", ")
+		}
+		shape.WriteString(order.GetField().GetFieldPath() + " " + order.GetDirection().String())
+	}
+
+	if limit := query.GetLimit(); limit != nil {
+		fmt.Fprintf(&shape, " limit %d", limit.GetValue())
+	}
+	return shape.String()
+}
+
+func filterShape(filter *firestorepb.StructuredQuery_Filter) string {
+	switch {
+	case filter.GetFieldFilter() != nil:
+		field := filter.GetFieldFilter()
+		return field.GetField().GetFieldPath() + " " + field.GetOp().String()
+	case filter.GetUnaryFilter() != nil:
+		unary := filter.GetUnaryFilter()
+		return unary.GetField().GetFieldPath() + " " + unary.GetOp().String()
+	case filter.GetCompositeFilter() != nil:
+		composite := filter.GetCompositeFilter()
+		parts := make([]string, len(composite.GetFilters()))
+		for i, part := range composite.GetFilters() {
+			parts[i] = filterShape(part)
+		}
+		return strings.Join(parts, " "+composite.GetOp().String()+" ")
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,64 @@
+package firestore
+
+import (
+	"context"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/spalqui/habitattrack-api/internal/repositories"
+)
+
+// jobLockRepository keeps one document per job, shared by every instance,
+// holding the last occurrence claimed.
+type jobLockRepository struct {
+	client     *firestore.Client
+	collection string
+}
+
+func NewJobLockRepository(client *firestore.Client) repositories.JobLockRepository {
+	return &jobLockRepository{
+		client:     client,
+		collection: "job_locks",
+	}
+}
+
+type jobLock struct {
+	Occurrence time.Time `firestore:"occurrence"`
+	Owner      string    `firestore:"owner"`
+	ClaimedAt  time.Time `firestore:"claimedAt"`
+}
+
+func (r *jobLockRepository) Claim(ctx context.Context, job string, occurrence time.Time, owner string) (bool, error) {
+	docRef := r.client.Collection(r.collection).Doc(job)
+
+	claimed := false
+	err := r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		claimed = false
+
+		doc, err := tx.Get(docRef)
+		switch {
+		case status.Code(err) == codes.NotFound:
+		case err != nil:
+			return err
+		default:
+			var lock jobLock
+			if err := doc.DataTo(&lock); err != nil {
+				return err
+			}
+			if !occurrence.After(lock.Occurrence) {
+				return nil
+			}
+		}
+
+		claimed = true
+		return tx.Set(docRef, &jobLock{Occurrence: occurrence, Owner: owner, ClaimedAt: time.Now()})
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return claimed, nil
+}
@@ -0,0 +1,50 @@
+package firestore
+
+import (
+	"context"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/spalqui/habitattrack-api/internal/models"
+	"github.com/spalqui/habitattrack-api/internal/repositories"
+)
+
+// maintenanceRepository keeps the switch in a single document shared by
+// every organization and instance.
+type maintenanceRepository struct {
+	client     *firestore.Client
+	collection string
+}
+
+func NewMaintenanceRepository(client *firestore.Client) repositories.MaintenanceRepository {
+	return &maintenanceRepository{
+		client:     client,
+		collection: "system",
+	}
+}
+
+// Get reports maintenance as disabled until it's first been set.
+func (r *maintenanceRepository) Get(ctx context.Context) (*models.Maintenance, error) {
+	doc, err := getDoc(ctx, r.client.Collection(r.collection).Doc("maintenance"))
+	if status.Code(err) == codes.NotFound {
+		return &models.Maintenance{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var maintenance models.Maintenance
+	if err := doc.DataTo(&maintenance); err != nil {
+		return nil, err
+	}
+	return &maintenance, nil
+}
+
+func (r *maintenanceRepository) Save(ctx context.Context, maintenance *models.Maintenance) error {
+	maintenance.UpdatedAt = time.Now()
+	_, err := r.client.Collection(r.collection).Doc("maintenance").Set(ctx, maintenance)
+	return err
+}
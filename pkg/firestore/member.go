@@ -0,0 +1,137 @@
+package firestore
+
+import (
+	"context"
+	"time"
+
+	"cloud.google.com/go/firestore"
+
+	"github.com/spalqui/habitattrack-api/internal/models"
+	"github.com/spalqui/habitattrack-api/internal/repositories"
+)
+
+type memberRepository struct {
+	client     *firestore.Client
+	collection string
+}
+
+func NewMemberRepository(client *firestore.Client) repositories.MemberRepository {
+	return &memberRepository{
+		client:     client,
+		collection: "members",
+	}
+}
+
+func (r *memberRepository) Create(ctx context.Context, member *models.Member) error {
+	member.CreatedAt = time.Now()
+	member.UpdatedAt = time.Now()
+
+	docRef := newDoc(r.client.Collection(r.collection))
+	if _, err := docRef.Create(ctx, member); err != nil {
+		return err
+	}
+
+	member.ID = docRef.ID
+	return nil
+}
+
+func (r *memberRepository) GetByID(ctx context.Context, id string) (*models.Member, error) {
+	doc, err := getInOrg(ctx, r.client.Collection(r.collection).Doc(id))
+	if err != nil {
+		return nil, err
+	}
+
+	var member models.Member
+	if err := doc.DataTo(&member); err != nil {
+		return nil, err
+	}
+
+	member.ID = doc.Ref.ID
+	return &member, nil
+}
+
+func (r *memberRepository) GetAll(ctx context.Context) ([]*models.Member, error) {
+	docs, err := getAll(ctx, orgScope(ctx, r.client.Collection(r.collection).Query))
+	if err != nil {
+		return nil, err
+	}
+
+	return membersFromDocs(docs)
+}
+
+// GetByOrgAndUser resolves access before the request's organization is
+// known, so it is not scoped to the caller. It returns nil when there is no
+// match.
+func (r *memberRepository) GetByOrgAndUser(ctx context.Context, orgID, userID string) (*models.Member, error) {
+	docs, err := getAll(ctx, r.client.Collection(r.collection).
+		Where("orgId", "==", orgID).
+		Where("userId", "==", userID).
+		Limit(1))
+	if err != nil {
+		return nil, err
+	}
+
+	members, err := membersFromDocs(docs)
+	if err != nil || len(members) == 0 {
+		return nil, err
+	}
+	return members[0], nil
+}
+
+// GetByUserID lists a user's memberships across all organizations.
+func (r *memberRepository) GetByUserID(ctx context.Context, userID string) ([]*models.Member, error) {
+	docs, err := getAll(ctx, r.client.Collection(r.collection).Where("userId", "==", userID))
+	if err != nil {
+		return nil, err
+	}
+
+	return membersFromDocs(docs)
+}
+
+// GetByOrgID lists an organization's members regardless of the caller's
+// organization.
+func (r *memberRepository) GetByOrgID(ctx context.Context, orgID string) ([]*models.Member, error) {
+	docs, err := getAll(ctx, r.client.Collection(r.collection).Where("orgId", "==", orgID))
+	if err != nil {
+		return nil, err
+	}
+
+	return membersFromDocs(docs)
+}
+
+func (r *memberRepository) Update(ctx context.Context, member *models.Member) error {
+	docRef := r.client.Collection(r.collection).Doc(member.ID)
+	doc, err := getInOrg(ctx, docRef)
+	if err != nil {
+		return err
+	}
+
+	member.OrgID = orgOf(doc)
+	member.UpdatedAt = time.Now()
+	_, err = docRef.Update(ctx, fieldUpdates(member, "createdAt", "orgId"))
+	return err
+}
+
+func (r *memberRepository) Delete(ctx context.Context, id string) error {
+	docRef := r.client.Collection(r.collection).Doc(id)
+	if _, err := getInOrg(ctx, docRef); err != nil {
+		return err
+	}
+
+	_, err := docRef.Delete(ctx)
+	return err
+}
+
+func membersFromDocs(docs []*firestore.DocumentSnapshot) ([]*models.Member, error) {
+	members := make([]*models.Member, len(docs))
+	for i, doc := range docs {
+		var member models.Member
+		if err := doc.DataTo(&member); err != nil {
+			return nil, err
+		}
+		member.ID = doc.Ref.ID
+		members[i] = &member
+	}
+
+	return members, nil
+}
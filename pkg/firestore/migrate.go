@@ -0,0 +1,154 @@
+package firestore
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/spalqui/habitattrack-api/internal/models"
+)
+
+// legacyCollections are the root collections that moved under
+// orgs/{orgId} when data became tenant-scoped.
+var legacyCollections = []string{
+	"properties",
+	"categories",
+	"transactions",
+	"deleted_transactions",
+	"transaction_external_ids",
+	"webhooks",
+	"webhook_deliveries",
+	"audit_events",
+	rollupsCollection,
+}
+
+// legacyCopyRef records that the legacy collections have been copied into an
+// organization.
+func legacyCopyRef(client *firestore.Client) *firestore.DocumentRef {
+	return client.Collection("system").Doc("legacy_copy")
+}
+
+// MigrationProgress is called as documents are copied.
+type MigrationProgress func(collection string, copied int)
+
+// migrationProgressInterval is how many documents are copied between
+// progress reports.
+const migrationProgressInterval = 500
+
+// CopyLegacyCollections copies the documents of the root collections used
+// before tenant scoping into the organization's collections, keeping their
+// IDs, and returns how many were copied from each. Source documents are left
+// in place and rerunning overwrites earlier copies. With dryRun nothing is
+// written and the counts are what would be copied.
+//
+// Categories and named properties also get the name index documents that
+// creating them now writes. Property totals aren't copied; they are
+// recomputed on first read. A completed copy is recorded, which satisfies
+// CheckLegacyData.
+func CopyLegacyCollections(ctx context.Context, client *firestore.Client, orgID string, dryRun bool, progress MigrationProgress) (map[string]int, error) {
+	target := client.Collection("orgs").Doc(orgID)
+	counts := make(map[string]int)
+
+	for _, name := range legacyCollections {
+		writes := newBulkWrites(ctx, client)
+		copied := 0
+
+		docs := client.Collection(name).Documents(ctx)
+		for {
+			doc, err := docs.Next()
+			if err == iterator.Done {
+				break
+			}
+			if err != nil {
+				docs.Stop()
+				return counts, writes.abort(err)
+			}
+
+			if !dryRun {
+				writes.set(copied, target.Collection(name).Doc(doc.Ref.ID), doc.Data())
+
+				if name == "categories" {
+					var category models.Category
+					if err := doc.DataTo(&category); err != nil {
+						docs.Stop()
+						return counts, writes.abort(err)
+					}
+					writes.set(copied, target.Collection("category_names").Doc(categoryNameKey(&category)), map[string]interface{}{
+						"categoryId": doc.Ref.ID,
+					})
+				}
+
+				if name == "properties" {
+					var property models.Property
+					if err := doc.DataTo(&property); err != nil {
+						docs.Stop()
+						return counts, writes.abort(err)
+					}
+					if property.Name != "" {
+						writes.set(copied, target.Collection("property_names").Doc(propertyNameKey(property.Name)), map[string]interface{}{
+							"propertyId": doc.Ref.ID,
+						})
+					}
+				}
+			}
+
+			copied++
+			if progress != nil && copied%migrationProgressInterval == 0 {
+				progress(name, copied)
+			}
+		}
+
+		if err := writes.firstError(); err != nil {
+			return counts, err
+		}
+
+		counts[name] = copied
+		if progress != nil {
+			progress(name, copied)
+		}
+	}
+
+	if dryRun {
+		return counts, nil
+	}
+	_, err := legacyCopyRef(client).Set(ctx, map[string]interface{}{
+		"orgId":    orgID,
+		"copiedAt": time.Now(),
+	})
+	return counts, err
+}
+
+// legacyCheckCollections are the legacy collections whose documents would
+// be lost from view once authentication scopes data to organizations.
+var legacyCheckCollections = []string{"properties", "categories", "transactions"}
+
+// CheckLegacyData fails when the root collections used before tenant
+// scoping still hold data that hasn't been copied into an organization.
+// With authentication enabled every caller reads their organization's
+// collections, so that data would silently disappear.
+func CheckLegacyData(ctx context.Context, client *firestore.Client) error {
+	_, err := getDoc(ctx, legacyCopyRef(client))
+	if err == nil {
+		return nil
+	}
+	if status.Code(err) != codes.NotFound {
+		return err
+	}
+
+	for _, name := range legacyCheckCollections {
+		_, err := client.Collection(name).Limit(1).Documents(ctx).Next()
+		if err == iterator.Done {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("the root %s collection holds data from before authentication that no organization can see; copy it into the owner's organization with \"migrate copy-legacy -org <user ID>\"", name)
+	}
+	return nil
+}
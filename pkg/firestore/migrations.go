@@ -0,0 +1,326 @@
+package firestore
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/spalqui/habitattrack-api/internal/models"
+	"github.com/spalqui/habitattrack-api/pkg/money"
+)
+
+// Migration is a versioned change to stored data. Up must be safe to rerun,
+// since a migration that fails part way is retried from the start.
+type Migration struct {
+	Version int
+	Name    string
+	Up      func(ctx context.Context, client *firestore.Client) error
+}
+
+// migrations is the registry, in version order. Append new migrations with
+// the next version; never renumber or remove applied ones.
+var migrations = []Migration{
+	{Version: 1, Name: "index category names", Up: indexCategoryNames},
+	{Version: 2, Name: "build monthly rollups", Up: buildRollups},
+	{Version: 3, Name: "store amounts in minor units", Up: convertAmounts},
+	{Version: 4, Name: "index property names", Up: indexPropertyNames},
+	{Version: 5, Name: "mark transactions without a property", Up: markUnassignedTransactions},
+}
+
+const migrationsCollection = "schema_migrations"
+
+// MigrationStatus reports whether a registered migration has been applied.
+type MigrationStatus struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+type migrationRecord struct {
+	Version   int        `firestore:"version"`
+	Name      string     `firestore:"name"`
+	StartedAt time.Time  `firestore:"startedAt"`
+	AppliedAt *time.Time `firestore:"appliedAt"`
+}
+
+func migrationRef(client *firestore.Client, version int) *firestore.DocumentRef {
+	return client.Collection(migrationsCollection).Doc(fmt.Sprintf("%04d", version))
+}
+
+// MigrationStatuses lists every registered migration and when it was
+// applied.
+func MigrationStatuses(ctx context.Context, client *firestore.Client) ([]MigrationStatus, error) {
+	statuses := make([]MigrationStatus, len(migrations))
+	for i, migration := range migrations {
+		statuses[i].Migration = migration
+
+		doc, err := getDoc(ctx, migrationRef(client, migration.Version))
+		if status.Code(err) == codes.NotFound {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var record migrationRecord
+		if err := doc.DataTo(&record); err != nil {
+			return nil, err
+		}
+		statuses[i].AppliedAt = record.AppliedAt
+	}
+
+	return statuses, nil
+}
+
+// RunMigrations applies the pending migrations in version order and returns
+// the ones it applied. Each is claimed with a record before it runs, so two
+// runners in different environments or processes don't apply it twice; a
+// failed migration's claim is released so it can be retried.
+func RunMigrations(ctx context.Context, client *firestore.Client, dryRun bool) ([]Migration, error) {
+	statuses, err := MigrationStatuses(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+
+	var applied []Migration
+	for _, migrationStatus := range statuses {
+		if migrationStatus.AppliedAt != nil {
+			continue
+		}
+
+		migration := migrationStatus.Migration
+		if dryRun {
+			applied = append(applied, migration)
+			continue
+		}
+
+		ref := migrationRef(client, migration.Version)
+		if _, err := ref.Create(ctx, migrationRecord{
+			Version:   migration.Version,
+			Name:      migration.Name,
+			StartedAt: time.Now(),
+		}); err != nil {
+			if status.Code(err) == codes.AlreadyExists {
+				return applied, fmt.Errorf("migration %d is already running", migration.Version)
+			}
+			return applied, err
+		}
+
+		if err := migration.Up(ctx, client); err != nil {
+			if _, deleteErr := ref.Delete(ctx); deleteErr != nil {
+				return applied, fmt.Errorf("migration %d failed: %w (releasing it also failed: %v)", migration.Version, err, deleteErr)
+			}
+			return applied, fmt.Errorf("migration %d failed: %w", migration.Version, err)
+		}
+
+		if _, err := ref.Update(ctx, []firestore.Update{{Path: "appliedAt", Value: time.Now()}}); err != nil {
+			return applied, err
+		}
+		applied = append(applied, migration)
+	}
+
+	return applied, nil
+}
+
+// indexCategoryNames writes the name index documents for categories created
+// before names were unique. Names already claimed by another category are
+// left to that category.
+func indexCategoryNames(ctx context.Context, client *firestore.Client) error {
+	docs := client.CollectionGroup("categories").Documents(ctx)
+	defer docs.Stop()
+
+	for {
+		doc, err := docs.Next()
+		if err == iterator.Done {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var category models.Category
+		if err := doc.DataTo(&category); err != nil {
+			return err
+		}
+
+		names := client.Collection("category_names")
+		if org := doc.Ref.Parent.Parent; org != nil {
+			names = org.Collection("category_names")
+		}
+
+		_, err = names.Doc(categoryNameKey(&category)).Create(ctx, map[string]interface{}{
+			"categoryId": doc.Ref.ID,
+		})
+		if err != nil && status.Code(err) != codes.AlreadyExists {
+			return err
+		}
+	}
+}
+
+// indexPropertyNames writes the name index documents for properties created
+// before names were reserved. Names already claimed by another property are
+// left to that property.
+func indexPropertyNames(ctx context.Context, client *firestore.Client) error {
+	docs := client.CollectionGroup("properties").Documents(ctx)
+	defer docs.Stop()
+
+	for {
+		doc, err := docs.Next()
+		if err == iterator.Done {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var property models.Property
+		if err := doc.DataTo(&property); err != nil {
+			return err
+		}
+		if property.Name == "" {
+			continue
+		}
+
+		names := client.Collection("property_names")
+		if org := doc.Ref.Parent.Parent; org != nil {
+			names = org.Collection("property_names")
+		}
+
+		_, err = names.Doc(propertyNameKey(property.Name)).Create(ctx, map[string]interface{}{
+			"propertyId": doc.Ref.ID,
+		})
+		if err != nil && status.Code(err) != codes.AlreadyExists {
+			return err
+		}
+	}
+}
+
+// markUnassignedTransactions stores an empty property ID on transactions
+// written without the field, and clears the property of transactions whose
+// property no longer exists, so the propertyId == "" query finds both. The
+// rollups are rebuilt to move the orphans out of their deleted property's.
+func markUnassignedTransactions(ctx context.Context, client *firestore.Client) error {
+	docs := client.CollectionGroup("transactions").Documents(ctx)
+	defer docs.Stop()
+
+	exists := make(map[string]bool)
+	orphaned := false
+	for {
+		doc, err := docs.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		propertyID, ok := doc.Data()["propertyId"].(string)
+		if !ok {
+			if _, err := doc.Ref.Update(ctx, []firestore.Update{{Path: "propertyId", Value: ""}}); err != nil {
+				return err
+			}
+			continue
+		}
+		if propertyID == "" {
+			continue
+		}
+
+		properties := client.Collection("properties")
+		if org := doc.Ref.Parent.Parent; org != nil {
+			properties = org.Collection("properties")
+		}
+		propertyRef := properties.Doc(propertyID)
+
+		found, checked := exists[propertyRef.Path]
+		if !checked {
+			_, err := getDoc(ctx, propertyRef)
+			if err != nil && status.Code(err) != codes.NotFound {
+				return err
+			}
+			found = err == nil
+			exists[propertyRef.Path] = found
+		}
+		if found {
+			continue
+		}
+
+		if _, err := doc.Ref.Update(ctx, []firestore.Update{
+			{Path: "propertyId", Value: ""},
+			{Path: "propertyName", Value: firestore.Delete},
+			{Path: "updatedAt", Value: storedNow()},
+		}); err != nil {
+			return err
+		}
+		orphaned = true
+	}
+
+	if !orphaned {
+		return nil
+	}
+	_, err := rebuildRollups(ctx, client)
+	return err
+}
+
+func buildRollups(ctx context.Context, client *firestore.Client) error {
+	_, err := rebuildRollups(ctx, client)
+	return err
+}
+
+// convertAmounts rewrites transaction amounts stored as floats in major units
+// as integer minor units. Amounts already converted are integers, so a rerun
+// skips them. The totals, which hold float sums, are deleted to be recomputed
+// on their next read, and the rollups are rebuilt from the converted amounts.
+func convertAmounts(ctx context.Context, client *firestore.Client) error {
+	for _, collection := range []string{"transactions", "deleted_transactions"} {
+		docs := client.CollectionGroup(collection).Documents(ctx)
+		err := func() error {
+			defer docs.Stop()
+			for {
+				doc, err := docs.Next()
+				if err == iterator.Done {
+					return nil
+				}
+				if err != nil {
+					return err
+				}
+
+				amount, ok := doc.Data()["amount"].(float64)
+				if !ok {
+					continue
+				}
+				exact, err := money.FromFloat(amount)
+				if err != nil {
+					return fmt.Errorf("transaction %s: %w", doc.Ref.ID, err)
+				}
+				if _, err := doc.Ref.Update(ctx, []firestore.Update{{Path: "amount", Value: int64(exact)}}); err != nil {
+					return err
+				}
+			}
+		}()
+		if err != nil {
+			return err
+		}
+	}
+
+	totals := client.CollectionGroup(totalsCollection).Documents(ctx)
+	defer totals.Stop()
+	for {
+		doc, err := totals.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if _, err := doc.Ref.Delete(ctx); err != nil {
+			return err
+		}
+	}
+
+	_, err := rebuildRollups(ctx, client)
+	return err
+}
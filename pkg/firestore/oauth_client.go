@@ -0,0 +1,87 @@
+package firestore
+
+import (
+	"context"
+	"time"
+
+	"cloud.google.com/go/firestore"
+
+	"github.com/spalqui/habitattrack-api/internal/models"
+	"github.com/spalqui/habitattrack-api/internal/repositories"
+	"github.com/spalqui/habitattrack-api/pkg/auth"
+)
+
+// OAuth clients live in a root collection because the token endpoint looks
+// them up by client ID before any organization is known.
+type oauthClientRepository struct {
+	client     *firestore.Client
+	collection string
+}
+
+func NewOAuthClientRepository(client *firestore.Client) repositories.OAuthClientRepository {
+	return &oauthClientRepository{
+		client:     client,
+		collection: "oauth_clients",
+	}
+}
+
+func (r *oauthClientRepository) Create(ctx context.Context, oauthClient *models.OAuthClient) error {
+	oauthClient.OrgID = auth.OrgID(ctx)
+	oauthClient.CreatedAt = time.Now()
+	oauthClient.UpdatedAt = time.Now()
+
+	docRef := newDoc(r.client.Collection(r.collection))
+	if _, err := docRef.Create(ctx, oauthClient); err != nil {
+		return err
+	}
+
+	oauthClient.ID = docRef.ID
+	return nil
+}
+
+func (r *oauthClientRepository) GetByID(ctx context.Context, id string) (*models.OAuthClient, error) {
+	doc, err := getInOrg(ctx, r.client.Collection(r.collection).Doc(id))
+	if err != nil {
+		return nil, err
+	}
+
+	var oauthClient models.OAuthClient
+	if err := doc.DataTo(&oauthClient); err != nil {
+		return nil, err
+	}
+
+	oauthClient.ID = doc.Ref.ID
+	return &oauthClient, nil
+}
+
+func (r *oauthClientRepository) GetAll(ctx context.Context) ([]*models.OAuthClient, error) {
+	docs, err := getAll(ctx, orgScope(ctx, r.client.Collection(r.collection).Query))
+	if err != nil {
+		return nil, err
+	}
+
+	oauthClients := make([]*models.OAuthClient, len(docs))
+	for i, doc := range docs {
+		var oauthClient models.OAuthClient
+		if err := doc.DataTo(&oauthClient); err != nil {
+			return nil, err
+		}
+		oauthClient.ID = doc.Ref.ID
+		oauthClients[i] = &oauthClient
+	}
+
+	return oauthClients, nil
+}
+
+func (r *oauthClientRepository) Update(ctx context.Context, oauthClient *models.OAuthClient) error {
+	docRef := r.client.Collection(r.collection).Doc(oauthClient.ID)
+	doc, err := getInOrg(ctx, docRef)
+	if err != nil {
+		return err
+	}
+
+	oauthClient.OrgID = orgOf(doc)
+	oauthClient.UpdatedAt = time.Now()
+	_, err = docRef.Update(ctx, fieldUpdates(oauthClient, "createdAt", "orgId"))
+	return err
+}
@@ -0,0 +1,62 @@
+package firestore
+
+import (
+	"context"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/spalqui/habitattrack-api/internal/models"
+	"github.com/spalqui/habitattrack-api/internal/repositories"
+)
+
+type organizationRepository struct {
+	client     *firestore.Client
+	collection string
+}
+
+func NewOrganizationRepository(client *firestore.Client) repositories.OrganizationRepository {
+	return &organizationRepository{
+		client:     client,
+		collection: "organizations",
+	}
+}
+
+func (r *organizationRepository) Create(ctx context.Context, organization *models.Organization) error {
+	organization.CreatedAt = time.Now()
+	organization.UpdatedAt = time.Now()
+
+	docRef := newDoc(r.client.Collection(r.collection))
+	if _, err := docRef.Create(ctx, organization); err != nil {
+		return err
+	}
+
+	organization.ID = docRef.ID
+	return nil
+}
+
+func (r *organizationRepository) GetByID(ctx context.Context, id string) (*models.Organization, error) {
+	doc, err := getDoc(ctx, r.client.Collection(r.collection).Doc(id))
+	if status.Code(err) == codes.NotFound {
+		return nil, repositories.ErrOrganizationNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var organization models.Organization
+	if err := doc.DataTo(&organization); err != nil {
+		return nil, err
+	}
+
+	organization.ID = doc.Ref.ID
+	return &organization, nil
+}
+
+func (r *organizationRepository) Update(ctx context.Context, organization *models.Organization) error {
+	organization.UpdatedAt = time.Now()
+	_, err := r.client.Collection(r.collection).Doc(organization.ID).Update(ctx, fieldUpdates(organization, "createdAt", "createdBy"))
+	return err
+}
@@ -0,0 +1,137 @@
+package firestore
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/spalqui/habitattrack-api/internal/models"
+	"github.com/spalqui/habitattrack-api/internal/repositories"
+	"github.com/spalqui/habitattrack-api/pkg/auth"
+)
+
+// outboxCollection is a root collection shared by every organization, so one
+// relay can drain it; each event records the organization it belongs to.
+const outboxCollection = "outbox"
+
+// enqueueEvent adds an event to the outbox as part of tx, so the event is
+// only recorded if the change it describes is.
+func enqueueEvent(ctx context.Context, client *firestore.Client, tx *firestore.Transaction, eventType string, data interface{}) error {
+	event, err := newOutboxEvent(ctx, eventType, data)
+	if err != nil {
+		return err
+	}
+	return tx.Create(newDoc(client.Collection(outboxCollection)), event)
+}
+
+// enqueueEvents queues an event for each of the items written by a bulk
+// write. Bulk writes aren't atomic, so these are only queued once the items
+// are written; a crash in between loses their events.
+func enqueueEvents[T any](ctx context.Context, client *firestore.Client, eventType string, items []T) error {
+	writes := newBulkWrites(ctx, client)
+	for i, item := range items {
+		event, err := newOutboxEvent(ctx, eventType, item)
+		if err != nil {
+			return writes.abort(err)
+		}
+		writes.create(i, newDoc(client.Collection(outboxCollection)), event)
+	}
+
+	return writes.finish().Err()
+}
+
+func newOutboxEvent(ctx context.Context, eventType string, data interface{}) (*models.OutboxEvent, error) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	return &models.OutboxEvent{
+		Type:        eventType,
+		OrgID:       auth.OrgID(ctx),
+		Payload:     payload,
+		AvailableAt: now,
+		CreatedAt:   now,
+	}, nil
+}
+
+type outboxRepository struct {
+	client     *firestore.Client
+	collection string
+}
+
+func NewOutboxRepository(client *firestore.Client) repositories.OutboxRepository {
+	return &outboxRepository{
+		client:     client,
+		collection: outboxCollection,
+	}
+}
+
+func (r *outboxRepository) Requeue(ctx context.Context, event *models.OutboxEvent) error {
+	event.Attempts = 0
+	event.LastError = ""
+	event.AvailableAt = time.Now()
+
+	_, err := r.client.Collection(r.collection).Doc(event.ID).Set(ctx, event)
+	return err
+}
+
+func (r *outboxRepository) Claim(ctx context.Context, limit int, lease time.Duration) ([]*models.OutboxEvent, error) {
+	var events []*models.OutboxEvent
+	err := r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		now := time.Now()
+		query := r.client.Collection(r.collection).
+			Where("availableAt", "<=", now).
+			OrderBy("availableAt", firestore.Asc).
+			Limit(limit)
+
+		docs, err := tx.Documents(query).GetAll()
+		if err != nil {
+			return err
+		}
+
+		events = make([]*models.OutboxEvent, len(docs))
+		for i, doc := range docs {
+			var event models.OutboxEvent
+			if err := doc.DataTo(&event); err != nil {
+				return err
+			}
+			event.ID = doc.Ref.ID
+			events[i] = &event
+
+			if err := tx.Update(doc.Ref, []firestore.Update{{Path: "availableAt", Value: now.Add(lease)}}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+func (r *outboxRepository) Delete(ctx context.Context, id string) error {
+	_, err := r.client.Collection(r.collection).Doc(id).Delete(ctx)
+	return err
+}
+
+// Release makes a failed event available again at retryAt. An event that has
+// since been deleted is left alone.
+func (r *outboxRepository) Release(ctx context.Context, id string, retryAt time.Time, cause error) error {
+	_, err := r.client.Collection(r.collection).Doc(id).Update(ctx, []firestore.Update{
+		{Path: "availableAt", Value: retryAt},
+		{Path: "attempts", Value: firestore.Increment(1)},
+		{Path: "lastError", Value: cause.Error()},
+	})
+	if status.Code(err) == codes.NotFound {
+		return nil
+	}
+	return err
+}
@@ -2,41 +2,105 @@ package firestore
 
 import (
 	"context"
-	"time"
+	"errors"
+	"net/url"
+	"strings"
 
 	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	"github.com/spalqui/habitattrack-api/internal/models"
 	"github.com/spalqui/habitattrack-api/internal/repositories"
 )
 
 type propertyRepository struct {
-	client     *firestore.Client
-	collection string
+	client         *firestore.Client
+	collection     string
+	nameCollection string
 }
 
 func NewPropertyRepository(client *firestore.Client) repositories.PropertyRepository {
 	return &propertyRepository{
-		client:     client,
-		collection: "properties",
+		client:         client,
+		collection:     "properties",
+		nameCollection: "property_names",
 	}
 }
 
+// Create writes a named property together with an index document keyed by
+// its name, in one transaction, so concurrent creates can't both claim a
+// name.
 func (r *propertyRepository) Create(ctx context.Context, property *models.Property) error {
-	property.CreatedAt = time.Now()
-	property.UpdatedAt = time.Now()
+	property.CreatedAt = storedNow()
+	property.UpdatedAt = storedNow()
 
-	docRef, _, err := r.client.Collection(r.collection).Add(ctx, property)
+	docRef := newDoc(tenantCollection(ctx, r.client, r.collection))
+	err := r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		if property.Name != "" {
+			if err := tx.Create(r.nameRef(ctx, property.Name), map[string]interface{}{
+				"propertyId": docRef.ID,
+			}); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Set(docRef, property); err != nil {
+			return err
+		}
+
+		created := *property
+		created.ID = docRef.ID
+		return enqueueEvent(ctx, r.client, tx, models.EventPropertyCreated, &created)
+	})
+	if status.Code(err) == codes.AlreadyExists {
+		return repositories.ErrPropertyNameExists
+	}
 	if err != nil {
 		return err
 	}
 
 	property.ID = docRef.ID
-	return nil
+	return readBack(ctx, docRef, property)
+}
+
+// nameRef is the index document for a property name. Names are compared
+// case-insensitively; unnamed properties, known by their address, have none.
+func (r *propertyRepository) nameRef(ctx context.Context, name string) *firestore.DocumentRef {
+	return tenantCollection(ctx, r.client, r.nameCollection).Doc(propertyNameKey(name))
+}
+
+func propertyNameKey(name string) string {
+	return url.PathEscape(strings.ToLower(strings.TrimSpace(name)))
+}
+
+// ownedNameRef returns the name's index document if it belongs to the
+// property, or nil. Properties named alike before names were indexed share
+// a document that belongs to only one of them.
+func (r *propertyRepository) ownedNameRef(ctx context.Context, tx *firestore.Transaction, name, propertyID string) (*firestore.DocumentRef, error) {
+	if name == "" {
+		return nil, nil
+	}
+
+	nameRef := r.nameRef(ctx, name)
+	doc, err := tx.Get(nameRef)
+	if status.Code(err) == codes.NotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if owner, _ := doc.Data()["propertyId"].(string); owner != propertyID {
+		return nil, nil
+	}
+	return nameRef, nil
 }
 
 func (r *propertyRepository) GetByID(ctx context.Context, id string) (*models.Property, error) {
-	doc, err := r.client.Collection(r.collection).Doc(id).Get(ctx)
+	doc, err := getDoc(ctx, tenantCollection(ctx, r.client, r.collection).Doc(id))
+	if status.Code(err) == codes.NotFound {
+		return nil, repositories.ErrPropertyNotFound
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -50,8 +114,33 @@ func (r *propertyRepository) GetByID(ctx context.Context, id string) (*models.Pr
 	return &property, nil
 }
 
+// GetByIDs reads the properties in one round trip, keyed by ID. IDs that
+// don't exist are left out.
+func (r *propertyRepository) GetByIDs(ctx context.Context, ids []string) (map[string]*models.Property, error) {
+	docs, err := getDocs(ctx, r.client, idRefs(tenantCollection(ctx, r.client, r.collection), ids))
+	if err != nil {
+		return nil, err
+	}
+
+	properties := make(map[string]*models.Property, len(docs))
+	for _, doc := range docs {
+		if !doc.Exists() {
+			continue
+		}
+
+		var property models.Property
+		if err := doc.DataTo(&property); err != nil {
+			return nil, err
+		}
+		property.ID = doc.Ref.ID
+		properties[property.ID] = &property
+	}
+
+	return properties, nil
+}
+
 func (r *propertyRepository) GetAll(ctx context.Context) ([]*models.Property, error) {
-	docs, err := r.client.Collection(r.collection).Documents(ctx).GetAll()
+	docs, err := getAll(ctx, tenantCollection(ctx, r.client, r.collection))
 	if err != nil {
 		return nil, err
 	}
@@ -69,13 +158,146 @@ func (r *propertyRepository) GetAll(ctx context.Context) ([]*models.Property, er
 	return properties, nil
 }
 
+// FindByName looks the name up in the name index, so it matches names the
+// same way creating a property checks them.
+func (r *propertyRepository) FindByName(ctx context.Context, name string) (*models.Property, error) {
+	if strings.TrimSpace(name) == "" {
+		return nil, nil
+	}
+
+	doc, err := getDoc(ctx, r.nameRef(ctx, name))
+	if status.Code(err) == codes.NotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	propertyID, _ := doc.Data()["propertyId"].(string)
+	if propertyID == "" {
+		return nil, nil
+	}
+
+	property, err := r.GetByID(ctx, propertyID)
+	if errors.Is(err, repositories.ErrPropertyNotFound) {
+		return nil, nil
+	}
+	return property, err
+}
+
+func (r *propertyRepository) ListPage(ctx context.Context, page models.PageRequest) (*models.Page[*models.Property], error) {
+	collection := tenantCollection(ctx, r.client, r.collection)
+	query, err := pageQuery(ctx, collection.Query, collection, page)
+	if err != nil {
+		return nil, err
+	}
+
+	docs, err := getAll(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	docs, next, prev := pageCursors(docs, page)
+
+	properties := make([]*models.Property, len(docs))
+	for i, doc := range docs {
+		var property models.Property
+		if err := doc.DataTo(&property); err != nil {
+			return nil, err
+		}
+		property.ID = doc.Ref.ID
+		properties[i] = &property
+	}
+
+	return &models.Page[*models.Property]{Items: properties, NextCursor: next, PrevCursor: prev}, nil
+}
+
+func (r *propertyRepository) Count(ctx context.Context) (int64, error) {
+	return countQuery(ctx, tenantCollection(ctx, r.client, r.collection).Query)
+}
+
+// Update replaces the property, provided it hasn't been updated since the
+// version whose UpdatedAt it carries, and moves the name index document when
+// the name changes.
 func (r *propertyRepository) Update(ctx context.Context, property *models.Property) error {
-	property.UpdatedAt = time.Now()
-	_, err := r.client.Collection(r.collection).Doc(property.ID).Set(ctx, property)
+	expected := property.UpdatedAt
+	property.UpdatedAt = storedNow()
+	docRef := tenantCollection(ctx, r.client, r.collection).Doc(property.ID)
+
+	err := r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(docRef)
+		if status.Code(err) == codes.NotFound {
+			return repositories.ErrPropertyNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		var existing models.Property
+		if err := doc.DataTo(&existing); err != nil {
+			return err
+		}
+		if err := checkUnchanged(existing.UpdatedAt, expected); err != nil {
+			return err
+		}
+
+		if propertyNameKey(existing.Name) != propertyNameKey(property.Name) {
+			// Reads must precede writes in a Firestore transaction
+			oldName, err := r.ownedNameRef(ctx, tx, existing.Name, property.ID)
+			if err != nil {
+				return err
+			}
+			if property.Name != "" {
+				if err := tx.Create(r.nameRef(ctx, property.Name), map[string]interface{}{"propertyId": property.ID}); err != nil {
+					return err
+				}
+			}
+			if oldName != nil {
+				if err := tx.Delete(oldName); err != nil {
+					return err
+				}
+			}
+		}
+
+		if err := tx.Update(docRef, fieldUpdates(property, "createdAt")); err != nil {
+			return err
+		}
+		return enqueueEvent(ctx, r.client, tx, models.EventPropertyUpdated, property)
+	})
+	if status.Code(err) == codes.AlreadyExists {
+		return repositories.ErrPropertyNameExists
+	}
 	return err
 }
 
 func (r *propertyRepository) Delete(ctx context.Context, id string) error {
-	_, err := r.client.Collection(r.collection).Doc(id).Delete(ctx)
-	return err
+	docRef := tenantCollection(ctx, r.client, r.collection).Doc(id)
+
+	return r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(docRef)
+		if status.Code(err) == codes.NotFound {
+			return repositories.ErrPropertyNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		var property models.Property
+		if err := doc.DataTo(&property); err != nil {
+			return err
+		}
+
+		nameRef, err := r.ownedNameRef(ctx, tx, property.Name, id)
+		if err != nil {
+			return err
+		}
+		if nameRef != nil {
+			if err := tx.Delete(nameRef); err != nil {
+				return err
+			}
+		}
+		if err := enqueueEvent(ctx, r.client, tx, models.EventPropertyDeleted, map[string]string{"id": id}); err != nil {
+			return err
+		}
+		return tx.Delete(docRef)
+	})
 }
@@ -0,0 +1,113 @@
+package firestore
+
+import (
+	"context"
+	"errors"
+
+	"cloud.google.com/go/firestore"
+	"cloud.google.com/go/firestore/apiv1/firestorepb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/spalqui/habitattrack-api/internal/models"
+	"github.com/spalqui/habitattrack-api/internal/repositories"
+)
+
+// pageQuery limits the query to one page either side of the cursor
+// document, ordered by the given fields and then by document ID. One extra
+// document is read so pageCursors can tell whether there's a page beyond.
+// Cursors are used instead of offsets because Firestore reads, and bills,
+// every skipped document.
+func pageQuery(ctx context.Context, query firestore.Query, collection *firestore.CollectionRef, page models.PageRequest, orderBy ...string) (firestore.Query, error) {
+	// Ordering explicitly, rather than leaving it to Firestore, lets the
+	// query run backwards for previous pages
+	for _, field := range orderBy {
+		query = query.OrderBy(field, firestore.Asc)
+	}
+	query = query.OrderBy(firestore.DocumentID, firestore.Asc)
+
+	if page.Cursor == "" {
+		return query.Limit(page.Limit + 1), nil
+	}
+
+	cursor, err := getDoc(ctx, collection.Doc(page.Cursor))
+	if status.Code(err) == codes.NotFound {
+		return query, repositories.ErrInvalidPageToken
+	}
+	if err != nil {
+		return query, err
+	}
+
+	if page.Backward {
+		return query.EndBefore(cursor).LimitToLast(page.Limit + 1), nil
+	}
+	return query.StartAfter(cursor).Limit(page.Limit + 1), nil
+}
+
+// pageCursors drops the extra document read by pageQuery and returns the
+// page's documents with the cursors of the pages after and before it; a
+// cursor is empty when there's no page that way.
+func pageCursors(docs []*firestore.DocumentSnapshot, page models.PageRequest) (pageDocs []*firestore.DocumentSnapshot, next, prev string) {
+	more := len(docs) > page.Limit
+	if more {
+		if page.Backward {
+			docs = docs[1:]
+		} else {
+			docs = docs[:page.Limit]
+		}
+	}
+	if len(docs) == 0 {
+		return docs, "", ""
+	}
+
+	first, last := docs[0].Ref.ID, docs[len(docs)-1].Ref.ID
+	if page.Backward {
+		// A page reached by going back always has the one it came from after it
+		next = last
+		if more {
+			prev = first
+		}
+	} else {
+		if more {
+			next = last
+		}
+		if page.Cursor != "" {
+			prev = first
+		}
+	}
+	return docs, next, prev
+}
+
+// idRefs references the documents with the given IDs, once each, skipping
+// empty IDs.
+func idRefs(collection *firestore.CollectionRef, ids []string) []*firestore.DocumentRef {
+	seen := make(map[string]bool, len(ids))
+	refs := make([]*firestore.DocumentRef, 0, len(ids))
+	for _, id := range ids {
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		refs = append(refs, collection.Doc(id))
+	}
+	return refs
+}
+
+func countQuery(ctx context.Context, query firestore.Query) (int64, error) {
+	var result firestore.AggregationResult
+	err := withRetry(ctx, func() error {
+		var err error
+		result, err = query.NewAggregationQuery().WithCount("count").Get(ctx)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	value, ok := result["count"].(*firestorepb.Value)
+	if !ok {
+		return 0, errors.New("unexpected count aggregation result")
+	}
+
+	return value.GetIntegerValue(), nil
+}
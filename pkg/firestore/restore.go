@@ -0,0 +1,141 @@
+package firestore
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"google.golang.org/api/iterator"
+
+	"github.com/spalqui/habitattrack-api/internal/models"
+)
+
+// IsEmpty reports whether nothing exists beneath prefix, a document path, or
+// in the whole database when prefix is empty.
+func (s *documentStore) IsEmpty(ctx context.Context, prefix string) (bool, error) {
+	collections := s.client.Collections(ctx)
+	if prefix != "" {
+		collections = s.client.Doc(prefix).Collections(ctx)
+	}
+
+	_, err := collections.Next()
+	if err == iterator.Done {
+		return true, nil
+	}
+	return false, err
+}
+
+// Validate checks that the record can be restored without writing it.
+func (s *documentStore) Validate(record *models.BackupRecord) error {
+	if err := validateDocumentPath(record.Path); err != nil {
+		return err
+	}
+	if record.Data == nil {
+		return errors.New("document data is missing")
+	}
+	_, err := s.decodeBackupValue("", record.Data)
+	return err
+}
+
+func validateDocumentPath(path string) error {
+	segments := strings.Split(path, "/")
+	if path == "" || len(segments)%2 != 0 {
+		return fmt.Errorf("%q is not a document path", path)
+	}
+	for _, segment := range segments {
+		if segment == "" {
+			return fmt.Errorf("%q is not a document path", path)
+		}
+	}
+	return nil
+}
+
+// Import writes the records beneath prefix, keeping their paths, so a backup
+// can be restored next to live data as well as into an empty database.
+func (s *documentStore) Import(ctx context.Context, prefix string, records []*models.BackupRecord) error {
+	writes := newBulkWrites(ctx, s.client)
+	for i, record := range records {
+		data, err := s.decodeBackupValue(prefix, record.Data)
+		if err != nil {
+			return writes.abort(err)
+		}
+		writes.set(i, s.client.Doc(joinPath(prefix, record.Path)), data)
+	}
+	return writes.firstError()
+}
+
+func joinPath(prefix, path string) string {
+	if prefix == "" {
+		return path
+	}
+	return prefix + "/" + path
+}
+
+// decodeBackupValue reverses encodeBackupValue. References are moved beneath
+// prefix along with the documents they point to.
+func (s *documentStore) decodeBackupValue(prefix string, value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if len(v) == 1 {
+			for tag, tagged := range v {
+				if decoded, ok, err := s.decodeTagged(prefix, tag, tagged); ok || err != nil {
+					return decoded, err
+				}
+			}
+		}
+
+		decoded := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			value, err := s.decodeBackupValue(prefix, item)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", key, err)
+			}
+			decoded[key] = value
+		}
+		return decoded, nil
+	case []interface{}:
+		decoded := make([]interface{}, len(v))
+		for i, item := range v {
+			value, err := s.decodeBackupValue(prefix, item)
+			if err != nil {
+				return nil, err
+			}
+			decoded[i] = value
+		}
+		return decoded, nil
+	default:
+		return v, nil
+	}
+}
+
+func (s *documentStore) decodeTagged(prefix, tag string, value interface{}) (interface{}, bool, error) {
+	text, isString := value.(string)
+
+	switch tag {
+	case "$time":
+		if !isString {
+			return nil, true, errors.New("$time must be a string")
+		}
+		t, err := time.Parse(time.RFC3339Nano, text)
+		return t, true, err
+	case "$bytes":
+		if !isString {
+			return nil, true, errors.New("$bytes must be a string")
+		}
+		b, err := base64.StdEncoding.DecodeString(text)
+		return b, true, err
+	case "$ref":
+		if !isString {
+			return nil, true, errors.New("$ref must be a string")
+		}
+		if err := validateDocumentPath(text); err != nil {
+			return nil, true, err
+		}
+		return s.client.Doc(joinPath(prefix, text)), true, nil
+	default:
+		return nil, false, nil
+	}
+}
@@ -0,0 +1,100 @@
+package firestore
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	maxRetries     = 4
+	baseRetryDelay = 100 * time.Millisecond
+	maxRetryDelay  = 2 * time.Second
+)
+
+// retryable reports whether a Firestore error is transient.
+func retryable(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted:
+		return true
+	default:
+		return false
+	}
+}
+
+// withRetry runs call until it succeeds, fails permanently, ctx is done or
+// the retries run out, backing off exponentially between attempts. Only
+// idempotent calls may be retried: a write that timed out may still have
+// been applied, so creates and increments aren't, and Firestore transactions
+// retry themselves.
+func withRetry(ctx context.Context, call func() error) error {
+	delay := baseRetryDelay
+	for attempt := 0; ; attempt++ {
+		err := call()
+		if err == nil || attempt == maxRetries || !retryable(err) || ctx.Err() != nil {
+			return err
+		}
+
+		// Full jitter keeps instances that failed together from retrying together
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(rand.N(delay)):
+		}
+		delay = min(delay*2, maxRetryDelay)
+	}
+}
+
+// getDoc reads a document, retrying transient errors.
+func getDoc(ctx context.Context, ref *firestore.DocumentRef) (*firestore.DocumentSnapshot, error) {
+	var doc *firestore.DocumentSnapshot
+	err := withRetry(ctx, func() error {
+		var err error
+		doc, err = ref.Get(ctx)
+		return err
+	})
+	return doc, err
+}
+
+// readBack replaces model with the document as stored, so callers see what
+// Firestore kept rather than what was sent, such as timestamps truncated to
+// microseconds.
+func readBack(ctx context.Context, ref *firestore.DocumentRef, model interface{}) error {
+	doc, err := getDoc(ctx, ref)
+	if err != nil {
+		return err
+	}
+	return doc.DataTo(model)
+}
+
+// getDocs reads documents by reference in one round trip, retrying transient
+// errors. Missing documents come back as snapshots that don't exist.
+func getDocs(ctx context.Context, client *firestore.Client, refs []*firestore.DocumentRef) ([]*firestore.DocumentSnapshot, error) {
+	var docs []*firestore.DocumentSnapshot
+	err := withRetry(ctx, func() error {
+		var err error
+		docs, err = client.GetAll(ctx, refs)
+		return err
+	})
+	return docs, err
+}
+
+// documentQuery is a query or a whole collection.
+type documentQuery interface {
+	Documents(ctx context.Context) *firestore.DocumentIterator
+}
+
+// getAll runs a query, retrying transient errors.
+func getAll(ctx context.Context, query documentQuery) ([]*firestore.DocumentSnapshot, error) {
+	var docs []*firestore.DocumentSnapshot
+	err := withRetry(ctx, func() error {
+		var err error
+		docs, err = query.Documents(ctx).GetAll()
+		return err
+	})
+	return docs, err
+}
@@ -0,0 +1,89 @@
+package firestore
+
+import (
+	"context"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/spalqui/habitattrack-api/internal/models"
+	"github.com/spalqui/habitattrack-api/internal/repositories"
+)
+
+type revocationRepository struct {
+	client     *firestore.Client
+	collection string
+}
+
+func NewRevocationRepository(client *firestore.Client) repositories.RevocationRepository {
+	return &revocationRepository{
+		client:     client,
+		collection: "revocations",
+	}
+}
+
+// Save keeps one revocation per user or token, keyed so the auth middleware
+// can look it up directly. A newer user revocation replaces the older cutoff.
+func (r *revocationRepository) Save(ctx context.Context, revocation *models.Revocation) error {
+	revocation.CreatedAt = time.Now()
+
+	id := "user:" + revocation.UserID
+	if revocation.TokenID != "" {
+		id = "token:" + revocation.TokenID
+	}
+
+	if _, err := r.client.Collection(r.collection).Doc(id).Set(ctx, revocation); err != nil {
+		return err
+	}
+
+	revocation.ID = id
+	return nil
+}
+
+func (r *revocationRepository) GetByUserID(ctx context.Context, userID string) (*models.Revocation, error) {
+	return r.get(ctx, "user:"+userID)
+}
+
+func (r *revocationRepository) GetByTokenID(ctx context.Context, tokenID string) (*models.Revocation, error) {
+	return r.get(ctx, "token:"+tokenID)
+}
+
+// get returns nil when there is no revocation with the given ID.
+func (r *revocationRepository) get(ctx context.Context, id string) (*models.Revocation, error) {
+	doc, err := getDoc(ctx, r.client.Collection(r.collection).Doc(id))
+	if status.Code(err) == codes.NotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var revocation models.Revocation
+	if err := doc.DataTo(&revocation); err != nil {
+		return nil, err
+	}
+
+	revocation.ID = doc.Ref.ID
+	return &revocation, nil
+}
+
+func (r *revocationRepository) GetAll(ctx context.Context) ([]*models.Revocation, error) {
+	docs, err := getAll(ctx, r.client.Collection(r.collection).OrderBy("createdAt", firestore.Desc))
+	if err != nil {
+		return nil, err
+	}
+
+	revocations := make([]*models.Revocation, len(docs))
+	for i, doc := range docs {
+		var revocation models.Revocation
+		if err := doc.DataTo(&revocation); err != nil {
+			return nil, err
+		}
+		revocation.ID = doc.Ref.ID
+		revocations[i] = &revocation
+	}
+
+	return revocations, nil
+}
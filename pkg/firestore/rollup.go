@@ -0,0 +1,248 @@
+package firestore
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/api/iterator"
+
+	"github.com/spalqui/habitattrack-api/internal/models"
+	"github.com/spalqui/habitattrack-api/internal/repositories"
+	"github.com/spalqui/habitattrack-api/pkg/money"
+)
+
+const rollupsCollection = "monthly_rollups"
+
+type rollupRepository struct {
+	client     *firestore.Client
+	collection string
+}
+
+func NewRollupRepository(client *firestore.Client) repositories.RollupRepository {
+	return &rollupRepository{
+		client:     client,
+		collection: rollupsCollection,
+	}
+}
+
+// rollupID names the rollup a transaction counts towards.
+func rollupID(transaction *models.Transaction) string {
+	return transactionMonth(transaction) + "_" + transaction.PropertyID + "_" + transaction.CategoryID + "_" + string(transaction.Type)
+}
+
+func transactionMonth(transaction *models.Transaction) string {
+	return transaction.Date.In(transaction.Location()).Format(models.RollupMonthFormat)
+}
+
+func rollupFields(transaction *models.Transaction, amount money.Amount, count int) map[string]interface{} {
+	return map[string]interface{}{
+		"month":            transactionMonth(transaction),
+		"propertyId":       transaction.PropertyID,
+		"categoryId":       transaction.CategoryID,
+		"type":             transaction.Type,
+		"amount":           firestore.Increment(int64(amount)),
+		"transactionCount": firestore.Increment(count),
+		"updatedAt":        time.Now(),
+	}
+}
+
+func rolledUp(transaction *models.Transaction) bool {
+	return transaction != nil && (transaction.Type == models.TransactionTypeIncome || transaction.Type == models.TransactionTypeExpense)
+}
+
+// adjustAggregates adds the transaction to its property's totals and monthly
+// rollup, or removes it when sign is -1, in the transaction that writes it.
+func adjustAggregates(ctx context.Context, client *firestore.Client, tx *firestore.Transaction, transaction *models.Transaction, sign int) error {
+	if err := adjustTotals(ctx, client, tx, transaction, sign); err != nil {
+		return err
+	}
+	if !rolledUp(transaction) {
+		return nil
+	}
+
+	ref := tenantCollection(ctx, client, rollupsCollection).Doc(rollupID(transaction))
+	return tx.Set(ref, rollupFields(transaction, money.Amount(sign)*transaction.Amount, sign), firestore.MergeAll)
+}
+
+// addToRollups counts transactions written outside a Firestore transaction,
+// such as by a BulkWriter, with one increment per rollup. A failure part way
+// leaves the rollups short until they're next rebuilt.
+func addToRollups(ctx context.Context, client *firestore.Client, transactions []*models.Transaction) error {
+	type increment struct {
+		transaction *models.Transaction
+		amount      money.Amount
+		count       int
+	}
+
+	increments := make(map[string]*increment)
+	for _, transaction := range transactions {
+		if !rolledUp(transaction) {
+			continue
+		}
+
+		id := rollupID(transaction)
+		if increments[id] == nil {
+			increments[id] = &increment{transaction: transaction}
+		}
+		increments[id].amount += transaction.Amount
+		increments[id].count++
+	}
+
+	for id, increment := range increments {
+		ref := tenantCollection(ctx, client, rollupsCollection).Doc(id)
+		if _, err := ref.Set(ctx, rollupFields(increment.transaction, increment.amount, increment.count), firestore.MergeAll); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *rollupRepository) List(ctx context.Context, filter models.RollupFilter) ([]*models.MonthlyRollup, error) {
+	return listRollups(ctx, r.client, filter)
+}
+
+func listRollups(ctx context.Context, client *firestore.Client, filter models.RollupFilter) ([]*models.MonthlyRollup, error) {
+	query := tenantCollection(ctx, client, rollupsCollection).Query
+	if filter.PropertyID != "" {
+		query = query.Where("propertyId", "==", filter.PropertyID)
+	}
+	if filter.From != "" {
+		query = query.Where("month", ">=", filter.From)
+	}
+	if filter.To != "" {
+		query = query.Where("month", "<=", filter.To)
+	}
+
+	docs, err := getAll(ctx, query.OrderBy("month", firestore.Asc))
+	if err != nil {
+		return nil, err
+	}
+
+	rollups := make([]*models.MonthlyRollup, 0, len(docs))
+	for _, doc := range docs {
+		var rollup models.MonthlyRollup
+		if err := doc.DataTo(&rollup); err != nil {
+			return nil, err
+		}
+		if rollup.TransactionCount == 0 {
+			continue
+		}
+		rollups = append(rollups, &rollup)
+	}
+
+	return rollups, nil
+}
+
+// EstimateCount sums the rollups of the months the filter covers. Date
+// bounds are widened to whole months, so ranges that start or end part way
+// through a month are overestimated.
+func (r *transactionRepository) EstimateCount(ctx context.Context, filter models.TransactionFilter) (int64, error) {
+	rollupFilter := models.RollupFilter{PropertyID: filter.PropertyID}
+	if !filter.StartDate.IsZero() {
+		rollupFilter.From = filter.StartDate.UTC().Format(models.RollupMonthFormat)
+	}
+	if !filter.EndDate.IsZero() {
+		rollupFilter.To = filter.EndDate.UTC().Format(models.RollupMonthFormat)
+	}
+
+	rollups, err := listRollups(ctx, r.client, rollupFilter)
+	if err != nil {
+		return 0, err
+	}
+
+	var count int64
+	for _, rollup := range rollups {
+		if len(filter.CategoryIDs) > 0 && !slices.Contains(filter.CategoryIDs, rollup.CategoryID) {
+			continue
+		}
+		if filter.HasProperty != nil && *filter.HasProperty == (rollup.PropertyID == "") {
+			continue
+		}
+		count += rollup.TransactionCount
+	}
+
+	return count, nil
+}
+
+// Rebuild recomputes the rollups of every organization, and of the root
+// collections, from their transactions, then replaces the stored rollups.
+// Transactions written while it runs can be missed until the next rebuild,
+// so it's best run when the API is quiet.
+func (r *rollupRepository) Rebuild(ctx context.Context) (int, error) {
+	return rebuildRollups(ctx, r.client)
+}
+
+func rebuildRollups(ctx context.Context, client *firestore.Client) (int, error) {
+	rollups := make(map[string]*models.MonthlyRollup)
+	refs := make(map[string]*firestore.DocumentRef)
+
+	docs := client.CollectionGroup("transactions").Documents(ctx)
+	defer docs.Stop()
+	for {
+		doc, err := docs.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		var transaction models.Transaction
+		if err := doc.DataTo(&transaction); err != nil {
+			return 0, err
+		}
+		if !rolledUp(&transaction) {
+			continue
+		}
+
+		collection := client.Collection(rollupsCollection)
+		if org := doc.Ref.Parent.Parent; org != nil {
+			collection = org.Collection(rollupsCollection)
+		}
+		ref := collection.Doc(rollupID(&transaction))
+
+		rollup, ok := rollups[ref.Path]
+		if !ok {
+			rollup = &models.MonthlyRollup{
+				Month:      transactionMonth(&transaction),
+				PropertyID: transaction.PropertyID,
+				CategoryID: transaction.CategoryID,
+				Type:       transaction.Type,
+				UpdatedAt:  time.Now(),
+			}
+			rollups[ref.Path] = rollup
+			refs[ref.Path] = ref
+		}
+		rollup.Amount += transaction.Amount
+		rollup.TransactionCount++
+	}
+
+	writes := newBulkWrites(ctx, client)
+
+	existing := client.CollectionGroup(rollupsCollection).Select().Documents(ctx)
+	defer existing.Stop()
+	for {
+		doc, err := existing.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return 0, writes.abort(err)
+		}
+		if rollups[doc.Ref.Path] == nil {
+			writes.deleteRef(doc.Ref)
+		}
+	}
+
+	for path, rollup := range rollups {
+		writes.set(writes.next, refs[path], rollup)
+		writes.next++
+	}
+
+	if err := writes.firstError(); err != nil {
+		return 0, err
+	}
+	return len(rollups), nil
+}
@@ -0,0 +1,71 @@
+package firestore
+
+import (
+	"context"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/spalqui/habitattrack-api/pkg/auth"
+)
+
+// tenantCollection returns the named collection inside the current
+// organization's document, so queries can only ever see that organization's
+// data. Without an authenticated caller (authentication disabled) the root
+// collection is used.
+func tenantCollection(ctx context.Context, client *firestore.Client, name string) *firestore.CollectionRef {
+	if orgID := auth.OrgID(ctx); orgID != "" {
+		return client.Collection("orgs").Doc(orgID).Collection(name)
+	}
+	return client.Collection(name)
+}
+
+// orgScope restricts a query on a shared root collection to the current
+// organization's documents. Without an authenticated caller (authentication
+// disabled) queries are left unscoped.
+func orgScope(ctx context.Context, query firestore.Query) firestore.Query {
+	return scopeBy(query, "orgId", auth.OrgID(ctx))
+}
+
+func getInOrg(ctx context.Context, ref *firestore.DocumentRef) (*firestore.DocumentSnapshot, error) {
+	return getScoped(ctx, ref, "orgId", auth.OrgID(ctx))
+}
+
+func orgOf(doc *firestore.DocumentSnapshot) string {
+	orgID, _ := doc.DataAt("orgId")
+	value, _ := orgID.(string)
+	return value
+}
+
+func scopeBy(query firestore.Query, field, value string) firestore.Query {
+	if value != "" {
+		return query.Where(field, "==", value)
+	}
+	return query
+}
+
+// checkField reports documents outside the caller's scope as not found, so
+// callers can't probe for IDs they don't have access to.
+func checkField(doc *firestore.DocumentSnapshot, field, value string) error {
+	if value == "" {
+		return nil
+	}
+
+	if current, _ := doc.DataAt(field); current != value {
+		return status.Errorf(codes.NotFound, "%q not found", doc.Ref.Path)
+	}
+	return nil
+}
+
+func getScoped(ctx context.Context, ref *firestore.DocumentRef, field, value string) (*firestore.DocumentSnapshot, error) {
+	doc, err := getDoc(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkField(doc, field, value); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
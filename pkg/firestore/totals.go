@@ -0,0 +1,105 @@
+package firestore
+
+import (
+	"context"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/spalqui/habitattrack-api/internal/models"
+)
+
+const totalsCollection = "property_totals"
+
+func totalsRef(ctx context.Context, client *firestore.Client, propertyID string) *firestore.DocumentRef {
+	return tenantCollection(ctx, client, totalsCollection).Doc(propertyID)
+}
+
+// adjustTotals adds the transaction to its property's running totals, or
+// removes it when sign is -1. It must run in the transaction that writes the
+// transaction itself so the totals can't drift.
+func adjustTotals(ctx context.Context, client *firestore.Client, tx *firestore.Transaction, transaction *models.Transaction, sign int) error {
+	if transaction == nil || transaction.PropertyID == "" {
+		return nil
+	}
+
+	var field string
+	switch transaction.Type {
+	case models.TransactionTypeIncome:
+		field = "income"
+	case models.TransactionTypeExpense:
+		field = "expense"
+	default:
+		return nil
+	}
+
+	return tx.Set(totalsRef(ctx, client, transaction.PropertyID), map[string]interface{}{
+		field:              firestore.Increment(int64(sign) * int64(transaction.Amount)),
+		"transactionCount": firestore.Increment(sign),
+		"updatedAt":        time.Now(),
+	}, firestore.MergeAll)
+}
+
+// unseedTotals marks the properties' totals for recomputing on their next
+// read, after writes that couldn't adjust them transactionally.
+func unseedTotals(ctx context.Context, client *firestore.Client, propertyIDs map[string]bool) error {
+	for propertyID := range propertyIDs {
+		if _, err := totalsRef(ctx, client, propertyID).Set(ctx, map[string]interface{}{
+			"seeded":    false,
+			"updatedAt": time.Now(),
+		}, firestore.MergeAll); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetPropertyTotals reads the property's running totals. Totals that have
+// never been seeded, such as for properties whose transactions predate them,
+// are computed from the transactions first; doing so in a transaction that
+// reads the totals document keeps concurrent writes from being lost.
+func (r *transactionRepository) GetPropertyTotals(ctx context.Context, propertyID string) (*models.PropertyTotals, error) {
+	ref := totalsRef(ctx, r.client, propertyID)
+
+	var totals models.PropertyTotals
+	err := r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(ref)
+		if err != nil && status.Code(err) != codes.NotFound {
+			return err
+		}
+		if err == nil {
+			if err := doc.DataTo(&totals); err != nil {
+				return err
+			}
+			if totals.Seeded {
+				return nil
+			}
+		}
+
+		query := tenantCollection(ctx, r.client, r.collection).Where("propertyId", "==", propertyID)
+		docs, err := tx.Documents(query).GetAll()
+		if err != nil {
+			return err
+		}
+
+		totals = models.PropertyTotals{Seeded: true, UpdatedAt: time.Now()}
+		for _, doc := range docs {
+			var transaction models.Transaction
+			if err := doc.DataTo(&transaction); err != nil {
+				return err
+			}
+			totals.Add(&transaction)
+		}
+
+		return tx.Set(ref, &totals)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	totals.PropertyID = propertyID
+	totals.Net = totals.Income - totals.Expense
+	return &totals, nil
+}
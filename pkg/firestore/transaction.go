@@ -2,41 +2,153 @@ package firestore
 
 import (
 	"context"
+	"fmt"
+	"net/url"
 	"time"
 
 	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	"github.com/spalqui/habitattrack-api/internal/models"
 	"github.com/spalqui/habitattrack-api/internal/repositories"
 )
 
 type transactionRepository struct {
-	client     *firestore.Client
-	collection string
+	client             *firestore.Client
+	collection         string
+	deletedCollection  string
+	externalCollection string
 }
 
 func NewTransactionRepository(client *firestore.Client) repositories.TransactionRepository {
 	return &transactionRepository{
-		client:     client,
-		collection: "transactions",
+		client:             client,
+		collection:         "transactions",
+		deletedCollection:  "deleted_transactions",
+		externalCollection: "transaction_external_ids",
 	}
 }
 
 func (r *transactionRepository) Create(ctx context.Context, transaction *models.Transaction) error {
-	transaction.CreatedAt = time.Now()
-	transaction.UpdatedAt = time.Now()
+	transaction.CreatedAt = storedNow()
+	transaction.UpdatedAt = storedNow()
 
-	docRef, _, err := r.client.Collection(r.collection).Add(ctx, transaction)
+	docRef := newDoc(tenantCollection(ctx, r.client, r.collection))
+	err := r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		// The index document makes external IDs unique per source
+		if transaction.ExternalID != "" {
+			if err := tx.Create(r.externalRef(ctx, transaction.Source, transaction.ExternalID), map[string]interface{}{
+				"transactionId": docRef.ID,
+			}); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Set(docRef, transaction); err != nil {
+			return err
+		}
+		if err := adjustAggregates(ctx, r.client, tx, transaction, 1); err != nil {
+			return err
+		}
+
+		created := *transaction
+		created.ID = docRef.ID
+		return enqueueEvent(ctx, r.client, tx, models.EventTransactionCreated, &created)
+	})
+	if status.Code(err) == codes.AlreadyExists {
+		return repositories.ErrExternalIDExists
+	}
 	if err != nil {
 		return err
 	}
 
 	transaction.ID = docRef.ID
-	return nil
+	return readBack(ctx, docRef, transaction)
+}
+
+// CreateMany writes the transactions with a BulkWriter, reporting each one's
+// outcome by its position. Transactions with an external ID are created one
+// at a time, since their uniqueness check needs a Firestore transaction.
+func (r *transactionRepository) CreateMany(ctx context.Context, transactions []*models.Transaction) (*models.BulkWriteResult, error) {
+	writes := newBulkWrites(ctx, r.client)
+	for i, transaction := range transactions {
+		if transaction.ExternalID != "" {
+			err := r.Create(ctx, transaction)
+			writes.record(i, transaction.ID, err)
+			continue
+		}
+
+		transaction.CreatedAt = storedNow()
+		transaction.UpdatedAt = storedNow()
+		docRef := newDoc(tenantCollection(ctx, r.client, r.collection))
+		transaction.ID = docRef.ID
+		writes.create(i, docRef, transaction)
+	}
+
+	result := writes.finish()
+	properties := make(map[string]bool)
+	var written []*models.Transaction
+	for _, item := range result.Items {
+		transaction := transactions[item.Index]
+		if item.Error != "" {
+			transaction.ID = ""
+		} else if transaction.ExternalID == "" {
+			if transaction.PropertyID != "" {
+				properties[transaction.PropertyID] = true
+			}
+			written = append(written, transaction)
+		}
+	}
+
+	// Bulk writes can't adjust the running totals and rollups, or queue the
+	// events, in the same transaction
+	if err := unseedTotals(ctx, r.client, properties); err != nil {
+		return nil, err
+	}
+	if err := addToRollups(ctx, r.client, written); err != nil {
+		return nil, err
+	}
+	if err := enqueueEvents(ctx, r.client, models.EventTransactionCreated, written); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (r *transactionRepository) GetByExternalID(ctx context.Context, source, externalID string) (*models.Transaction, error) {
+	doc, err := getDoc(ctx, r.externalRef(ctx, source, externalID))
+	if status.Code(err) == codes.NotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	transactionID, err := doc.DataAt("transactionId")
+	if err != nil {
+		return nil, err
+	}
+
+	id, ok := transactionID.(string)
+	if !ok || id == "" {
+		return nil, fmt.Errorf("external ID index %s has no transaction ID", doc.Ref.ID)
+	}
+
+	return r.GetByID(ctx, id)
+}
+
+// externalRef locates the index document for an external ID, which is unique
+// per organization and source.
+func (r *transactionRepository) externalRef(ctx context.Context, source, externalID string) *firestore.DocumentRef {
+	return tenantCollection(ctx, r.client, r.externalCollection).Doc(url.QueryEscape(source) + ":" + url.QueryEscape(externalID))
 }
 
 func (r *transactionRepository) GetByID(ctx context.Context, id string) (*models.Transaction, error) {
-	doc, err := r.client.Collection(r.collection).Doc(id).Get(ctx)
+	doc, err := getDoc(ctx, tenantCollection(ctx, r.client, r.collection).Doc(id))
+	if status.Code(err) == codes.NotFound {
+		return nil, repositories.ErrTransactionNotFound
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -51,7 +163,7 @@ func (r *transactionRepository) GetByID(ctx context.Context, id string) (*models
 }
 
 func (r *transactionRepository) GetByPropertyID(ctx context.Context, propertyID string) ([]*models.Transaction, error) {
-	docs, err := r.client.Collection(r.collection).Where("propertyId", "==", propertyID).Documents(ctx).GetAll()
+	docs, err := getAll(ctx, tenantCollection(ctx, r.client, r.collection).Where("propertyId", "==", propertyID))
 	if err != nil {
 		return nil, err
 	}
@@ -70,7 +182,26 @@ func (r *transactionRepository) GetByPropertyID(ctx context.Context, propertyID
 }
 
 func (r *transactionRepository) GetAll(ctx context.Context) ([]*models.Transaction, error) {
-	docs, err := r.client.Collection(r.collection).Documents(ctx).GetAll()
+	docs, err := getAll(ctx, tenantCollection(ctx, r.client, r.collection))
+	if err != nil {
+		return nil, err
+	}
+
+	transactions := make([]*models.Transaction, len(docs))
+	for i, doc := range docs {
+		var transaction models.Transaction
+		if err := doc.DataTo(&transaction); err != nil {
+			return nil, err
+		}
+		transaction.ID = doc.Ref.ID
+		transactions[i] = &transaction
+	}
+
+	return transactions, nil
+}
+
+func (r *transactionRepository) List(ctx context.Context, filter models.TransactionFilter) ([]*models.Transaction, error) {
+	docs, err := getAll(ctx, r.filterQuery(ctx, filter))
 	if err != nil {
 		return nil, err
 	}
@@ -88,13 +219,416 @@ func (r *transactionRepository) GetAll(ctx context.Context) ([]*models.Transacti
 	return transactions, nil
 }
 
+func (r *transactionRepository) ListPage(ctx context.Context, filter models.TransactionFilter, page models.PageRequest) (*models.Page[*models.Transaction], error) {
+	query, err := pageQuery(ctx, r.filterQuery(ctx, filter), tenantCollection(ctx, r.client, r.collection), page, pageOrder(filter)...)
+	if err != nil {
+		return nil, err
+	}
+
+	docs, err := getAll(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	docs, next, prev := pageCursors(docs, page)
+
+	transactions := make([]*models.Transaction, len(docs))
+	for i, doc := range docs {
+		var transaction models.Transaction
+		if err := doc.DataTo(&transaction); err != nil {
+			return nil, err
+		}
+		transaction.ID = doc.Ref.ID
+		transactions[i] = &transaction
+	}
+
+	return &models.Page[*models.Transaction]{Items: transactions, NextCursor: next, PrevCursor: prev}, nil
+}
+
+// Stream calls fn with each page of matching transactions, reading the next
+// page only once fn returns so a large result is never held in memory.
+func (r *transactionRepository) Stream(ctx context.Context, filter models.TransactionFilter, pageSize int, fn func(transactions []*models.Transaction) error) error {
+	query := r.filterQuery(ctx, filter).Limit(pageSize)
+	for {
+		docs, err := getAll(ctx, query)
+		if err != nil {
+			return err
+		}
+		if len(docs) == 0 {
+			return nil
+		}
+
+		transactions := make([]*models.Transaction, len(docs))
+		for i, doc := range docs {
+			var transaction models.Transaction
+			if err := doc.DataTo(&transaction); err != nil {
+				return err
+			}
+			transaction.ID = doc.Ref.ID
+			transactions[i] = &transaction
+		}
+
+		if err := fn(transactions); err != nil {
+			return err
+		}
+		if len(docs) < pageSize {
+			return nil
+		}
+
+		query = query.StartAfter(docs[len(docs)-1])
+	}
+}
+
+func (r *transactionRepository) Count(ctx context.Context, filter models.TransactionFilter) (int64, error) {
+	return countQuery(ctx, r.filterQuery(ctx, filter))
+}
+
+func (r *transactionRepository) CountByCategoryID(ctx context.Context, categoryID string) (int64, error) {
+	return countQuery(ctx, tenantCollection(ctx, r.client, r.collection).Where("categoryId", "==", categoryID))
+}
+
+// pageOrder returns the field a filtered page is ordered by before document
+// ID: the first field filterQuery filters by anything but equality, which
+// Firestore requires to come first when the filter is an inequality.
+func pageOrder(filter models.TransactionFilter) []string {
+	switch {
+	case filter.HasProperty != nil && *filter.HasProperty:
+		return []string{"propertyId"}
+	case len(filter.CategoryIDs) > 1:
+		return []string{"categoryId"}
+	case !filter.StartDate.IsZero() || !filter.EndDate.IsZero():
+		return []string{"date"}
+	default:
+		return nil
+	}
+}
+
+func (r *transactionRepository) filterQuery(ctx context.Context, filter models.TransactionFilter) firestore.Query {
+	query := tenantCollection(ctx, r.client, r.collection).Query
+
+	if filter.PropertyID != "" {
+		query = query.Where("propertyId", "==", filter.PropertyID)
+	}
+
+	if filter.HasProperty != nil {
+		if *filter.HasProperty {
+			query = query.Where("propertyId", "!=", "")
+		} else {
+			query = query.Where("propertyId", "==", "")
+		}
+	}
+
+	switch len(filter.CategoryIDs) {
+	case 0:
+	case 1:
+		query = query.Where("categoryId", "==", filter.CategoryIDs[0])
+	default:
+		query = query.Where("categoryId", "in", filter.CategoryIDs)
+	}
+
+	if !filter.StartDate.IsZero() {
+		query = query.Where("date", ">=", filter.StartDate)
+	}
+
+	if !filter.EndDate.IsZero() {
+		query = query.Where("date", "<=", filter.EndDate)
+	}
+
+	return query
+}
+
+// Update replaces the transaction, provided it hasn't been updated since the
+// version whose UpdatedAt it carries.
 func (r *transactionRepository) Update(ctx context.Context, transaction *models.Transaction) error {
-	transaction.UpdatedAt = time.Now()
-	_, err := r.client.Collection(r.collection).Doc(transaction.ID).Set(ctx, transaction)
-	return err
+	expected := transaction.UpdatedAt
+	transaction.UpdatedAt = storedNow()
+	docRef := tenantCollection(ctx, r.client, r.collection).Doc(transaction.ID)
+
+	return r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(docRef)
+		if status.Code(err) == codes.NotFound {
+			return repositories.ErrTransactionNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		var original models.Transaction
+		if err := doc.DataTo(&original); err != nil {
+			return err
+		}
+		if err := checkUnchanged(original.UpdatedAt, expected); err != nil {
+			return err
+		}
+
+		if err := tx.Update(docRef, fieldUpdates(transaction, "createdAt")); err != nil {
+			return err
+		}
+
+		if err := adjustAggregates(ctx, r.client, tx, &original, -1); err != nil {
+			return err
+		}
+		if err := adjustAggregates(ctx, r.client, tx, transaction, 1); err != nil {
+			return err
+		}
+		return enqueueEvent(ctx, r.client, tx, models.EventTransactionUpdated, transaction)
+	})
+}
+
+func (r *transactionRepository) UpdatePropertyName(ctx context.Context, propertyID, name string) error {
+	return r.updateDenormalizedField(ctx, "propertyId", propertyID, "propertyName", name)
+}
+
+func (r *transactionRepository) UpdateCategoryName(ctx context.Context, categoryID, name string) error {
+	return r.updateDenormalizedField(ctx, "categoryId", categoryID, "categoryName", name)
+}
+
+func (r *transactionRepository) updateDenormalizedField(ctx context.Context, keyField, key, field, value string) error {
+	docs, err := getAll(ctx, tenantCollection(ctx, r.client, r.collection).Where(keyField, "==", key))
+	if err != nil {
+		return err
+	}
+
+	for _, doc := range docs {
+		if current, _ := doc.DataAt(field); current == value {
+			continue
+		}
+		if _, err := doc.Ref.Update(ctx, []firestore.Update{{Path: field, Value: value}}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// maxTransactionWrites is Firestore's limit on writes in one transaction.
+const maxTransactionWrites = 500
+
+// writesPerTransaction is the most writes a batched change makes for one
+// transaction: the document itself, its totals and rollup on the way out and
+// on the way in, and its event. A delete also writes the deleted copy and
+// frees the external ID in place of the second totals and rollup.
+const writesPerTransaction = 6
+
+// writeBatchSize is the number of transactions changed in one Firestore
+// transaction, leaving a margin under maxTransactionWrites.
+const writeBatchSize = maxTransactionWrites/writesPerTransaction - 10
+
+func (r *transactionRepository) ReassignCategory(ctx context.Context, ids []string, categoryID, categoryName string) (int, error) {
+	return r.reassign(ctx, ids, []firestore.Update{
+		{Path: "categoryId", Value: categoryID},
+		{Path: "categoryName", Value: categoryName},
+	}, func(transaction *models.Transaction) {
+		transaction.CategoryID = categoryID
+		transaction.CategoryName = categoryName
+	})
+}
+
+// ReassignProperty moves the transactions to another property, and into the
+// months their dates fall in within its timezone.
+func (r *transactionRepository) ReassignProperty(ctx context.Context, ids []string, propertyID, propertyName, timezone string) (int, error) {
+	return r.reassign(ctx, ids, []firestore.Update{
+		{Path: "propertyId", Value: propertyID},
+		{Path: "propertyName", Value: propertyName},
+		{Path: "timezone", Value: timezone},
+	}, func(transaction *models.Transaction) {
+		transaction.PropertyID = propertyID
+		transaction.PropertyName = propertyName
+		transaction.Timezone = timezone
+	})
+}
+
+// SetTimezone moves the transactions to the months their dates fall in
+// within timezone.
+func (r *transactionRepository) SetTimezone(ctx context.Context, ids []string, timezone string) (int, error) {
+	return r.reassign(ctx, ids, []firestore.Update{
+		{Path: "timezone", Value: timezone},
+	}, func(transaction *models.Transaction) {
+		transaction.Timezone = timezone
+	})
+}
+
+// reassign applies the updates to each existing transaction and moves it
+// between totals and rollups, with apply making the same change to the
+// loaded transaction for its event.
+func (r *transactionRepository) reassign(ctx context.Context, ids []string, updates []firestore.Update, apply func(transaction *models.Transaction)) (int, error) {
+	updatedAt := storedNow()
+	updates = append(updates, firestore.Update{Path: "updatedAt", Value: updatedAt})
+
+	// The amounts are needed to move the aggregates, so load each batch first
+	return r.inBatchesOf(ctx, ids, func(tx *firestore.Transaction, batch []string) error {
+		docs, err := tx.GetAll(r.refs(ctx, batch))
+		if err != nil {
+			return err
+		}
+
+		for _, doc := range docs {
+			if !doc.Exists() {
+				continue
+			}
+
+			var transaction models.Transaction
+			if err := doc.DataTo(&transaction); err != nil {
+				return err
+			}
+
+			if err := tx.Update(doc.Ref, updates); err != nil {
+				return err
+			}
+
+			if err := adjustAggregates(ctx, r.client, tx, &transaction, -1); err != nil {
+				return err
+			}
+			apply(&transaction)
+			if err := adjustAggregates(ctx, r.client, tx, &transaction, 1); err != nil {
+				return err
+			}
+
+			transaction.ID = doc.Ref.ID
+			transaction.UpdatedAt = updatedAt
+			if err := enqueueEvent(ctx, r.client, tx, models.EventTransactionUpdated, &transaction); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *transactionRepository) DeleteMany(ctx context.Context, ids []string) (int, error) {
+	// Reads must precede writes in a Firestore transaction, so load each batch first
+	return r.inBatchesOf(ctx, ids, func(tx *firestore.Transaction, batch []string) error {
+		docs, err := tx.GetAll(r.refs(ctx, batch))
+		if err != nil {
+			return err
+		}
+
+		for _, doc := range docs {
+			if err := r.moveToDeleted(ctx, tx, doc); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *transactionRepository) refs(ctx context.Context, ids []string) []*firestore.DocumentRef {
+	refs := make([]*firestore.DocumentRef, len(ids))
+	for i, id := range ids {
+		refs[i] = tenantCollection(ctx, r.client, r.collection).Doc(id)
+	}
+	return refs
+}
+
+func (r *transactionRepository) inBatches(ctx context.Context, ids []string, write func(tx *firestore.Transaction, id string) error) (int, error) {
+	return r.inBatchesOf(ctx, ids, func(tx *firestore.Transaction, batch []string) error {
+		for _, id := range batch {
+			if err := write(tx, id); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *transactionRepository) inBatchesOf(ctx context.Context, ids []string, apply func(tx *firestore.Transaction, batch []string) error) (int, error) {
+	done := 0
+	for start := 0; start < len(ids); start += writeBatchSize {
+		batch := ids[start:min(start+writeBatchSize, len(ids))]
+
+		err := r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+			return apply(tx, batch)
+		})
+		if err != nil {
+			return done, err
+		}
+
+		done += len(batch)
+	}
+
+	return done, nil
 }
 
+// Delete moves the transaction into the deleted collection so it can be
+// reviewed before being purged.
 func (r *transactionRepository) Delete(ctx context.Context, id string) error {
-	_, err := r.client.Collection(r.collection).Doc(id).Delete(ctx)
-	return err
+	docRef := tenantCollection(ctx, r.client, r.collection).Doc(id)
+
+	return r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(docRef)
+		if status.Code(err) == codes.NotFound {
+			return repositories.ErrTransactionNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		return r.moveToDeleted(ctx, tx, doc)
+	})
+}
+
+func (r *transactionRepository) moveToDeleted(ctx context.Context, tx *firestore.Transaction, doc *firestore.DocumentSnapshot) error {
+	if !doc.Exists() {
+		return nil
+	}
+
+	var transaction models.Transaction
+	if err := doc.DataTo(&transaction); err != nil {
+		return err
+	}
+
+	deletedAt := time.Now()
+	transaction.DeletedAt = &deletedAt
+
+	if err := tx.Set(tenantCollection(ctx, r.client, r.deletedCollection).Doc(doc.Ref.ID), &transaction); err != nil {
+		return err
+	}
+
+	// Free the external ID so the source can record it again
+	if transaction.ExternalID != "" {
+		if err := tx.Delete(r.externalRef(ctx, transaction.Source, transaction.ExternalID)); err != nil {
+			return err
+		}
+	}
+
+	if err := adjustAggregates(ctx, r.client, tx, &transaction, -1); err != nil {
+		return err
+	}
+
+	if err := enqueueEvent(ctx, r.client, tx, models.EventTransactionDeleted, map[string]string{"id": doc.Ref.ID}); err != nil {
+		return err
+	}
+	return tx.Delete(doc.Ref)
+}
+
+func (r *transactionRepository) ListDeleted(ctx context.Context) ([]*models.Transaction, error) {
+	docs, err := getAll(ctx, tenantCollection(ctx, r.client, r.deletedCollection).OrderBy("deletedAt", firestore.Desc))
+	if err != nil {
+		return nil, err
+	}
+
+	transactions := make([]*models.Transaction, len(docs))
+	for i, doc := range docs {
+		var transaction models.Transaction
+		if err := doc.DataTo(&transaction); err != nil {
+			return nil, err
+		}
+		transaction.ID = doc.Ref.ID
+		transactions[i] = &transaction
+	}
+
+	return transactions, nil
+}
+
+func (r *transactionRepository) PurgeDeleted(ctx context.Context, olderThan time.Time) (int, error) {
+	docs, err := getAll(ctx, tenantCollection(ctx, r.client, r.deletedCollection).Where("deletedAt", "<", olderThan))
+	if err != nil {
+		return 0, err
+	}
+
+	deletes := newBulkWrites(ctx, r.client)
+	for i, doc := range docs {
+		deletes.delete(i, doc.Ref)
+	}
+
+	result := deletes.finish()
+	return result.Succeeded, result.Err()
 }
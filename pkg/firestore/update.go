@@ -0,0 +1,66 @@
+package firestore
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/firestore"
+
+	"github.com/spalqui/habitattrack-api/internal/repositories"
+)
+
+// storedNow is the current time as Firestore stores it, in UTC to the
+// microsecond, so an UpdatedAt returned to a client matches the stored one
+// exactly when the client sends it back.
+func storedNow() time.Time {
+	return time.Now().UTC().Truncate(time.Microsecond)
+}
+
+// checkUnchanged refuses an update made from the version of a document last
+// updated at expected when the stored one was updated at another time. A
+// zero expected skips the check.
+func checkUnchanged(stored, expected time.Time) error {
+	if !expected.IsZero() && !stored.Equal(expected) {
+		return repositories.ErrUpdateConflict
+	}
+	return nil
+}
+
+// fieldUpdates returns an update for each stored field of model, a pointer to
+// a struct, except those named in immutable. Updating by field path leaves
+// fields the model doesn't own untouched, and unlike Set it fails instead of
+// recreating a document deleted in the meantime. Zero omitempty fields are
+// deleted, matching what Set would have stored.
+func fieldUpdates(model interface{}, immutable ...string) []firestore.Update {
+	value := reflect.Indirect(reflect.ValueOf(model))
+	fields := value.Type()
+
+	var updates []firestore.Update
+	for i := 0; i < fields.NumField(); i++ {
+		field := fields.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, options, _ := strings.Cut(field.Tag.Get("firestore"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		if slices.Contains(immutable, name) {
+			continue
+		}
+
+		var fieldValue interface{} = value.Field(i).Interface()
+		if strings.Contains(options, "omitempty") && value.Field(i).IsZero() {
+			fieldValue = firestore.Delete
+		}
+		updates = append(updates, firestore.Update{Path: name, Value: fieldValue})
+	}
+
+	return updates
+}
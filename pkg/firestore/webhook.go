@@ -0,0 +1,236 @@
+package firestore
+
+import (
+	"context"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/spalqui/habitattrack-api/internal/models"
+	"github.com/spalqui/habitattrack-api/internal/repositories"
+)
+
+type webhookRepository struct {
+	client     *firestore.Client
+	collection string
+}
+
+func NewWebhookRepository(client *firestore.Client) repositories.WebhookRepository {
+	return &webhookRepository{
+		client:     client,
+		collection: "webhooks",
+	}
+}
+
+func (r *webhookRepository) Create(ctx context.Context, webhook *models.Webhook) error {
+	webhook.CreatedAt = time.Now()
+	webhook.UpdatedAt = time.Now()
+
+	docRef := newDoc(tenantCollection(ctx, r.client, r.collection))
+	if _, err := docRef.Create(ctx, webhook); err != nil {
+		return err
+	}
+
+	webhook.ID = docRef.ID
+	return nil
+}
+
+func (r *webhookRepository) GetByID(ctx context.Context, id string) (*models.Webhook, error) {
+	doc, err := getDoc(ctx, tenantCollection(ctx, r.client, r.collection).Doc(id))
+	if status.Code(err) == codes.NotFound {
+		return nil, repositories.ErrWebhookNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var webhook models.Webhook
+	if err := doc.DataTo(&webhook); err != nil {
+		return nil, err
+	}
+
+	webhook.ID = doc.Ref.ID
+	return &webhook, nil
+}
+
+func (r *webhookRepository) GetAll(ctx context.Context) ([]*models.Webhook, error) {
+	docs, err := getAll(ctx, tenantCollection(ctx, r.client, r.collection))
+	if err != nil {
+		return nil, err
+	}
+
+	return webhooksFromDocs(docs)
+}
+
+func (r *webhookRepository) GetByEvent(ctx context.Context, event string) ([]*models.Webhook, error) {
+	docs, err := getAll(ctx, tenantCollection(ctx, r.client, r.collection).Where("events", "array-contains-any", []string{event, models.EventAll}))
+	if err != nil {
+		return nil, err
+	}
+
+	return webhooksFromDocs(docs)
+}
+
+func (r *webhookRepository) Update(ctx context.Context, webhook *models.Webhook) error {
+	webhook.UpdatedAt = time.Now()
+	_, err := tenantCollection(ctx, r.client, r.collection).Doc(webhook.ID).Update(ctx, fieldUpdates(webhook, "createdAt"))
+	if status.Code(err) == codes.NotFound {
+		return repositories.ErrWebhookNotFound
+	}
+	return err
+}
+
+func (r *webhookRepository) Delete(ctx context.Context, id string) error {
+	_, err := tenantCollection(ctx, r.client, r.collection).Doc(id).Delete(ctx)
+	return err
+}
+
+func webhooksFromDocs(docs []*firestore.DocumentSnapshot) ([]*models.Webhook, error) {
+	webhooks := make([]*models.Webhook, len(docs))
+	for i, doc := range docs {
+		var webhook models.Webhook
+		if err := doc.DataTo(&webhook); err != nil {
+			return nil, err
+		}
+		webhook.ID = doc.Ref.ID
+		webhooks[i] = &webhook
+	}
+
+	return webhooks, nil
+}
+
+type webhookDeliveryRepository struct {
+	client     *firestore.Client
+	collection string
+}
+
+func NewWebhookDeliveryRepository(client *firestore.Client) repositories.WebhookDeliveryRepository {
+	return &webhookDeliveryRepository{
+		client:     client,
+		collection: "webhook_deliveries",
+	}
+}
+
+func (r *webhookDeliveryRepository) Create(ctx context.Context, delivery *models.WebhookDelivery) error {
+	delivery.CreatedAt = time.Now()
+
+	docRef := newDoc(tenantCollection(ctx, r.client, r.collection))
+	if _, err := docRef.Create(ctx, delivery); err != nil {
+		return err
+	}
+
+	delivery.ID = docRef.ID
+	return nil
+}
+
+func (r *webhookDeliveryRepository) GetByWebhookID(ctx context.Context, webhookID string) ([]*models.WebhookDelivery, error) {
+	docs, err := getAll(ctx, tenantCollection(ctx, r.client, r.collection).Where("webhookId", "==", webhookID))
+	if err != nil {
+		return nil, err
+	}
+
+	deliveries := make([]*models.WebhookDelivery, len(docs))
+	for i, doc := range docs {
+		var delivery models.WebhookDelivery
+		if err := doc.DataTo(&delivery); err != nil {
+			return nil, err
+		}
+		delivery.ID = doc.Ref.ID
+		deliveries[i] = &delivery
+	}
+
+	return deliveries, nil
+}
+
+// deliveryQueueRepository keeps every organization's pending deliveries in
+// one root collection, like the outbox, so one dispatcher can drain it.
+type deliveryQueueRepository struct {
+	client     *firestore.Client
+	collection string
+}
+
+func NewDeliveryQueueRepository(client *firestore.Client) repositories.DeliveryQueueRepository {
+	return &deliveryQueueRepository{
+		client:     client,
+		collection: "webhook_queue",
+	}
+}
+
+func (r *deliveryQueueRepository) Enqueue(ctx context.Context, deliveries []*models.PendingDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	return r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		for _, delivery := range deliveries {
+			delivery.AvailableAt = now
+			delivery.CreatedAt = now
+			if err := tx.Set(r.client.Collection(r.collection).Doc(delivery.ID), delivery); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *deliveryQueueRepository) Claim(ctx context.Context, limit int, lease time.Duration) ([]*models.PendingDelivery, error) {
+	var deliveries []*models.PendingDelivery
+	err := r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		now := time.Now()
+		query := r.client.Collection(r.collection).
+			Where("availableAt", "<=", now).
+			OrderBy("availableAt", firestore.Asc).
+			Limit(limit)
+
+		docs, err := tx.Documents(query).GetAll()
+		if err != nil {
+			return err
+		}
+
+		deliveries = make([]*models.PendingDelivery, len(docs))
+		for i, doc := range docs {
+			var delivery models.PendingDelivery
+			if err := doc.DataTo(&delivery); err != nil {
+				return err
+			}
+			delivery.ID = doc.Ref.ID
+			deliveries[i] = &delivery
+
+			if err := tx.Update(doc.Ref, []firestore.Update{{Path: "availableAt", Value: now.Add(lease)}}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+func (r *deliveryQueueRepository) Delete(ctx context.Context, id string) error {
+	_, err := r.client.Collection(r.collection).Doc(id).Delete(ctx)
+	return err
+}
+
+// Release makes a failed delivery available again at retryAt. A delivery
+// that has since been deleted is left alone.
+func (r *deliveryQueueRepository) Release(ctx context.Context, id string, retryAt time.Time, cause error) error {
+	_, err := r.client.Collection(r.collection).Doc(id).Update(ctx, []firestore.Update{
+		{Path: "availableAt", Value: retryAt},
+		{Path: "attempts", Value: firestore.Increment(1)},
+		{Path: "lastError", Value: cause.Error()},
+	})
+	if status.Code(err) == codes.NotFound {
+		return nil
+	}
+	return err
+}
+
+func (r *deliveryQueueRepository) CountDue(ctx context.Context) (int64, error) {
+	return countQuery(ctx, r.client.Collection(r.collection).Where("availableAt", "<=", time.Now()))
+}
@@ -0,0 +1,94 @@
+package gcs
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"cloud.google.com/go/storage"
+	"google.golang.org/api/iterator"
+
+	"github.com/spalqui/habitattrack-api/internal/models"
+	"github.com/spalqui/habitattrack-api/internal/repositories"
+)
+
+type backupStore struct {
+	bucket *storage.BucketHandle
+	prefix string
+}
+
+// NewBackupStore keeps backups as objects under backups/ in the bucket.
+func NewBackupStore(client *storage.Client, bucket string) repositories.BackupStore {
+	return &backupStore{
+		bucket: client.Bucket(bucket),
+		prefix: "backups/",
+	}
+}
+
+// Create starts writing the named backup. The object only appears once the
+// writer is closed; cancelling ctx first discards it.
+func (s *backupStore) Create(ctx context.Context, name string) io.WriteCloser {
+	writer := s.bucket.Object(s.prefix + name).NewWriter(ctx)
+	writer.ContentType = "application/x-ndjson"
+	return writer
+}
+
+func (s *backupStore) Open(ctx context.Context, name string) (io.ReadCloser, error) {
+	return s.bucket.Object(s.prefix + name).NewReader(ctx)
+}
+
+func (s *backupStore) List(ctx context.Context) ([]*models.Backup, error) {
+	var backups []*models.Backup
+
+	objects := s.bucket.Objects(ctx, &storage.Query{Prefix: s.prefix})
+	for {
+		attrs, err := objects.Next()
+		if err == iterator.Done {
+			return backups, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		backups = append(backups, &models.Backup{
+			Name:      strings.TrimPrefix(attrs.Name, s.prefix),
+			Size:      attrs.Size,
+			CreatedAt: attrs.Created,
+		})
+	}
+}
+
+func (s *backupStore) Delete(ctx context.Context, name string) error {
+	return s.bucket.Object(s.prefix + name).Delete(ctx)
+}
+
+// backupPermissions are what creating, listing, restoring and pruning
+// backups need on the bucket.
+var backupPermissions = []string{
+	"storage.objects.create",
+	"storage.objects.get",
+	"storage.objects.list",
+	"storage.objects.delete",
+}
+
+// CheckAccess confirms the bucket exists and the service account holds
+// every permission backups use.
+func (s *backupStore) CheckAccess(ctx context.Context) error {
+	granted, err := s.bucket.IAM().TestPermissions(ctx, backupPermissions)
+	if err != nil {
+		return err
+	}
+
+	var missing []string
+	for _, permission := range backupPermissions {
+		if !slices.Contains(granted, permission) {
+			missing = append(missing, permission)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("missing bucket permissions: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
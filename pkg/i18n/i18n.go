@@ -0,0 +1,293 @@
+package i18n
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const DefaultLanguage = "en"
+
+// Messages are keyed by their English text. Keys ending in ": " translate
+// the fixed prefix of messages that carry a dynamic suffix.
+var catalogs = map[string]map[string]string{
+	"es": {
+		"Invalid request body":                          "Cuerpo de la solicitud no válido",
+		"a valid http or https URL is required":         "se requiere una URL http o https válida",
+		"address is required":                           "la dirección es obligatoria",
+		"amount must be greater than zero":              "el importe debe ser mayor que cero",
+		"at least one event is required":                "se requiere al menos un evento",
+		"category ID is required":                       "el ID de la categoría es obligatorio",
+		"category ID is required for update":            "el ID de la categoría es obligatorio para actualizar",
+		"category name is required":                     "el nombre de la categoría es obligatorio",
+		"category not found":                            "categoría no encontrada",
+		"category type does not match transaction type": "el tipo de la categoría no coincide con el tipo de la transacción",
+		"invalid transaction type":                      "tipo de transacción no válido",
+		"postcode is required":                          "el código postal es obligatorio",
+		"property ID is required":                       "el ID de la propiedad es obligatorio",
+		"property ID is required for update":            "el ID de la propiedad es obligatorio para actualizar",
+		"property not found":                            "propiedad no encontrada",
+		"secret is required":                            "el secreto es obligatorio",
+		"transaction ID is required":                    "el ID de la transacción es obligatorio",
+		"transaction ID is required for update":         "el ID de la transacción es obligatorio para actualizar",
+		"unknown event: ":                               "evento desconocido: ",
+		"webhook ID is required":                        "el ID del webhook es obligatorio",
+		"webhook ID is required for update":             "el ID del webhook es obligatorio para actualizar",
+		"webhook not found":                             "webhook no encontrado",
+
+		"hasProperty must be true or false":                    "hasProperty debe ser true o false",
+		"at most 30 category IDs can be filtered at once":      "se pueden filtrar como máximo 30 IDs de categoría a la vez",
+		"propertyId cannot be combined with hasProperty=false": "propertyId no se puede combinar con hasProperty=false",
+
+		"startDate must be an RFC3339 timestamp or a YYYY-MM-DD date": "startDate debe ser una marca de tiempo RFC3339 o una fecha AAAA-MM-DD",
+		"endDate must be an RFC3339 timestamp or a YYYY-MM-DD date":   "endDate debe ser una marca de tiempo RFC3339 o una fecha AAAA-MM-DD",
+
+		"olderThan is required":                                       "olderThan es obligatorio",
+		"olderThan must not be in the future":                         "olderThan no puede estar en el futuro",
+		"olderThan must be an RFC3339 timestamp or a YYYY-MM-DD date": "olderThan debe ser una marca de tiempo RFC3339 o una fecha AAAA-MM-DD",
+
+		"rate limit exceeded": "se ha superado el límite de solicitudes",
+
+		"exactly one source category ID is required":               "se requiere exactamente un ID de categoría de origen",
+		"target category ID is required":                           "el ID de la categoría de destino es obligatorio",
+		"source and target categories must differ":                 "las categorías de origen y destino deben ser distintas",
+		"source category not found":                                "categoría de origen no encontrada",
+		"target category not found":                                "categoría de destino no encontrada",
+		"target category type does not match source category type": "el tipo de la categoría de destino no coincide con el de la categoría de origen",
+
+		"reassignTo and cascade cannot be combined":                  "reassignTo y cascade no se pueden combinar",
+		"cannot reassign transactions to the property being deleted": "no se pueden reasignar transacciones a la propiedad que se está eliminando",
+		"target property not found":                                  "propiedad de destino no encontrada",
+		"cascade must be true or false":                              "cascade debe ser true o false",
+
+		"date must be an RFC3339 timestamp or a YYYY-MM-DD date": "date debe ser una marca de tiempo RFC3339 o una fecha AAAA-MM-DD",
+
+		"a transaction with this external ID already exists for the source": "ya existe una transacción con este ID externo para el origen",
+		"source is required":      "el origen es obligatorio",
+		"external ID is required": "el ID externo es obligatorio",
+		"transaction not found":   "transacción no encontrada",
+
+		"authentication required":  "autenticación requerida",
+		"invalid or expired token": "token no válido o caducado",
+
+		"invalid API key": "clave de API no válida",
+		"authentication is required to manage API keys": "se requiere autenticación para gestionar claves de API",
+		"API key name is required":                      "el nombre de la clave de API es obligatorio",
+		"API key ID is required":                        "el ID de la clave de API es obligatorio",
+		"API key not found":                             "clave de API no encontrada",
+		"API key has been revoked":                      "la clave de API ha sido revocada",
+
+		"failed to resolve organization access":        "no se pudo resolver el acceso a la organización",
+		"no access to this organization":               "sin acceso a esta organización",
+		"insufficient permissions":                     "permisos insuficientes",
+		"user is already a member":                     "el usuario ya es miembro",
+		"member ID is required for update":             "el ID del miembro es obligatorio para actualizar",
+		"member not found":                             "miembro no encontrado",
+		"member ID is required":                        "el ID del miembro es obligatorio",
+		"authentication is required to manage members": "se requiere autenticación para gestionar miembros",
+		"member user ID is required":                   "el ID de usuario del miembro es obligatorio",
+		"you cannot change your own membership":        "no puede cambiar su propia membresía",
+		"role must be owner, editor or accountant":     "el rol debe ser owner, editor o accountant",
+
+		"authentication is required to manage organizations": "se requiere autenticación para gestionar organizaciones",
+		"organization name is required":                      "el nombre de la organización es obligatorio",
+		"organization ID is required":                        "el ID de la organización es obligatorio",
+		"organization ID is required for update":             "el ID de la organización es obligatorio para actualizar",
+		"organization not found":                             "organización no encontrada",
+
+		"insufficient scope": "alcance insuficiente",
+		"authentication is required to manage OAuth clients": "se requiere autenticación para gestionar clientes OAuth",
+		"client name is required":                            "el nombre del cliente es obligatorio",
+		"at least one scope is required":                     "se requiere al menos un alcance",
+		"unknown scope: ":                                    "alcance desconocido: ",
+		"client ID is required":                              "el ID del cliente es obligatorio",
+		"client not found":                                   "cliente no encontrado",
+
+		"user ID is required":              "el ID de usuario es obligatorio",
+		"token ID is required":             "el ID del token es obligatorio",
+		"administrator access required":    "se requiere acceso de administrador",
+		"token has been revoked":           "el token ha sido revocado",
+		"failed to check token revocation": "no se pudo comprobar la revocación del token",
+
+		"expiresAt must be in the future": "expiresAt debe estar en el futuro",
+		"API key has expired":             "la clave de API ha caducado",
+
+		"invalid or expired confirmation token":                                    "token de confirmación no válido o caducado",
+		"transfer ownership of these organizations before deleting your account: ": "transfiere la propiedad de estas organizaciones antes de eliminar tu cuenta: ",
+		"authentication is required to delete an account":                          "se requiere autenticación para eliminar una cuenta",
+		"account deletion requires signing in as the user":                         "eliminar la cuenta requiere iniciar sesión como el usuario",
+		"X-Confirmation-Token header is required":                                  "el encabezado X-Confirmation-Token es obligatorio",
+
+		"access from this network is not allowed": "no se permite el acceso desde esta red",
+
+		"multi-factor authentication is required for this operation": "esta operación requiere autenticación multifactor",
+		"a recent sign-in is required for this operation":            "esta operación requiere un inicio de sesión reciente",
+
+		"grace period must be between 0 and 168 hours": "el periodo de gracia debe estar entre 0 y 168 horas",
+
+		"you cannot impersonate yourself":            "no puedes suplantarte a ti mismo",
+		"a reason is required to impersonate a user": "se requiere un motivo para suplantar a un usuario",
+		"impersonation ID is required":               "el ID de la suplantación es obligatorio",
+		"impersonation not found":                    "suplantación no encontrada",
+		"impersonated must be true or false":         "impersonated debe ser true o false",
+
+		"authentication is required to record consent":                    "se requiere autenticación para registrar el consentimiento",
+		"consent must be given by the user":                               "el consentimiento debe darlo el propio usuario",
+		"document must be terms or privacy":                               "el documento debe ser terms o privacy",
+		"version is required":                                             "la versión es obligatoria",
+		"only the current version can be accepted: ":                      "solo se puede aceptar la versión actual: ",
+		"failed to check consent":                                         "no se pudo comprobar el consentimiento",
+		"you must accept the latest terms and privacy policy to continue": "debes aceptar los últimos términos y la política de privacidad para continuar",
+
+		"limit must be between 1 and 200": "limit debe estar entre 1 y 200",
+
+		"page token is invalid or expired": "el token de página no es válido o ha caducado",
+
+		"at least one transaction is required":            "se requiere al menos una transacción",
+		"at most 500 transactions can be created at once": "se pueden crear como máximo 500 transacciones a la vez",
+
+		"transaction is required": "se requiere la transacción",
+
+		"a category with this name already exists for the type": "ya existe una categoría con este nombre para el tipo",
+
+		"invalid backup name": "nombre de copia de seguridad no válido",
+
+		"from must be a month in YYYY-MM format": "from debe ser un mes en formato AAAA-MM",
+		"to must be a month in YYYY-MM format":   "to debe ser un mes en formato AAAA-MM",
+
+		"format must be csv or json": "format debe ser csv o json",
+
+		"pageToken is invalid":                       "pageToken no es válido",
+		"pageToken was issued for different filters": "pageToken se emitió para otros filtros",
+
+		"totalsMode must be exact, none or estimate": "totalsMode debe ser exact, none o estimate",
+
+		"verbose must be true or false": "verbose debe ser true o false",
+
+		"an unexpected error occurred": "se produjo un error inesperado",
+
+		"upload is too large":         "el archivo subido es demasiado grande",
+		"request body is too large":   "el cuerpo de la solicitud es demasiado grande",
+		"failed to read request body": "no se pudo leer el cuerpo de la solicitud",
+
+		"the service is under maintenance; writes are temporarily unavailable": "el servicio está en mantenimiento; las escrituras no están disponibles temporalmente",
+
+		"level must be debug, info, warn or error":    "level debe ser debug, info, warn o error",
+		"durationSeconds must be between 0 and 86400": "durationSeconds debe estar entre 0 y 86400",
+
+		"the service is temporarily unavailable; try again later": "el servicio no está disponible temporalmente; inténtelo de nuevo más tarde",
+
+		"task not found": "tarea no encontrada",
+		"task failed":    "la tarea ha fallado",
+
+		"dead letter not found":         "mensaje fallido no encontrado",
+		"kind must be webhook or event": "kind debe ser webhook o event",
+		"webhook no longer exists":      "el webhook ya no existe",
+		"webhook is inactive":           "el webhook está inactivo",
+
+		"category is in use": "la categoría está en uso",
+		"cannot reassign transactions to the category being deleted": "no se pueden reasignar las transacciones a la categoría que se está eliminando",
+
+		"property is in use": "la propiedad está en uso",
+
+		"a property with this name already exists": "ya existe una propiedad con este nombre",
+
+		"endDate must not be before startDate": "endDate no puede ser anterior a startDate",
+		"to must not be before from":           "to no puede ser anterior a from",
+
+		"timezone must be an IANA time zone name such as Europe/London": "timezone debe ser un nombre de zona horaria IANA, como Europe/London",
+
+		"Request body has an unknown field": "El cuerpo de la solicitud tiene un campo desconocido",
+
+		"propertyId cannot be cleared": "propertyId no se puede borrar",
+		"type cannot be cleared":       "type no se puede borrar",
+		"categoryId cannot be cleared": "categoryId no se puede borrar",
+		"amount cannot be cleared":     "amount no se puede borrar",
+		"date cannot be cleared":       "date no se puede borrar",
+		"address cannot be cleared":    "address no se puede borrar",
+		"postcode cannot be cleared":   "postcode no se puede borrar",
+
+		"a similar transaction already exists": "ya existe una transacción similar",
+
+		"transaction date is in the future":         "la fecha de la transacción está en el futuro",
+		"the transaction is dated in the future":    "la transacción tiene una fecha futura",
+		"futureDates must be allow, warn or reject": "futureDates debe ser allow, warn o reject",
+
+		"the record was changed by another request; fetch it again and retry": "otra solicitud modificó el registro; vuelve a obtenerlo e inténtalo de nuevo",
+
+		"a category with transactions can't change between income and expense; create a new category instead": "una categoría con transacciones no puede cambiar entre ingreso y gasto; crea una categoría nueva en su lugar",
+
+		"webhook URL must not point to a private or internal address": "la URL del webhook no debe apuntar a una dirección privada o interna",
+
+		"Bad Request":           "Solicitud incorrecta",
+		"Unauthorized":          "No autorizado",
+		"Forbidden":             "Prohibido",
+		"Not Found":             "No encontrado",
+		"Conflict":              "Conflicto",
+		"Unprocessable Entity":  "Entidad no procesable",
+		"Too Many Requests":     "Demasiadas solicitudes",
+		"Internal Server Error": "Error interno del servidor",
+	},
+}
+
+func Negotiate(acceptLanguage string) string {
+	type candidate struct {
+		lang    string
+		quality float64
+	}
+
+	var candidates []candidate
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if tag == "" {
+			continue
+		}
+
+		quality := 1.0
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if v, err := strconv.ParseFloat(q, 64); err == nil {
+				quality = v
+			}
+		}
+
+		base, _, _ := strings.Cut(strings.ToLower(tag), "-")
+		candidates = append(candidates, candidate{lang: base, quality: quality})
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].quality > candidates[j].quality
+	})
+
+	for _, c := range candidates {
+		if c.quality <= 0 {
+			continue
+		}
+		if c.lang == DefaultLanguage {
+			return DefaultLanguage
+		}
+		if _, ok := catalogs[c.lang]; ok {
+			return c.lang
+		}
+	}
+
+	return DefaultLanguage
+}
+
+func Translate(lang, message string) string {
+	catalog, ok := catalogs[lang]
+	if !ok {
+		return message
+	}
+
+	if translated, ok := catalog[message]; ok {
+		return translated
+	}
+
+	for key, translated := range catalog {
+		if strings.HasSuffix(key, ": ") && strings.HasPrefix(message, key) {
+			return translated + strings.TrimPrefix(message, key)
+		}
+	}
+
+	return message
+}
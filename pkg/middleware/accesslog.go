@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/spalqui/habitattrack-api/pkg/requestid"
+)
+
+type accessLogKey struct{}
+
+// accessLogEntry collects fields set by handlers further down the chain,
+// which can't change the request the logger holds.
+type accessLogEntry struct {
+	userID string
+}
+
+// setAccessLogUser records the authenticated user on the request's access
+// log entry, if there is one.
+func setAccessLogUser(ctx context.Context, userID string) {
+	if entry, ok := ctx.Value(accessLogKey{}).(*accessLogEntry); ok {
+		entry.userID = userID
+	}
+}
+
+// AccessLog writes a structured log line for each request once it has been
+// served.
+func AccessLog(logger *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			entry := &accessLogEntry{}
+
+			// Capture the status code and size of the response
+			wrapped := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+
+			next.ServeHTTP(wrapped, r.WithContext(context.WithValue(r.Context(), accessLogKey{}, entry)))
+
+			logger.LogAttrs(r.Context(), slog.LevelInfo, "request",
+				slog.String("method", r.Method),
+				slog.String("path", r.URL.Path),
+				slog.Int("status", wrapped.statusCode),
+				slog.Float64("latencyMs", float64(time.Since(start).Microseconds())/1000),
+				slog.String("userId", entry.userID),
+				slog.String("requestId", requestid.FromContext(r.Context())),
+				slog.Int64("responseBytes", wrapped.size),
+			)
+		})
+	}
+}
+
+type responseWriter struct {
+	http.ResponseWriter
+	statusCode int
+	size       int64
+}
+
+func (rw *responseWriter) WriteHeader(code int) {
+	rw.statusCode = code
+	rw.ResponseWriter.WriteHeader(code)
+}
+
+func (rw *responseWriter) Write(b []byte) (int, error) {
+	n, err := rw.ResponseWriter.Write(b)
+	rw.size += int64(n)
+	return n, err
+}
+
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
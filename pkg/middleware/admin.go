@@ -0,0 +1,21 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/spalqui/habitattrack-api/pkg/auth"
+	"github.com/spalqui/habitattrack-api/pkg/utils"
+)
+
+// RequireAdmin restricts routes to principals with the admin claim. Like
+// Authorize, it lets requests through when authentication is disabled.
+func RequireAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if principal, ok := auth.FromContext(r.Context()); ok && !principal.Admin {
+			utils.WriteErrorResponse(w, r, http.StatusForbidden, "administrator access required")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/spalqui/habitattrack-api/pkg/utils"
+)
+
+// IPAllowlist rejects requests to the given path prefixes unless the client
+// IP falls within one of the networks. Other paths are unaffected.
+func IPAllowlist(networks []*net.IPNet, proxyHops int, pathPrefixes ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !matchesPrefix(r.URL.Path, pathPrefixes) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ip := net.ParseIP(ClientIP(r, proxyHops))
+			for _, network := range networks {
+				if ip != nil && network.Contains(ip) {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+
+			utils.WriteErrorResponse(w, r, http.StatusForbidden, "access from this network is not allowed")
+		})
+	}
+}
+
+// ParseCIDRs parses a comma-separated list of CIDR ranges. Bare IP addresses
+// are treated as single-host ranges.
+func ParseCIDRs(value string) ([]*net.IPNet, error) {
+	var networks []*net.IPNet
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid IP address %q", entry)
+			}
+			bits := 8 * len(ip.To16())
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR range %q", entry)
+		}
+		networks = append(networks, network)
+	}
+
+	return networks, nil
+}
+
+// matchesPrefix reports whether path is one of the prefixes or lies beneath
+// one, matching whole path segments.
+func matchesPrefix(path string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if path == prefix || strings.HasPrefix(path, strings.TrimSuffix(prefix, "/")+"/") {
+			return true
+		}
+	}
+	return false
+}
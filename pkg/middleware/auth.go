@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/spalqui/habitattrack-api/pkg/auth"
+	"github.com/spalqui/habitattrack-api/pkg/utils"
+)
+
+// Authenticate requires a valid X-API-Key or bearer ID token on every request
+// except preflights and the given public paths, and stores the caller in the
+// request context. Bearer tokens are also checked against revocations.
+func Authenticate(tokens auth.TokenVerifier, apiKeys auth.APIKeyVerifier, revocations auth.RevocationChecker, publicPaths ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodOptions || slices.Contains(publicPaths, r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if key := r.Header.Get("X-API-Key"); key != "" {
+				principal, err := apiKeys.VerifyAPIKey(r.Context(), key)
+				if err != nil {
+					utils.WriteErrorResponse(w, r, http.StatusUnauthorized, "invalid API key")
+					return
+				}
+
+				setAccessLogUser(r.Context(), principal.UserID)
+				next.ServeHTTP(w, r.WithContext(auth.WithPrincipal(r.Context(), principal)))
+				return
+			}
+
+			token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || token == "" {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				utils.WriteErrorResponse(w, r, http.StatusUnauthorized, "authentication required")
+				return
+			}
+
+			principal, err := tokens.Verify(r.Context(), token)
+			if err != nil {
+				w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+				utils.WriteErrorResponse(w, r, http.StatusUnauthorized, "invalid or expired token")
+				return
+			}
+
+			revoked, err := revocations.IsRevoked(r.Context(), principal)
+			if err != nil {
+				log.Printf("Checking token revocation: %v", err)
+				utils.WriteErrorResponse(w, r, http.StatusInternalServerError, "failed to check token revocation")
+				return
+			}
+			if revoked {
+				w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+				utils.WriteErrorResponse(w, r, http.StatusUnauthorized, "token has been revoked")
+				return
+			}
+
+			setAccessLogUser(r.Context(), principal.UserID)
+			next.ServeHTTP(w, r.WithContext(auth.WithPrincipal(r.Context(), principal)))
+		})
+	}
+}
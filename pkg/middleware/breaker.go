@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/spalqui/habitattrack-api/pkg/breaker"
+	"github.com/spalqui/habitattrack-api/pkg/utils"
+)
+
+const ErrorCodeBackendUnavailable = "backend_unavailable"
+
+// FailFast turns the server error a handler writes after a backend call was
+// rejected by an open circuit breaker into 503 with a Retry-After, so
+// clients back off instead of retrying straight away.
+func FailFast(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r = r.WithContext(breaker.WithTrips(r.Context()))
+		next.ServeHTTP(&failFastWriter{ResponseWriter: w, r: r}, r)
+	})
+}
+
+type failFastWriter struct {
+	http.ResponseWriter
+	r           *http.Request
+	wroteHeader bool
+	replaced    bool
+}
+
+func (fw *failFastWriter) WriteHeader(code int) {
+	if fw.wroteHeader {
+		return
+	}
+	fw.wroteHeader = true
+
+	if code >= http.StatusInternalServerError {
+		if open := breaker.Tripped(fw.r.Context()); open != nil {
+			// The handler's own body is dropped in favour of this one
+			fw.replaced = true
+			fw.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(open.RetryAfter.Seconds()))))
+			utils.WriteErrorResponseWithCode(fw.ResponseWriter, fw.r, http.StatusServiceUnavailable, ErrorCodeBackendUnavailable, "the service is temporarily unavailable; try again later")
+			return
+		}
+	}
+	fw.ResponseWriter.WriteHeader(code)
+}
+
+func (fw *failFastWriter) Write(b []byte) (int, error) {
+	if !fw.wroteHeader {
+		fw.WriteHeader(http.StatusOK)
+	}
+	if fw.replaced {
+		return len(b), nil
+	}
+	return fw.ResponseWriter.Write(b)
+}
+
+func (fw *failFastWriter) Unwrap() http.ResponseWriter {
+	return fw.ResponseWriter
+}
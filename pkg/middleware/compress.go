@@ -0,0 +1,174 @@
+package middleware
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var gzipWriters = sync.Pool{
+	New: func() interface{} { return gzip.NewWriter(io.Discard) },
+}
+
+// Compress gzip- or deflate-encodes JSON responses of at least minBytes for
+// clients that accept it. Smaller responses are sent as they are, since
+// compressing them costs more than it saves.
+func Compress(minBytes int) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Vary", "Accept-Encoding")
+
+			encoding := acceptedEncoding(r.Header.Get("Accept-Encoding"))
+			if encoding == "" || r.Method == http.MethodHead {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			cw := &compressWriter{ResponseWriter: w, encoding: encoding, minBytes: minBytes, statusCode: http.StatusOK}
+			defer cw.finish()
+
+			next.ServeHTTP(cw, r)
+		})
+	}
+}
+
+// acceptedEncoding picks gzip, then deflate, from an Accept-Encoding header,
+// or "" when the client accepts neither.
+func acceptedEncoding(header string) string {
+	accepted := make(map[string]bool)
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		quality := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if q, err := strconv.ParseFloat(value, 64); err == nil {
+				quality = q
+			}
+		}
+		accepted[strings.ToLower(strings.TrimSpace(name))] = quality > 0
+	}
+
+	switch {
+	case accepted["gzip"] || accepted["*"]:
+		return "gzip"
+	case accepted["deflate"]:
+		return "deflate"
+	default:
+		return ""
+	}
+}
+
+// compressWriter buffers the start of the response until it knows whether
+// the body reaches minBytes, then either compresses or passes it through.
+type compressWriter struct {
+	http.ResponseWriter
+	encoding   string
+	minBytes   int
+	statusCode int
+
+	buf        []byte
+	decided    bool
+	compressor io.WriteCloser
+}
+
+func (cw *compressWriter) WriteHeader(code int) {
+	if !cw.decided {
+		cw.statusCode = code
+	}
+}
+
+func (cw *compressWriter) Write(p []byte) (int, error) {
+	if cw.decided {
+		if cw.compressor != nil {
+			return cw.compressor.Write(p)
+		}
+		return cw.ResponseWriter.Write(p)
+	}
+
+	cw.buf = append(cw.buf, p...)
+	if len(cw.buf) >= cw.minBytes {
+		if err := cw.decide(true); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// decide writes the header and buffered body, compressed if large is set and
+// the response is uncompressed JSON.
+func (cw *compressWriter) decide(large bool) error {
+	cw.decided = true
+
+	header := cw.Header()
+	compress := large &&
+		header.Get("Content-Encoding") == "" &&
+		strings.Contains(header.Get("Content-Type"), "json")
+
+	if compress {
+		header.Set("Content-Encoding", cw.encoding)
+		header.Del("Content-Length")
+
+		if cw.encoding == "gzip" {
+			gz := gzipWriters.Get().(*gzip.Writer)
+			gz.Reset(cw.ResponseWriter)
+			cw.compressor = gz
+		} else {
+			fw, err := flate.NewWriter(cw.ResponseWriter, flate.DefaultCompression)
+			if err != nil {
+				return err
+			}
+			cw.compressor = fw
+		}
+	}
+
+	cw.ResponseWriter.WriteHeader(cw.statusCode)
+
+	buf := cw.buf
+	cw.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	_, err := cw.Write(buf)
+	return err
+}
+
+// FlushError sends everything written so far, compressed if the response is
+// JSON, so streamed responses aren't held back until minBytes is buffered.
+func (cw *compressWriter) FlushError() error {
+	if !cw.decided {
+		if err := cw.decide(true); err != nil {
+			return err
+		}
+	}
+
+	if flusher, ok := cw.compressor.(interface{ Flush() error }); ok {
+		if err := flusher.Flush(); err != nil {
+			return err
+		}
+	}
+	return http.NewResponseController(cw.ResponseWriter).Flush()
+}
+
+func (cw *compressWriter) Flush() {
+	cw.FlushError()
+}
+
+func (cw *compressWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
+func (cw *compressWriter) finish() {
+	if !cw.decided {
+		cw.decide(false)
+	}
+
+	if cw.compressor != nil {
+		cw.compressor.Close()
+		if gz, ok := cw.compressor.(*gzip.Writer); ok {
+			gzipWriters.Put(gz)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	"github.com/spalqui/habitattrack-api/pkg/auth"
+	"github.com/spalqui/habitattrack-api/pkg/utils"
+)
+
+const ErrorCodeConsentRequired = "consent_required"
+
+type ConsentChecker interface {
+	MissingConsents(ctx context.Context, userID string) ([]string, error)
+}
+
+// RequireConsent blocks signed-in users who have not accepted the current
+// terms or privacy policy. Integrations and impersonating admins are not
+// asked, and the exempt path prefixes stay reachable so users can review and
+// accept the documents.
+func RequireConsent(consents ConsentChecker, exemptPrefixes ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal, ok := auth.FromContext(r.Context())
+			if !ok || principal.UserID == "" || principal.APIKeyID != "" || principal.ImpersonatorID != "" ||
+				r.Method == http.MethodOptions || matchesPrefix(r.URL.Path, exemptPrefixes) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			missing, err := consents.MissingConsents(r.Context(), principal.UserID)
+			if err != nil {
+				log.Printf("Checking consent for %s: %v", principal.UserID, err)
+				utils.WriteErrorResponse(w, r, http.StatusInternalServerError, "failed to check consent")
+				return
+			}
+
+			if len(missing) > 0 {
+				utils.WriteErrorResponseWithCode(w, r, http.StatusForbidden, ErrorCodeConsentRequired, "you must accept the latest terms and privacy policy to continue")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"expvar"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+var legacyRouteRequests = expvar.NewMap("legacy_route_requests")
+
+// Deprecated marks a route as legacy: responses advertise the sunset date
+// and successor URL, and each hit is counted per route template in the
+// legacy_route_requests expvar.
+func Deprecated(sunset time.Time, successor func(r *http.Request) string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route := r.URL.Path
+			if current := mux.CurrentRoute(r); current != nil {
+				if template, err := current.GetPathTemplate(); err == nil {
+					route = template
+				}
+			}
+			legacyRouteRequests.Add(r.Method+" "+route, 1)
+
+			w.Header().Set("Deprecation", "true")
+			if !sunset.IsZero() {
+				w.Header().Set("Sunset", sunset.UTC().Format(http.TimeFormat))
+			}
+			w.Header().Add("Link", "<"+successor(r)+`>; rel="successor-version"`)
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
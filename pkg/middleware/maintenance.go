@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/spalqui/habitattrack-api/pkg/utils"
+)
+
+const ErrorCodeMaintenance = "maintenance"
+
+type MaintenanceChecker interface {
+	InMaintenance(ctx context.Context) (bool, time.Duration, error)
+}
+
+// Maintenance refuses writes with 503 and a Retry-After while maintenance
+// mode is on; reads carry on. The exempt path prefixes keep the switch
+// reachable so it can be turned off again. If the switch can't be read,
+// requests are let through rather than failing every write.
+func Maintenance(checker MaintenanceChecker, exemptPrefixes ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions:
+				next.ServeHTTP(w, r)
+				return
+			}
+			if matchesPrefix(r.URL.Path, exemptPrefixes) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			enabled, retryAfter, err := checker.InMaintenance(r.Context())
+			if err != nil {
+				log.Printf("Checking maintenance mode: %v", err)
+			}
+			if !enabled {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter.Seconds())))
+			utils.WriteErrorResponseWithCode(w, r, http.StatusServiceUnavailable, ErrorCodeMaintenance, "the service is under maintenance; writes are temporarily unavailable")
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/spalqui/habitattrack-api/pkg/auth"
+	"github.com/spalqui/habitattrack-api/pkg/utils"
+)
+
+const (
+	ErrorCodeMFARequired            = "mfa_required"
+	ErrorCodeReauthenticationNeeded = "reauthentication_required"
+)
+
+// RequireMFA guards destructive operations: the caller must be a user who
+// signed in with a second factor within maxAge. Failures carry an error code
+// telling the client whether to enrol in MFA or just sign in again.
+func RequireMFA(maxAge time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal, ok := auth.FromContext(r.Context())
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if !principal.MFA || principal.APIKeyID != "" || principal.ClientID != "" {
+				utils.WriteErrorResponseWithCode(w, r, http.StatusForbidden, ErrorCodeMFARequired, "multi-factor authentication is required for this operation")
+				return
+			}
+
+			if time.Since(principal.IssuedAt) > maxAge {
+				utils.WriteErrorResponseWithCode(w, r, http.StatusForbidden, ErrorCodeReauthenticationNeeded, "a recent sign-in is required for this operation")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// When applies the middleware only to requests matching the condition.
+func When(condition func(r *http.Request) bool, middleware func(http.Handler) http.Handler) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		guarded := middleware(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if condition(r) {
+				guarded.ServeHTTP(w, r)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
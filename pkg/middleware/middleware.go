@@ -1,58 +1,69 @@
 package middleware
 
 import (
-	"log"
 	"net/http"
-	"time"
+	"slices"
+	"sync/atomic"
 )
 
-func CORS(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
-
-		if r.Method == "OPTIONS" {
-			w.WriteHeader(http.StatusOK)
-			return
-		}
-
-		next.ServeHTTP(w, r)
-	})
+// CORSOrigins is the list of origins allowed to call the API from a
+// browser, which can be replaced while requests are being served. "*"
+// allows any origin.
+type CORSOrigins struct {
+	origins atomic.Pointer[[]string]
 }
 
-func JSONContentType(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-		next.ServeHTTP(w, r)
-	})
+func NewCORSOrigins(origins []string) *CORSOrigins {
+	c := &CORSOrigins{}
+	c.Set(origins)
+	return c
 }
 
-func Logging(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		start := time.Now()
+func (c *CORSOrigins) Set(origins []string) {
+	c.origins.Store(&origins)
+}
 
-		// Create a custom ResponseWriter to capture status code
-		wrapped := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+// allowOrigin returns the Access-Control-Allow-Origin value for the request
+// origin, or "" when it isn't allowed.
+func (c *CORSOrigins) allowOrigin(origin string) string {
+	origins := *c.origins.Load()
+	if slices.Contains(origins, "*") {
+		return "*"
+	}
+	if origin != "" && slices.Contains(origins, origin) {
+		return origin
+	}
+	return ""
+}
 
-		next.ServeHTTP(wrapped, r)
+func CORS(origins *CORSOrigins) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			allowed := origins.allowOrigin(r.Header.Get("Origin"))
+			if allowed != "*" {
+				w.Header().Add("Vary", "Origin")
+			}
+			if allowed != "" {
+				w.Header().Set("Access-Control-Allow-Origin", allowed)
+				w.Header().Set("Timing-Allow-Origin", allowed)
+			}
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-API-Key, X-Organization-ID, X-Request-ID, X-Confirmation-Token")
+			w.Header().Set("Access-Control-Expose-Headers", "X-Total-Count, X-RateLimit-Limit, X-RateLimit-Remaining, X-RateLimit-Reset, Retry-After, Deprecation, Sunset, Link, X-Request-ID, Server-Timing")
 
-		log.Printf(
-			"%s %s %d %v",
-			r.Method,
-			r.RequestURI,
-			wrapped.statusCode,
-			time.Since(start),
-		)
-	})
-}
+			if r.Method == "OPTIONS" {
+				w.WriteHeader(http.StatusOK)
+				return
+			}
 
-type responseWriter struct {
-	http.ResponseWriter
-	statusCode int
+			next.ServeHTTP(w, r)
+		})
+	}
 }
 
-func (rw *responseWriter) WriteHeader(code int) {
-	rw.statusCode = code
-	rw.ResponseWriter.WriteHeader(code)
+func JSONContentType(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		next.ServeHTTP(w, r)
+	})
 }
@@ -0,0 +1,117 @@
+package middleware
+
+import (
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/spalqui/habitattrack-api/pkg/auth"
+	"github.com/spalqui/habitattrack-api/pkg/ratelimit"
+	"github.com/spalqui/habitattrack-api/pkg/utils"
+)
+
+// RateLimitByIP limits all requests from each client address. It runs
+// before Authenticate, so requests that fail authentication still count and
+// guessing credentials costs the guesser rather than the datastore.
+func RateLimitByIP(limiter ratelimit.Limiter, policy *ratelimit.DynamicPolicy, proxyHops int) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			caller := "ip:" + ClientIP(r, proxyHops)
+			if enforce(w, r, limiter, caller+":all", policy.Load().AddressQuota(caller)) {
+				next.ServeHTTP(w, r)
+			}
+		})
+	}
+}
+
+// RateLimit keeps separate read and write buckets per caller. It should run
+// after Authenticate so callers are identified by principal rather than by
+// IP. The policy is read per request, so changes to it apply straight away.
+func RateLimit(limiter ratelimit.Limiter, policy *ratelimit.DynamicPolicy, proxyHops int) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			caller := rateLimitKey(r, proxyHops)
+
+			write := true
+			switch r.Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions:
+				write = false
+			}
+
+			bucket := caller + ":read"
+			if write {
+				bucket = caller + ":write"
+			}
+
+			if enforce(w, r, limiter, bucket, policy.Load().Quota(caller, write)) {
+				next.ServeHTTP(w, r)
+			}
+		})
+	}
+}
+
+// enforce takes a request from the bucket, answering 429 and reporting false
+// when it's empty.
+func enforce(w http.ResponseWriter, r *http.Request, limiter ratelimit.Limiter, bucket string, quota ratelimit.Quota) bool {
+	result, err := limiter.Allow(r.Context(), bucket, quota)
+	if err != nil {
+		// Fail open so a limiter outage doesn't take the API down with it
+		log.Printf("Rate limiter error: %v", err)
+		return true
+	}
+
+	w.Header().Set("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+	w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(result.Reset.Unix(), 10))
+
+	if !result.Allowed {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(result.RetryAfter.Seconds()))))
+		utils.WriteErrorResponse(w, r, http.StatusTooManyRequests, "rate limit exceeded")
+		return false
+	}
+	return true
+}
+
+// rateLimitKey identifies the caller by authenticated principal, falling
+// back to the client IP. Unverified credentials aren't used, since a caller
+// could present a new one with every request.
+func rateLimitKey(r *http.Request, proxyHops int) string {
+	if principal, ok := auth.FromContext(r.Context()); ok {
+		switch {
+		case principal.APIKeyID != "":
+			return "apiKey:" + principal.APIKeyID
+		case principal.ClientID != "":
+			return "client:" + principal.ClientID
+		case principal.UserID != "":
+			return "user:" + principal.UserID
+		}
+	}
+
+	return "ip:" + ClientIP(r, proxyHops)
+}
+
+// ClientIP is the address of the client behind proxyHops trusted proxies.
+// Each proxy appends the address it received the request from to
+// X-Forwarded-For, so the client's is the proxyHops-th entry from the right;
+// anything further left was sent by the client and can't be trusted. With no
+// trusted proxies, or too few entries, it's the connection's address.
+func ClientIP(r *http.Request, proxyHops int) string {
+	if proxyHops > 0 {
+		var entries []string
+		for _, header := range r.Header.Values("X-Forwarded-For") {
+			entries = append(entries, strings.Split(header, ",")...)
+		}
+		if len(entries) >= proxyHops {
+			return strings.TrimSpace(entries[len(entries)-proxyHops])
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
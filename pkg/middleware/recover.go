@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/spalqui/habitattrack-api/pkg/errorreport"
+	"github.com/spalqui/habitattrack-api/pkg/requestid"
+	"github.com/spalqui/habitattrack-api/pkg/utils"
+)
+
+const ErrorCodeInternal = "internal_error"
+
+// Recover turns a panicking handler into a logged stack trace and the usual
+// error body, rather than a dropped connection. Handlers that abort on
+// purpose with http.ErrAbortHandler are left to net/http. The panic goes to
+// reporter too, unless it's nil.
+func Recover(logger *slog.Logger, reporter errorreport.Reporter) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			wrapped := &recoverWriter{ResponseWriter: w}
+
+			defer func() {
+				recovered := recover()
+				if recovered == nil {
+					return
+				}
+				if recovered == http.ErrAbortHandler {
+					panic(recovered)
+				}
+
+				stack := debug.Stack()
+				logger.ErrorContext(r.Context(), "panic serving request",
+					slog.String("method", r.Method),
+					slog.String("path", r.URL.Path),
+					slog.String("requestId", requestid.FromContext(r.Context())),
+					slog.String("panic", fmt.Sprint(recovered)),
+					slog.String("stack", string(stack)),
+				)
+				if reporter != nil {
+					event := errorreport.FromRequest(r, http.StatusInternalServerError, fmt.Sprintf("panic: %v", recovered))
+					event.Stack = stack
+					reporter.Report(r.Context(), event)
+					r = r.WithContext(errorreport.MarkReported(r.Context()))
+				}
+
+				// Part of a response may already be on its way
+				if wrapped.wroteHeader {
+					panic(http.ErrAbortHandler)
+				}
+				utils.WriteErrorResponseWithCode(w, r, http.StatusInternalServerError, ErrorCodeInternal, "an unexpected error occurred")
+			}()
+
+			next.ServeHTTP(wrapped, r)
+		})
+	}
+}
+
+type recoverWriter struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+func (rw *recoverWriter) WriteHeader(code int) {
+	rw.wroteHeader = true
+	rw.ResponseWriter.WriteHeader(code)
+}
+
+func (rw *recoverWriter) Write(b []byte) (int, error) {
+	rw.wroteHeader = true
+	return rw.ResponseWriter.Write(b)
+}
+
+func (rw *recoverWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/spalqui/habitattrack-api/pkg/servertiming"
+)
+
+// ServerTiming adds a Server-Timing header breaking down where the time
+// went before the response started: Firestore reads and writes,
+// serialization and the total.
+func ServerTiming(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, timings := servertiming.NewContext(r.Context())
+		next.ServeHTTP(&timingWriter{ResponseWriter: w, timings: timings}, r.WithContext(ctx))
+	})
+}
+
+type timingWriter struct {
+	http.ResponseWriter
+	timings     *servertiming.Timings
+	wroteHeader bool
+}
+
+func (tw *timingWriter) WriteHeader(code int) {
+	if !tw.wroteHeader {
+		tw.wroteHeader = true
+		tw.Header().Set("Server-Timing", tw.timings.Header())
+	}
+	tw.ResponseWriter.WriteHeader(code)
+}
+
+func (tw *timingWriter) Write(b []byte) (int, error) {
+	if !tw.wroteHeader {
+		tw.WriteHeader(http.StatusOK)
+	}
+	return tw.ResponseWriter.Write(b)
+}
+
+func (tw *timingWriter) Timings() *servertiming.Timings {
+	return tw.timings
+}
+
+func (tw *timingWriter) Unwrap() http.ResponseWriter {
+	return tw.ResponseWriter
+}
@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/spalqui/habitattrack-api/pkg/utils"
+)
+
+type TaskCallerVerifier interface {
+	Verify(ctx context.Context, token string) error
+}
+
+// RequireTaskCaller only lets the task queue call the task receiver, which
+// otherwise sits outside user authentication.
+func RequireTaskCaller(callers TaskCallerVerifier) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || token == "" {
+				utils.WriteErrorResponse(w, r, http.StatusUnauthorized, "authentication required")
+				return
+			}
+
+			if err := callers.Verify(r.Context(), token); err != nil {
+				log.Printf("Rejected task call: %v", err)
+				utils.WriteErrorResponse(w, r, http.StatusUnauthorized, "invalid or expired token")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
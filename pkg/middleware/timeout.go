@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// Timeouts are the deadlines given to each kind of request. A zero duration
+// leaves that kind of request without a deadline.
+type Timeouts struct {
+	Read  time.Duration
+	List  time.Duration
+	Write time.Duration
+}
+
+// Timeout bounds each request's context by the deadline for its kind:
+// fetching a single resource, listing a collection, or writing. Requests
+// under any of the exempt path prefixes, such as long-running exports, run
+// without a deadline.
+func Timeout(timeouts Timeouts, exemptPrefixes ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if matchesPrefix(r.URL.Path, exemptPrefixes) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			timeout := timeouts.Write
+			switch r.Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions:
+				timeout = timeouts.List
+				if isResourceRoute(r) {
+					timeout = timeouts.Read
+				}
+			}
+			if timeout <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// isResourceRoute reports whether the matched route addresses a single
+// resource, which is the case when its path ends in a variable such as
+// /properties/{id}.
+func isResourceRoute(r *http.Request) bool {
+	route := mux.CurrentRoute(r)
+	if route == nil {
+		return false
+	}
+
+	template, err := route.GetPathTemplate()
+	if err != nil {
+		return false
+	}
+	return strings.HasSuffix(template, "}")
+}
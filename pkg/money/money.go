@@ -0,0 +1,198 @@
+// Package money holds amounts exactly, as whole minor units of the service's
+// currency (pence for GBP), so sums don't pick up floating-point error. In
+// JSON an amount is a decimal number with the currency's number of decimal
+// places, such as 12.30.
+package money
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Amount is a number of minor units of the service's currency.
+type Amount int64
+
+// Currency is an ISO 4217 currency and its number of decimal places.
+type Currency struct {
+	Code     string
+	Decimals int
+}
+
+// currencies are the currencies the service can keep its books in.
+var currencies = map[string]int{
+	"AUD": 2,
+	"CAD": 2,
+	"CHF": 2,
+	"DKK": 2,
+	"EUR": 2,
+	"GBP": 2,
+	"JPY": 0,
+	"KWD": 3,
+	"NOK": 2,
+	"NZD": 2,
+	"SEK": 2,
+	"USD": 2,
+	"ZAR": 2,
+}
+
+var currency = Currency{Code: "GBP", Decimals: 2}
+
+// Supported reports whether code is a currency the service can use.
+func Supported(code string) bool {
+	_, ok := currencies[code]
+	return ok
+}
+
+// ValidCode reports whether code has the form of an ISO 4217 code: three
+// upper-case letters.
+func ValidCode(code string) bool {
+	if len(code) != 3 {
+		return false
+	}
+	for _, r := range code {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+// Codes lists the supported currencies in alphabetical order.
+func Codes() []string {
+	codes := make([]string, 0, len(currencies))
+	for code := range currencies {
+		codes = append(codes, code)
+	}
+	slices.Sort(codes)
+	return codes
+}
+
+// SetCurrency selects the currency amounts are in. Every stored amount is
+// in minor units of this currency, so it can't change once there's data.
+func SetCurrency(code string) error {
+	if !ValidCode(code) {
+		return fmt.Errorf("currency %q is not an ISO 4217 code, three upper-case letters such as GBP", code)
+	}
+	decimals, ok := currencies[code]
+	if !ok {
+		return fmt.Errorf("unsupported currency %q, not one of %v", code, Codes())
+	}
+	currency = Currency{Code: code, Decimals: decimals}
+	return nil
+}
+
+// CurrentCurrency returns the currency amounts are in.
+func CurrentCurrency() Currency {
+	return currency
+}
+
+func unit() int64 {
+	scale := int64(1)
+	for i := 0; i < currency.Decimals; i++ {
+		scale *= 10
+	}
+	return scale
+}
+
+// Parse reads a decimal amount in major units, such as "12.30" or "-5". It
+// fails rather than round an amount with more decimal places than the
+// currency has.
+func Parse(s string) (Amount, error) {
+	text, negative := strings.CutPrefix(s, "-")
+	whole, fraction, point := strings.Cut(text, ".")
+	if !isDigits(whole) || (point && !isDigits(fraction)) {
+		return 0, fmt.Errorf("invalid amount %q", s)
+	}
+
+	fraction = strings.TrimRight(fraction, "0")
+	if len(fraction) > currency.Decimals {
+		return 0, fmt.Errorf("amount %s has more than %d decimal places", s, currency.Decimals)
+	}
+	fraction += strings.Repeat("0", currency.Decimals-len(fraction))
+
+	minor, err := strconv.ParseInt(whole+fraction, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("amount %s is out of range", s)
+	}
+	if negative {
+		minor = -minor
+	}
+	return Amount(minor), nil
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// FromFloat rounds f, in major units, to the nearest minor unit, with
+// half-way cases rounded away from zero. It's for amounts that were stored
+// as floats before amounts were exact. It fails if f isn't a number or is
+// too large for an Amount.
+func FromFloat(f float64) (Amount, error) {
+	minor := math.Round(f * float64(unit()))
+	if !(minor >= math.MinInt64 && minor < math.MaxInt64) {
+		return 0, fmt.Errorf("amount %v is out of range", f)
+	}
+	return Amount(minor), nil
+}
+
+// Float64 approximates the amount in major units, for clients that can only
+// take floats.
+func (a Amount) Float64() float64 {
+	return float64(a) / float64(unit())
+}
+
+// String formats the amount in major units with the currency's decimal
+// places, such as "12.30".
+func (a Amount) String() string {
+	sign := ""
+	magnitude := uint64(a)
+	if a < 0 {
+		sign = "-"
+		magnitude = -magnitude
+	}
+
+	scale := uint64(unit())
+	whole := strconv.FormatUint(magnitude/scale, 10)
+	if currency.Decimals == 0 {
+		return sign + whole
+	}
+	return fmt.Sprintf("%s%s.%0*d", sign, whole, currency.Decimals, magnitude%scale)
+}
+
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+// UnmarshalJSON accepts a JSON number or a string holding one.
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	text := string(data)
+	if text == "null" {
+		return nil
+	}
+	if unquoted, err := strconv.Unquote(text); err == nil {
+		text = unquoted
+	}
+	if text == "" {
+		return errors.New("amount is empty")
+	}
+
+	amount, err := Parse(text)
+	if err != nil {
+		return err
+	}
+	*a = amount
+	return nil
+}
@@ -0,0 +1,155 @@
+package money
+
+import (
+	"math"
+	"testing"
+)
+
+func withCurrency(t *testing.T, code string) {
+	t.Helper()
+	previous := currency
+	if err := SetCurrency(code); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { currency = previous })
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		currency string
+		in       string
+		want     Amount
+		wantErr  bool
+	}{
+		{"GBP", "12.30", 1230, false},
+		{"GBP", "12.3", 1230, false},
+		{"GBP", "12", 1200, false},
+		{"GBP", "0.01", 1, false},
+		{"GBP", "-5", -500, false},
+		{"GBP", "-0.05", -5, false},
+		{"GBP", "-0", 0, false},
+		{"GBP", "1.2300", 123, false},
+		{"GBP", "1.234", 0, true},
+		{"GBP", "-1.005", 0, true},
+		{"JPY", "150", 150, false},
+		{"JPY", "150.5", 0, true},
+		{"KWD", "1.234", 1234, false},
+		{"KWD", "1.2345", 0, true},
+		{"GBP", "92233720368547758.07", 9223372036854775807, false},
+		{"GBP", "92233720368547758.08", 0, true},
+		{"GBP", "-92233720368547758.08", 0, true},
+		{"GBP", "", 0, true},
+		{"GBP", "-", 0, true},
+		{"GBP", ".5", 0, true},
+		{"GBP", "5.", 0, true},
+		{"GBP", "+5", 0, true},
+		{"GBP", "1e3", 0, true},
+		{"GBP", "1,000", 0, true},
+		{"GBP", "--5", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.currency+" "+tt.in, func(t *testing.T) {
+			withCurrency(t, tt.currency)
+			got, err := Parse(tt.in)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Parse(%q) = %d, want an error", tt.in, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.in, err)
+			}
+			if got != tt.want {
+				t.Errorf("Parse(%q) = %d, want %d", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestString(t *testing.T) {
+	tests := []struct {
+		currency string
+		in       Amount
+		want     string
+	}{
+		{"GBP", 1230, "12.30"},
+		{"GBP", 5, "0.05"},
+		{"GBP", 0, "0.00"},
+		{"GBP", -5, "-0.05"},
+		{"GBP", -1230, "-12.30"},
+		{"GBP", 9223372036854775807, "92233720368547758.07"},
+		{"GBP", -9223372036854775808, "-92233720368547758.08"},
+		{"JPY", 150, "150"},
+		{"JPY", -150, "-150"},
+		{"KWD", 1234, "1.234"},
+		{"KWD", -1, "-0.001"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.currency+" "+tt.want, func(t *testing.T) {
+			withCurrency(t, tt.currency)
+			if got := tt.in.String(); got != tt.want {
+				t.Errorf("Amount(%d).String() = %q, want %q", int64(tt.in), got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStringParseRoundTrip(t *testing.T) {
+	withCurrency(t, "GBP")
+	for _, amount := range []Amount{0, 1, -1, 99, -100, 123456, 9223372036854775807} {
+		got, err := Parse(amount.String())
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", amount.String(), err)
+		}
+		if got != amount {
+			t.Errorf("Parse(%q) = %d, want %d", amount.String(), got, amount)
+		}
+	}
+}
+
+func TestFromFloat(t *testing.T) {
+	tests := []struct {
+		currency string
+		in       float64
+		want     Amount
+		wantErr  bool
+	}{
+		{"GBP", 12.3, 1230, false},
+		{"GBP", 0.1 + 0.2, 30, false},
+		{"GBP", -5, -500, false},
+		{"GBP", -0.05, -5, false},
+		// Half-way cases round away from zero.
+		{"GBP", 0.125, 13, false},
+		{"GBP", -0.125, -13, false},
+		{"GBP", 0.375, 38, false},
+		{"JPY", 0.5, 1, false},
+		{"JPY", -0.5, -1, false},
+		{"JPY", 2.5, 3, false},
+		{"KWD", 0.0005, 1, false},
+		{"JPY", -math.Exp2(63), math.MinInt64, false},
+		{"JPY", math.Exp2(63), 0, true},
+		{"GBP", 1e17, 0, true},
+		{"GBP", -1e17, 0, true},
+		{"GBP", math.Inf(1), 0, true},
+		{"GBP", math.NaN(), 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.currency, func(t *testing.T) {
+			withCurrency(t, tt.currency)
+			got, err := FromFloat(tt.in)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("FromFloat(%v) = %d, want an error", tt.in, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("FromFloat(%v): %v", tt.in, err)
+			}
+			if got != tt.want {
+				t.Errorf("FromFloat(%v) = %d, want %d", tt.in, got, tt.want)
+			}
+		})
+	}
+}
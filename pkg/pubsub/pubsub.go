@@ -0,0 +1,60 @@
+// Package pubsub publishes entity change events to a Pub/Sub topic, for
+// consumers that would otherwise poll the API.
+package pubsub
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	pubsub "google.golang.org/api/pubsub/v1"
+
+	"github.com/spalqui/habitattrack-api/internal/models"
+	"github.com/spalqui/habitattrack-api/pkg/auth"
+)
+
+type Publisher struct {
+	service *pubsub.Service
+	topic   string
+}
+
+// NewPublisher publishes to the topic, named in full as
+// projects/PROJECT/topics/TOPIC.
+func NewPublisher(ctx context.Context, topic string) (*Publisher, error) {
+	service, err := pubsub.NewService(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Publisher{service: service, topic: topic}, nil
+}
+
+// Send publishes the event as JSON, the same document webhooks receive. The
+// type, ID and organization are repeated as attributes so subscriptions can
+// filter on them.
+func (p *Publisher) Send(ctx context.Context, event *models.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	attributes := map[string]string{
+		"eventType": event.Type,
+		"eventId":   event.ID,
+	}
+	if orgID := auth.OrgID(ctx); orgID != "" {
+		attributes["orgId"] = orgID
+	}
+
+	_, err = p.service.Projects.Topics.Publish(p.topic, &pubsub.PublishRequest{
+		Messages: []*pubsub.PubsubMessage{{
+			Data:       base64.StdEncoding.EncodeToString(data),
+			Attributes: attributes,
+		}},
+	}).Context(ctx).Do()
+	if err != nil {
+		return fmt.Errorf("publishing event %s to %s: %w", event.ID, p.topic, err)
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package ratelimit
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// Policy holds separate quotas for reads and writes, and Address, the quota
+// for all requests from one client address before they're authenticated.
+// Overrides replace them for specific callers, keyed like "apiKey:<id>",
+// "client:<id>", "user:<id>" or "ip:<address>".
+type Policy struct {
+	Read      Quota
+	Write     Quota
+	Address   Quota
+	Overrides map[string]Quota
+}
+
+// Quota returns the quota for the caller's read or write bucket.
+func (p Policy) Quota(caller string, write bool) Quota {
+	if quota, ok := p.Overrides[caller]; ok {
+		return quota
+	}
+	if write {
+		return p.Write
+	}
+	return p.Read
+}
+
+// AddressQuota returns the quota for all requests from the address, keyed
+// "ip:<address>".
+func (p Policy) AddressQuota(caller string) Quota {
+	if quota, ok := p.Overrides[caller]; ok {
+		return quota
+	}
+	return p.Address
+}
+
+// ParseOverrides parses a comma-separated list of caller=rate/burst entries,
+// e.g. "apiKey:abc=50/100,client:xyz=20/40".
+func ParseOverrides(value string) (map[string]Quota, error) {
+	overrides := make(map[string]Quota)
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		caller, limits, ok := strings.Cut(entry, "=")
+		rate, burst, ok2 := strings.Cut(limits, "/")
+		if !ok || !ok2 || caller == "" {
+			return nil, fmt.Errorf("invalid rate limit override %q", entry)
+		}
+
+		quota := Quota{}
+		var err error
+		if quota.Rate, err = strconv.ParseFloat(rate, 64); err != nil || quota.Rate <= 0 {
+			return nil, fmt.Errorf("invalid rate in rate limit override %q", entry)
+		}
+		if quota.Burst, err = strconv.Atoi(burst); err != nil || quota.Burst < 1 {
+			return nil, fmt.Errorf("invalid burst in rate limit override %q", entry)
+		}
+
+		overrides[strings.TrimSpace(caller)] = quota
+	}
+
+	return overrides, nil
+}
+
+// DynamicPolicy holds the policy in force, which can be replaced while
+// requests are being served.
+type DynamicPolicy struct {
+	current atomic.Pointer[Policy]
+}
+
+func NewDynamicPolicy(policy Policy) *DynamicPolicy {
+	d := &DynamicPolicy{}
+	d.Store(policy)
+	return d
+}
+
+func (d *DynamicPolicy) Load() Policy {
+	return *d.current.Load()
+}
+
+func (d *DynamicPolicy) Store(policy Policy) {
+	d.current.Store(&policy)
+}
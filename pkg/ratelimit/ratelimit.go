@@ -0,0 +1,45 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+type Quota struct {
+	Rate  float64
+	Burst int
+}
+
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Time
+	RetryAfter time.Duration
+}
+
+// Limiter implements a token bucket per key: each key may burst up to
+// quota.Burst requests, refilled at quota.Rate tokens per second.
+type Limiter interface {
+	Allow(ctx context.Context, key string, quota Quota) (Result, error)
+}
+
+func newResult(allowed bool, tokens float64, quota Quota, now time.Time) Result {
+	result := Result{
+		Allowed:   allowed,
+		Limit:     quota.Burst,
+		Remaining: int(math.Floor(tokens)),
+		Reset:     now.Add(secondsToDuration((float64(quota.Burst) - tokens) / quota.Rate)),
+	}
+
+	if !allowed {
+		result.RetryAfter = secondsToDuration((1 - tokens) / quota.Rate)
+	}
+
+	return result
+}
+
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
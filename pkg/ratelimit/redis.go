@@ -0,0 +1,68 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var tokenBucketScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+
+local state = redis.call("HMGET", KEYS[1], "tokens", "ts")
+local tokens = tonumber(state[1]) or burst
+local ts = tonumber(state[2]) or now
+
+tokens = math.min(burst, tokens + math.max(0, now - ts) * rate)
+
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+
+redis.call("HSET", KEYS[1], "tokens", tokens, "ts", now)
+redis.call("EXPIRE", KEYS[1], math.ceil(burst / rate) + 1)
+
+return {allowed, tostring(tokens)}
+`)
+
+type redisLimiter struct {
+	client *redis.Client
+	prefix string
+}
+
+func NewRedisLimiter(client *redis.Client) Limiter {
+	return &redisLimiter{
+		client: client,
+		prefix: "ratelimit:",
+	}
+}
+
+func (l *redisLimiter) Allow(ctx context.Context, key string, quota Quota) (Result, error) {
+	now := time.Now()
+	seconds := float64(now.UnixMicro()) / 1e6
+
+	reply, err := tokenBucketScript.Run(ctx, l.client, []string{l.prefix + key}, quota.Rate, quota.Burst, seconds).Slice()
+	if err != nil {
+		return Result{}, err
+	}
+
+	if len(reply) != 2 {
+		return Result{}, fmt.Errorf("unexpected rate limit script reply: %v", reply)
+	}
+
+	allowed, _ := reply[0].(int64)
+	tokens, err := strconv.ParseFloat(fmt.Sprint(reply[1]), 64)
+	if err != nil {
+		return Result{}, err
+	}
+
+	return newResult(allowed == 1, math.Max(0, tokens), quota, now), nil
+}
@@ -0,0 +1,69 @@
+// Package sanitize cleans free text from clients before it's stored, so
+// stray control characters and markup don't end up in exports or the web UI.
+package sanitize
+
+import (
+	"fmt"
+	"html"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Policy is how free text is cleaned. A max length of zero doesn't limit.
+type Policy struct {
+	MaxNameLength        int
+	MaxDescriptionLength int
+	EscapeHTML           bool
+}
+
+// Clean trims s, collapses each run of whitespace to a single space and
+// drops other control characters.
+func Clean(s string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range s {
+		switch {
+		case unicode.IsSpace(r):
+			space = true
+		case unicode.IsControl(r), r == utf8.RuneError:
+		default:
+			if space && b.Len() > 0 {
+				b.WriteByte(' ')
+			}
+			space = false
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// Name cleans a short field such as a name, in place.
+func (p Policy) Name(field string, s *string) error {
+	return p.apply(field, s, p.MaxNameLength)
+}
+
+// Description cleans a long field such as a description, in place.
+func (p Policy) Description(field string, s *string) error {
+	return p.apply(field, s, p.MaxDescriptionLength)
+}
+
+// apply counts characters before escaping, and unescapes first so that
+// saving text that was escaped when stored doesn't escape it twice.
+func (p Policy) apply(field string, s *string, max int) error {
+	text := *s
+	if p.EscapeHTML {
+		text = html.UnescapeString(text)
+	}
+	text = Clean(text)
+
+	if max > 0 && utf8.RuneCountInString(text) > max {
+		return fmt.Errorf("%s must be at most %d characters", field, max)
+	}
+
+	if p.EscapeHTML {
+		text = html.EscapeString(text)
+	}
+	*s = text
+	return nil
+}
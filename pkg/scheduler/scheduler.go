@@ -0,0 +1,140 @@
+// Package scheduler runs recurring jobs on cron schedules, with each
+// occurrence run by a single instance of the service.
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// Job is recurring work. Schedule is a standard five-field cron expression
+// or a descriptor such as "@daily" or "@every 6h", in the server's time
+// zone unless prefixed with "CRON_TZ=". Unlike cron's, "@every" intervals count from the Unix
+// epoch rather than start-up, so every instance agrees on the occurrences.
+type Job struct {
+	Name     string
+	Schedule string
+	Run      func(ctx context.Context) error
+}
+
+// Locker claims an occurrence of a job for one instance. Claim reports false
+// when another instance has already claimed that occurrence or a later one.
+type Locker interface {
+	Claim(ctx context.Context, job string, occurrence time.Time, owner string) (bool, error)
+}
+
+type entry struct {
+	job      Job
+	schedule cron.Schedule
+}
+
+// Scheduler runs its jobs until the context passed to Run is done. Without a
+// Locker every instance runs every occurrence.
+type Scheduler struct {
+	locker  Locker
+	owner   string
+	logger  *slog.Logger
+	entries []entry
+}
+
+// New returns a scheduler that identifies itself to locker as owner.
+func New(locker Locker, owner string, logger *slog.Logger) *Scheduler {
+	return &Scheduler{locker: locker, owner: owner, logger: logger}
+}
+
+// Add registers a job, failing when its schedule can't be parsed.
+func (s *Scheduler) Add(job Job) error {
+	schedule, err := parse(job.Schedule)
+	if err != nil {
+		return fmt.Errorf("job %s: invalid schedule %q: %w", job.Name, job.Schedule, err)
+	}
+
+	s.entries = append(s.entries, entry{job: job, schedule: schedule})
+	return nil
+}
+
+func parse(spec string) (cron.Schedule, error) {
+	if interval, ok := strings.CutPrefix(spec, "@every "); ok {
+		d, err := time.ParseDuration(interval)
+		if err != nil {
+			return nil, err
+		}
+		if d < time.Second {
+			return nil, errors.New("interval must be at least a second")
+		}
+		return every(d), nil
+	}
+	return cron.ParseStandard(spec)
+}
+
+type every time.Duration
+
+func (e every) Next(t time.Time) time.Time {
+	return t.Truncate(time.Duration(e)).Add(time.Duration(e))
+}
+
+// Run starts every job and blocks until ctx is done. A job's next
+// occurrence is skipped while its previous run is still going.
+func (s *Scheduler) Run(ctx context.Context) {
+	done := make(chan struct{})
+	for _, e := range s.entries {
+		go func() {
+			s.loop(ctx, e)
+			done <- struct{}{}
+		}()
+	}
+
+	for range s.entries {
+		<-done
+	}
+}
+
+func (s *Scheduler) loop(ctx context.Context, e entry) {
+	var last time.Time
+	for {
+		// A timer firing early mustn't repeat the occurrence it fired for
+		from := time.Now()
+		if from.Before(last) {
+			from = last
+		}
+		occurrence := e.schedule.Next(from)
+		timer := time.NewTimer(time.Until(occurrence))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+			s.run(ctx, e, occurrence)
+			last = occurrence
+		}
+	}
+}
+
+func (s *Scheduler) run(ctx context.Context, e entry, occurrence time.Time) {
+	logger := s.logger.With("job", e.job.Name, "occurrence", occurrence)
+
+	if s.locker != nil {
+		claimed, err := s.locker.Claim(ctx, e.job.Name, occurrence, s.owner)
+		if err != nil {
+			logger.Error("Claiming scheduled job failed", "error", err)
+			return
+		}
+		if !claimed {
+			logger.Debug("Scheduled job claimed by another instance")
+			return
+		}
+	}
+
+	start := time.Now()
+	if err := e.job.Run(ctx); err != nil {
+		logger.Error("Scheduled job failed", "error", err, "duration", time.Since(start))
+		return
+	}
+	logger.Info("Scheduled job finished", "duration", time.Since(start))
+}
@@ -0,0 +1,88 @@
+// Package servertiming collects how long each part of serving a request
+// took, for the Server-Timing response header.
+package servertiming
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	FirestoreRead  = "firestore-read"
+	FirestoreWrite = "firestore-write"
+	Serialize      = "serialize"
+)
+
+// Timings accumulates durations by metric name. Its methods are safe for
+// concurrent use and do nothing on a nil *Timings, so callers needn't check
+// whether a request is being timed.
+type Timings struct {
+	mu        sync.Mutex
+	start     time.Time
+	names     []string
+	durations map[string]time.Duration
+}
+
+type contextKey struct{}
+
+// NewContext starts timing a request.
+func NewContext(ctx context.Context) (context.Context, *Timings) {
+	timings := &Timings{start: time.Now(), durations: make(map[string]time.Duration)}
+	return context.WithValue(ctx, contextKey{}, timings), timings
+}
+
+// FromContext returns the request's timings, or nil when it isn't timed.
+func FromContext(ctx context.Context) *Timings {
+	timings, _ := ctx.Value(contextKey{}).(*Timings)
+	return timings
+}
+
+// FromWriter finds the timings of the request w responds to, through any
+// wrapping writers, or returns nil.
+func FromWriter(w http.ResponseWriter) *Timings {
+	for {
+		switch writer := w.(type) {
+		case interface{ Timings() *Timings }:
+			return writer.Timings()
+		case interface{ Unwrap() http.ResponseWriter }:
+			w = writer.Unwrap()
+		default:
+			return nil
+		}
+	}
+}
+
+func (t *Timings) Add(name string, d time.Duration) {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, ok := t.durations[name]; !ok {
+		t.names = append(t.names, name)
+	}
+	t.durations[name] += d
+}
+
+// Header formats the timings so far, followed by the total time since the
+// request started.
+func (t *Timings) Header() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	metrics := make([]string, 0, len(t.names)+1)
+	for _, name := range t.names {
+		metrics = append(metrics, metric(name, t.durations[name]))
+	}
+	metrics = append(metrics, metric("total", time.Since(t.start)))
+	return strings.Join(metrics, ", ")
+}
+
+func metric(name string, d time.Duration) string {
+	return fmt.Sprintf("%s;dur=%.1f", name, float64(d.Microseconds())/1000)
+}
@@ -0,0 +1,102 @@
+package tasks
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	cloudtasks "google.golang.org/api/cloudtasks/v2"
+	"google.golang.org/api/idtoken"
+)
+
+// ReceiverPath is where Cloud Tasks delivers a task, followed by its name.
+const ReceiverPath = "/internal/tasks/"
+
+type cloudQueue struct {
+	service        *cloudtasks.Service
+	queue          string
+	baseURL        string
+	serviceAccount string
+}
+
+// NewCloudQueue queues tasks on the Cloud Tasks queue, named in full as
+// projects/PROJECT/locations/LOCATION/queues/QUEUE. Cloud Tasks posts each
+// task back to baseURL with an OIDC token for serviceAccount, retrying until
+// the receiver succeeds.
+func NewCloudQueue(ctx context.Context, queue, baseURL, serviceAccount string) (Queue, error) {
+	service, err := cloudtasks.NewService(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &cloudQueue{
+		service:        service,
+		queue:          queue,
+		baseURL:        strings.TrimSuffix(baseURL, "/"),
+		serviceAccount: serviceAccount,
+	}, nil
+}
+
+func (q *cloudQueue) Enqueue(ctx context.Context, task *Task) error {
+	body, err := json.Marshal(task)
+	if err != nil {
+		return err
+	}
+
+	_, err = q.service.Projects.Locations.Queues.Tasks.Create(q.queue, &cloudtasks.CreateTaskRequest{
+		Task: &cloudtasks.Task{
+			HttpRequest: &cloudtasks.HttpRequest{
+				HttpMethod: "POST",
+				Url:        q.baseURL + ReceiverPath + url.PathEscape(task.Name),
+				Headers:    map[string]string{"Content-Type": "application/json"},
+				Body:       base64.StdEncoding.EncodeToString(body),
+				OidcToken: &cloudtasks.OidcToken{
+					ServiceAccountEmail: q.serviceAccount,
+					Audience:            q.baseURL,
+				},
+			},
+		},
+	}).Context(ctx).Do()
+	if err != nil {
+		return fmt.Errorf("queueing task %s: %w", task.Name, err)
+	}
+	return nil
+}
+
+// CallerVerifier accepts the OIDC tokens Cloud Tasks sends with each task:
+// issued by Google for serviceAccount, with the service's base URL as the
+// audience.
+type CallerVerifier struct {
+	validator      *idtoken.Validator
+	audience       string
+	serviceAccount string
+}
+
+func NewCallerVerifier(ctx context.Context, baseURL, serviceAccount string) (*CallerVerifier, error) {
+	validator, err := idtoken.NewValidator(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CallerVerifier{
+		validator:      validator,
+		audience:       strings.TrimSuffix(baseURL, "/"),
+		serviceAccount: serviceAccount,
+	}, nil
+}
+
+func (v *CallerVerifier) Verify(ctx context.Context, token string) error {
+	payload, err := v.validator.Validate(ctx, token, v.audience)
+	if err != nil {
+		return err
+	}
+
+	if verified, _ := payload.Claims["email_verified"].(bool); !verified || payload.Claims["email"] != v.serviceAccount {
+		return errors.New("token is not for the task queue's service account")
+	}
+	return nil
+}
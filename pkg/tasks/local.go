@@ -0,0 +1,31 @@
+package tasks
+
+import (
+	"context"
+	"log"
+)
+
+type localQueue struct {
+	registry *Registry
+}
+
+// NewLocalQueue runs each task in the background of this process, once.
+// Tasks still running when the process stops are lost, so it suits
+// development and single instances.
+func NewLocalQueue(registry *Registry) Queue {
+	return &localQueue{registry: registry}
+}
+
+func (q *localQueue) Enqueue(ctx context.Context, task *Task) error {
+	if _, ok := q.registry.handlers[task.Name]; !ok {
+		return ErrUnknownTask
+	}
+
+	// The task outlives the request that queued it
+	go func() {
+		if err := q.registry.Run(context.WithoutCancel(ctx), task); err != nil {
+			log.Printf("Task %s failed: %v", task.Name, err)
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,75 @@
+// Package tasks runs long work outside the request that asked for it. Work
+// is queued as a Task and later run by the Handler registered for its name,
+// either in-process or through Cloud Tasks calling back into the service.
+package tasks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+
+	"github.com/spalqui/habitattrack-api/pkg/auth"
+)
+
+var ErrUnknownTask = errors.New("unknown task")
+
+// Task is work for the handler registered under Name, on behalf of the
+// organization OrgID.
+type Task struct {
+	Name    string          `json:"name"`
+	OrgID   string          `json:"orgId,omitempty"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// NewTask encodes payload for the handler registered under name, acting on
+// the organization of ctx.
+func NewTask(ctx context.Context, name string, payload interface{}) (*Task, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	return &Task{Name: name, OrgID: auth.OrgID(ctx), Payload: data}, nil
+}
+
+type Queue interface {
+	Enqueue(ctx context.Context, task *Task) error
+}
+
+// Handler runs a task. Returning an error has the task retried.
+type Handler func(ctx context.Context, payload json.RawMessage) error
+
+type Registry struct {
+	handlers map[string]Handler
+}
+
+func NewRegistry() *Registry {
+	return &Registry{handlers: make(map[string]Handler)}
+}
+
+func (r *Registry) Register(name string, handler Handler) {
+	r.handlers[name] = handler
+}
+
+// Names lists the registered tasks in order.
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.handlers))
+	for name := range r.handlers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Run calls the task's handler with the task's organization in the context.
+func (r *Registry) Run(ctx context.Context, task *Task) error {
+	handler, ok := r.handlers[task.Name]
+	if !ok {
+		return ErrUnknownTask
+	}
+
+	if task.OrgID != "" {
+		ctx = auth.WithPrincipal(ctx, &auth.Principal{OrgID: task.OrgID})
+	}
+	return handler(ctx, task.Payload)
+}
@@ -0,0 +1,150 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const (
+	FieldNamingCamel = "camel"
+	FieldNamingSnake = "snake"
+)
+
+var fieldNaming = FieldNamingCamel
+
+// SetFieldNaming selects the key style of JSON responses. Responses are
+// camelCase; snake is kept for clients written against the old field names.
+func SetFieldNaming(naming string) {
+	fieldNaming = naming
+}
+
+// UnknownFieldError names a request body field that the request doesn't
+// take, such as a misspelt one.
+type UnknownFieldError struct {
+	Field string
+}
+
+func (e *UnknownFieldError) Error() string {
+	return fmt.Sprintf("unknown field %q", e.Field)
+}
+
+// DecodeJSON decodes the request body into v, accepting snake_case keys from
+// older clients as well as camelCase. Fields v doesn't have are rejected
+// with an UnknownFieldError rather than silently dropped.
+func DecodeJSON(r *http.Request, v interface{}) error {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+
+	raw, err := decodeRaw(body)
+	if err != nil {
+		return err
+	}
+
+	normalized, err := json.Marshal(renameKeys(raw, snakeToCamel))
+	if err != nil {
+		return err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(normalized))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		// encoding/json reports unknown fields only in the error's text
+		if field, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+			if unquoted, err := strconv.Unquote(field); err == nil {
+				field = unquoted
+			}
+			return &UnknownFieldError{Field: field}
+		}
+		return err
+	}
+	return nil
+}
+
+func encodeForNaming(data interface{}) (interface{}, error) {
+	if fieldNaming != FieldNamingSnake {
+		return data, nil
+	}
+
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := decodeRaw(encoded)
+	if err != nil {
+		return nil, err
+	}
+
+	return renameKeys(raw, camelToSnake), nil
+}
+
+// decodeRaw keeps numbers as the text they were written as, so renaming
+// keys doesn't round amounts or change how they're formatted.
+func decodeRaw(data []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var raw interface{}
+	if err := decoder.Decode(&raw); err != nil {
+		return nil, err
+	}
+	if decoder.More() {
+		return nil, errors.New("unexpected data after JSON value")
+	}
+	return raw, nil
+}
+
+func renameKeys(value interface{}, rename func(string) string) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		renamed := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			renamed[rename(key)] = renameKeys(item, rename)
+		}
+		return renamed
+	case []interface{}:
+		for i, item := range v {
+			v[i] = renameKeys(item, rename)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+func camelToSnake(s string) string {
+	var b strings.Builder
+	for i, r := range s {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func snakeToCamel(s string) string {
+	if !strings.Contains(s, "_") {
+		return s
+	}
+
+	parts := strings.Split(s, "_")
+	for i := 1; i < len(parts); i++ {
+		if parts[i] != "" {
+			parts[i] = strings.ToUpper(parts[i][:1]) + parts[i][1:]
+		}
+	}
+	return strings.Join(parts, "")
+}
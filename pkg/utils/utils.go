@@ -1,26 +1,159 @@
 package utils
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"net/http"
+	"strings"
+	"time"
+
+	"github.com/spalqui/habitattrack-api/pkg/errorreport"
+	"github.com/spalqui/habitattrack-api/pkg/i18n"
+	"github.com/spalqui/habitattrack-api/pkg/servertiming"
+)
+
+const (
+	ErrorFormatJSON    = "json"
+	ErrorFormatProblem = "problem"
 )
 
+var errorFormat = ErrorFormatJSON
+
+var errorReporter errorreport.Reporter
+
 type ErrorResponse struct {
-	Error   string `json:"error"`
-	Message string `json:"message"`
+	Error   string      `json:"error"`
+	Message string      `json:"message"`
+	Code    string      `json:"code,omitempty"`
+	Details interface{} `json:"details,omitempty"`
+}
+
+type ProblemDetails struct {
+	Type     string      `json:"type"`
+	Title    string      `json:"title"`
+	Status   int         `json:"status"`
+	Detail   string      `json:"detail,omitempty"`
+	Instance string      `json:"instance,omitempty"`
+	Code     string      `json:"code,omitempty"`
+	Details  interface{} `json:"details,omitempty"`
+}
+
+func SetErrorFormat(format string) {
+	errorFormat = format
 }
 
+// SetErrorReporter reports every 5xx error response to reporter.
+func SetErrorReporter(reporter errorreport.Reporter) {
+	errorReporter = reporter
+}
+
+// WriteJSONResponse encodes data before writing the status, so the time
+// spent serializing makes it into the Server-Timing header.
 func WriteJSONResponse(w http.ResponseWriter, statusCode int, data interface{}) {
+	start := time.Now()
+	body, err := encodeForNaming(data)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	var encoded bytes.Buffer
+	if err := json.NewEncoder(&encoded).Encode(body); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	servertiming.FromWriter(w).Add(servertiming.Serialize, time.Since(start))
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(statusCode)
-	json.NewEncoder(w).Encode(data)
+	w.Write(encoded.Bytes())
+}
+
+func WriteErrorResponse(w http.ResponseWriter, r *http.Request, statusCode int, message string) {
+	WriteErrorResponseWithCode(w, r, statusCode, "", message)
+}
+
+// WriteErrorResponseWithCode adds a stable, untranslated code that clients
+// can branch on.
+func WriteErrorResponseWithCode(w http.ResponseWriter, r *http.Request, statusCode int, code, message string) {
+	WriteErrorResponseWithDetails(w, r, statusCode, code, message, nil)
 }
 
-func WriteErrorResponse(w http.ResponseWriter, statusCode int, message string) {
+// WriteErrorResponseWithDetails adds details, data about the error that
+// clients can act on, to the response.
+func WriteErrorResponseWithDetails(w http.ResponseWriter, r *http.Request, statusCode int, code, message string, details interface{}) {
+	errorreport.ReportResponse(errorReporter, r, statusCode, message)
+
+	lang := i18n.Negotiate(r.Header.Get("Accept-Language"))
+	title := i18n.Translate(lang, http.StatusText(statusCode))
+	detail := i18n.Translate(lang, message)
+
+	w.Header().Set("Content-Language", lang)
+	w.Header().Add("Vary", "Accept-Language")
+
+	if wantsProblemDetails(r) {
+		w.Header().Set("Content-Type", "application/problem+json")
+		w.WriteHeader(statusCode)
+		json.NewEncoder(w).Encode(ProblemDetails{
+			Type:     "about:blank",
+			Title:    title,
+			Status:   statusCode,
+			Detail:   detail,
+			Instance: r.URL.Path,
+			Code:     code,
+			Details:  details,
+		})
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(statusCode)
 	json.NewEncoder(w).Encode(ErrorResponse{
-		Error:   http.StatusText(statusCode),
-		Message: message,
+		Error:   title,
+		Message: detail,
+		Code:    code,
+		Details: details,
 	})
 }
+
+// Translate translates message into the language the request prefers.
+func Translate(r *http.Request, message string) string {
+	return i18n.Translate(i18n.Negotiate(r.Header.Get("Accept-Language")), message)
+}
+
+// WriteDecodeError answers a request whose body couldn't be decoded, naming
+// the field when it was one the request doesn't take.
+func WriteDecodeError(w http.ResponseWriter, r *http.Request, statusCode int, err error) {
+	var unknown *UnknownFieldError
+	if errors.As(err, &unknown) {
+		WriteErrorResponseWithDetails(w, r, statusCode, "", "Request body has an unknown field", map[string]string{"field": unknown.Field})
+		return
+	}
+
+	WriteErrorResponse(w, r, statusCode, "Invalid request body")
+}
+
+func wantsProblemDetails(r *http.Request) bool {
+	return errorFormat == ErrorFormatProblem || strings.Contains(r.Header.Get("Accept"), "application/problem+json")
+}
+
+// ParseTimeParam accepts RFC3339 timestamps or YYYY-MM-DD dates. Dates are
+// interpreted in loc at the start of the day, or its last instant when
+// endOfDay is set, so a date-only range includes the whole end day.
+func ParseTimeParam(value string, loc *time.Location, endOfDay bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	day, err := time.ParseInLocation(time.DateOnly, value, loc)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if endOfDay {
+		return day.AddDate(0, 0, 1).Add(-time.Nanosecond), nil
+	}
+
+	return day, nil
+}